@@ -0,0 +1,182 @@
+// Package adaptor implements Schnorr adaptor signatures over the BabyJubJub
+// subgroup generated by babyjub.B8, compatible with the Poseidon EdDSA of
+// babyjub.
+//
+// A pre-signature for the adaptor point T = t * B8 is a signature that
+// becomes a valid babyjub.Signature, accepted by PublicKey.VerifyPoseidon,
+// once it is completed with the adaptor secret t, and then anyone with the
+// pre-signature and the signature can extract t.  This makes the release of
+// a signature reveal a secret, as needed by atomic swaps.
+//
+// The pre-signature holds the final nonce point R = r * B8 + T and
+// S' = r + c * s, where c = Poseidon(R.x, R.y, A.x, A.y, msg) is the
+// challenge of SignPoseidon, and the completed signature is (R, S' + t).
+package adaptor
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// PreSignature is an adaptor pre-signature.
+type PreSignature struct {
+	R8 *babyjub.Point
+	S  *big.Int
+}
+
+// NewAdaptor returns a random adaptor secret t and its adaptor point
+// T = t * B8.
+func NewAdaptor() (*big.Int, *babyjub.Point, error) {
+	t, err := babyjub.NewRandScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, babyjub.NewPoint().Mul(t, babyjub.B8), nil
+}
+
+func checkPoint(p *babyjub.Point) bool {
+	return p != nil && p.X != nil && p.Y != nil && p.InSubGroup()
+}
+
+func checkScalar(s *big.Int) bool {
+	return s != nil && s.Sign() >= 0 && s.Cmp(babyjub.SubOrder) < 0
+}
+
+// challenge returns Poseidon(R.x, R.y, A.x, A.y, msg) mod SubOrder.
+func challenge(r, a *babyjub.Point, msg *big.Int) (*big.Int, error) {
+	hm, err := poseidon.Hash([]*big.Int{r.X, r.Y, a.X, a.Y, msg})
+	if err != nil {
+		return nil, err
+	}
+	return hm.Mod(hm, babyjub.SubOrder), nil
+}
+
+// PreSign computes the pre-signature of msg for the adaptor point T with the
+// private key sk.  The nonce is derived deterministically from the key, msg
+// and T with blake-512, with a prefix that separates it from the nonces of
+// SignPoseidon.
+func PreSign(sk *babyjub.PrivateKey, msg *big.Int, adaptor *babyjub.Point) (*PreSignature, error) {
+	if !checkPoint(adaptor) {
+		return nil, fmt.Errorf("adaptor point not in the subgroup")
+	}
+	h1 := babyjub.Blake512(sk[:])
+	msgBuf := utils.BigIntLEBytes(msg)
+	tComp := adaptor.Compress()
+	rInput := append([]byte("adaptor"), h1[32:]...)
+	rInput = append(rInput, msgBuf[:]...)
+	rInput = append(rInput, tComp[:]...)
+	r := utils.SetBigIntFromLEBytes(new(big.Int), babyjub.Blake512(rInput))
+	r.Mod(r, babyjub.SubOrder)
+
+	R8 := babyjub.NewPoint().Add(babyjub.NewPoint().Mul(r, babyjub.B8), adaptor)
+	A := sk.Public().Point()
+	c, err := challenge(R8, A, msg)
+	if err != nil {
+		return nil, err
+	}
+	S := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	S.Mul(S, c)
+	S.Add(S, r)
+	S.Mod(S, babyjub.SubOrder)
+	return &PreSignature{R8: R8, S: S}, nil
+}
+
+// PreVerify verifies the pre-signature of msg for the adaptor point T under
+// the public key: S' * B8 == R - T + c * A.
+func PreVerify(pk *babyjub.PublicKey, msg *big.Int, adaptor *babyjub.Point,
+	pre *PreSignature) error {
+	if !checkPoint(pk.Point()) || !checkPoint(adaptor) || !checkPoint(pre.R8) {
+		return fmt.Errorf("point not in the subgroup")
+	}
+	if !checkScalar(pre.S) {
+		return fmt.Errorf("pre-signature scalar not in [0, SubOrder)")
+	}
+	c, err := challenge(pre.R8, pk.Point(), msg)
+	if err != nil {
+		return err
+	}
+	right := babyjub.NewPoint().Add(pre.R8, babyjub.NewPoint().Neg(adaptor))
+	right.Add(right, babyjub.NewPoint().Mul(c, pk.Point()))
+	if !babyjub.NewPoint().Mul(pre.S, babyjub.B8).Equal(right) {
+		return fmt.Errorf("invalid pre-signature")
+	}
+	return nil
+}
+
+// Complete completes the pre-signature with the adaptor secret t into a
+// signature (R, S' + t).
+func Complete(pre *PreSignature, t *big.Int) *babyjub.Signature {
+	s := new(big.Int).Add(pre.S, t)
+	s.Mod(s, babyjub.SubOrder)
+	return &babyjub.Signature{R8: babyjub.NewPoint().Set(pre.R8), S: s}
+}
+
+// Extract returns the adaptor secret t = S - S' of the pre-signature and the
+// signature that completes it.  Returns error if the signature is not the
+// completion of the pre-signature for the adaptor point T.
+func Extract(pre *PreSignature, sig *babyjub.Signature, adaptor *babyjub.Point) (*big.Int, error) {
+	if !pre.R8.Equal(sig.R8) {
+		return nil, fmt.Errorf("signature nonce does not match the pre-signature")
+	}
+	t := new(big.Int).Sub(sig.S, pre.S)
+	t.Mod(t, babyjub.SubOrder)
+	if !babyjub.NewPoint().Mul(t, babyjub.B8).Equal(adaptor) {
+		return nil, fmt.Errorf("extracted secret does not match the adaptor point")
+	}
+	return t, nil
+}
+
+// PreSignatureComp represents a compressed pre-signature, with the same
+// layout as babyjub.SignatureComp.
+type PreSignatureComp [64]byte
+
+// MarshalText implements the marshaler for the PreSignatureComp
+func (pComp PreSignatureComp) MarshalText() ([]byte, error) {
+	return utils.Hex(pComp[:]).MarshalText()
+}
+
+// String returns the string representation of the PreSignatureComp
+func (pComp PreSignatureComp) String() string { return utils.Hex(pComp[:]).String() }
+
+// UnmarshalText implements the unmarshaler for the PreSignatureComp
+func (pComp *PreSignatureComp) UnmarshalText(h []byte) error {
+	return utils.HexDecodeInto(pComp[:], h)
+}
+
+// Compress returns the PreSignatureComp for the given PreSignature
+func (pre *PreSignature) Compress() PreSignatureComp {
+	return PreSignatureComp((&babyjub.Signature{R8: pre.R8, S: pre.S}).Compress())
+}
+
+// Decompress returns the PreSignature for the given PreSignatureComp.
+// Returns error if the point decompression fails.
+func (pComp *PreSignatureComp) Decompress() (*PreSignature, error) {
+	sig, err := new(babyjub.Signature).Decompress(*pComp)
+	if err != nil {
+		return nil, err
+	}
+	return &PreSignature{R8: sig.R8, S: sig.S}, nil
+}
+
+// MarshalText implements the marshaler for the PreSignature
+func (pre PreSignature) MarshalText() ([]byte, error) {
+	return pre.Compress().MarshalText()
+}
+
+// UnmarshalText implements the unmarshaler for the PreSignature
+func (pre *PreSignature) UnmarshalText(h []byte) error {
+	var pComp PreSignatureComp
+	if err := pComp.UnmarshalText(h); err != nil {
+		return err
+	}
+	pre2, err := pComp.Decompress()
+	if err != nil {
+		return err
+	}
+	*pre = *pre2
+	return nil
+}
@@ -0,0 +1,97 @@
+package adaptor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptor(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+	msg := big.NewInt(424242)
+	secret, adaptor, err := NewAdaptor()
+	require.Nil(t, err)
+
+	pre, err := PreSign(&sk, msg, adaptor)
+	require.Nil(t, err)
+	require.Nil(t, PreVerify(pk, msg, adaptor, pre))
+
+	// the pre-signature alone is not a valid signature
+	assert.False(t, pk.VerifyPoseidon(msg, &babyjub.Signature{R8: pre.R8, S: pre.S}))
+
+	sig := Complete(pre, secret)
+	assert.True(t, pk.VerifyPoseidon(msg, sig))
+
+	extracted, err := Extract(pre, sig, adaptor)
+	require.Nil(t, err)
+	assert.Equal(t, secret, extracted)
+
+	// deterministic
+	pre2, err := PreSign(&sk, msg, adaptor)
+	require.Nil(t, err)
+	assert.Equal(t, pre.Compress(), pre2.Compress())
+}
+
+func TestAdaptorSwap(t *testing.T) {
+	// Alice knows the secret t; Bob pre-signs his transfer to Alice and
+	// Alice pre-signs her transfer to Bob, both for T.  When Alice
+	// publishes Bob's completed signature, Bob extracts t and completes
+	// Alice's.
+	alice := babyjub.NewRandPrivKey()
+	bob := babyjub.NewRandPrivKey()
+	secret, adaptor, err := NewAdaptor()
+	require.Nil(t, err)
+	msgAlice, msgBob := big.NewInt(1), big.NewInt(2)
+
+	preBob, err := PreSign(&bob, msgBob, adaptor)
+	require.Nil(t, err)
+	require.Nil(t, PreVerify(bob.Public(), msgBob, adaptor, preBob))
+	preAlice, err := PreSign(&alice, msgAlice, adaptor)
+	require.Nil(t, err)
+	require.Nil(t, PreVerify(alice.Public(), msgAlice, adaptor, preAlice))
+
+	sigBob := Complete(preBob, secret)
+	assert.True(t, bob.Public().VerifyPoseidon(msgBob, sigBob))
+
+	t2, err := Extract(preBob, sigBob, adaptor)
+	require.Nil(t, err)
+	sigAlice := Complete(preAlice, t2)
+	assert.True(t, alice.Public().VerifyPoseidon(msgAlice, sigAlice))
+}
+
+func TestAdaptorErrors(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+	msg := big.NewInt(5)
+	secret, adaptor, err := NewAdaptor()
+	require.Nil(t, err)
+	pre, err := PreSign(&sk, msg, adaptor)
+	require.Nil(t, err)
+
+	_, other, err := NewAdaptor()
+	require.Nil(t, err)
+	assert.NotNil(t, PreVerify(pk, msg, other, pre))
+	assert.NotNil(t, PreVerify(pk, big.NewInt(6), adaptor, pre))
+	otherSk := babyjub.NewRandPrivKey()
+	assert.NotNil(t, PreVerify(otherSk.Public(), msg, adaptor, pre))
+
+	// extracting with a wrong signature or adaptor point
+	sig := Complete(pre, secret)
+	_, err = Extract(pre, sig, other)
+	assert.NotNil(t, err)
+	sig2, err := PreSign(&sk, big.NewInt(6), adaptor)
+	require.Nil(t, err)
+	_, err = Extract(pre, Complete(sig2, secret), adaptor)
+	assert.NotNil(t, err)
+
+	// encoding round trip
+	text, err := pre.MarshalText()
+	require.Nil(t, err)
+	var pre2 PreSignature
+	require.Nil(t, pre2.UnmarshalText(text))
+	assert.Nil(t, PreVerify(pk, msg, adaptor, &pre2))
+}
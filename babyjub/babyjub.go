@@ -164,6 +164,34 @@ func (p *Point) Mul(s *big.Int, q *Point) *Point {
 	return p
 }
 
+// Add computes the addition of the Points a and b and stores the result in p,
+// which is also returned.
+func (p *Point) Add(a, b *Point) *Point {
+	r := NewPointProjective().Add(a.Projective(), b.Projective()).Affine()
+	p.X, p.Y = r.X, r.Y
+	return p
+}
+
+// Neg computes the negation of the Point a and stores the result in p, which
+// is also returned.
+func (p *Point) Neg(a *Point) *Point {
+	x := new(big.Int).Neg(a.X)
+	x.Mod(x, constants.Q)
+	p.X, p.Y = x, new(big.Int).Set(a.Y)
+	return p
+}
+
+// Equal returns true when the Points p and q are equal.
+func (p *Point) Equal(q *Point) bool {
+	return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// Coordinates returns the affine coordinates of the Point p.  It makes Point
+// implement poseidon.Point, to be appended to a poseidon.Transcript.
+func (p *Point) Coordinates() (*big.Int, *big.Int) {
+	return p.X, p.Y
+}
+
 // InCurve returns true when the Point p is in the babyjub curve.
 func (p *Point) InCurve() bool {
 	x2 := new(big.Int).Set(p.X)
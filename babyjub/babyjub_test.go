@@ -359,3 +359,21 @@ func BenchmarkBabyjub(b *testing.B) {
 		}
 	})
 }
+
+func TestPointAddNegEqual(t *testing.T) {
+	p := NewPoint().Mul(big.NewInt(5), B8)
+	q := NewPoint().Mul(big.NewInt(7), B8)
+
+	r := NewPoint().Add(p, q)
+	assert.True(t, r.Equal(NewPoint().Mul(big.NewInt(12), B8)))
+	assert.False(t, r.Equal(p))
+
+	n := NewPoint().Neg(p)
+	assert.True(t, n.InCurve())
+	assert.True(t, NewPoint().Add(p, n).Equal(NewPoint()))
+	assert.True(t, n.Equal(NewPoint().Mul(new(big.Int).Sub(SubOrder, big.NewInt(5)), B8)))
+
+	// the result can alias the operands
+	p.Add(p, q)
+	assert.True(t, p.Equal(r))
+}
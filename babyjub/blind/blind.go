@@ -0,0 +1,203 @@
+// Package blind implements blind Schnorr signatures over the BabyJubJub
+// subgroup generated by babyjub.B8, with the Poseidon challenge of babyjub, so
+// the unblinded signatures are babyjub.Signature values accepted by
+// PublicKey.VerifyPoseidon, and the issuer can't link them to the signing
+// sessions.
+//
+// The protocol is the Clause Blind Schnorr of Fuchsbauer and Wolf
+// ("Concurrently Secure Blind Schnorr Signatures"), which resists the ROS
+// attack on concurrent sessions of the classic blind Schnorr: the issuer
+// opens two sessions of the classic protocol, the user blinds both, and the
+// issuer completes only one of them, chosen at random.
+//
+// In each classic session the issuer commits to R = k * B8, the user blinds
+// it into R' = R + alpha * B8 + beta * A, computes the challenge
+// c' = Poseidon(R'.x, R'.y, A.x, A.y, msg) and sends c = c' + beta, the
+// issuer responds with s = k + c * sk, and the user unblinds the signature
+// (R', s + alpha).
+package blind
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// Commitment is the first message, from the issuer to the user, with the
+// nonce points of the two sessions.
+type Commitment struct {
+	R [2]*babyjub.Point `json:"r"`
+}
+
+// Challenge is the second message, from the user to the issuer, with the
+// blinded challenges of the two sessions.
+type Challenge struct {
+	C [2]*big.Int `json:"c"`
+}
+
+// Response is the third message, from the issuer to the user, with the
+// session chosen by the issuer and its response.
+type Response struct {
+	Session int      `json:"session"`
+	S       *big.Int `json:"s"`
+}
+
+func checkPoint(p *babyjub.Point) bool {
+	return p != nil && p.X != nil && p.Y != nil && p.InSubGroup()
+}
+
+func checkScalar(s *big.Int) bool {
+	return s != nil && s.Sign() >= 0 && s.Cmp(babyjub.SubOrder) < 0
+}
+
+// State of a signing session.
+const (
+	stateStart = iota
+	stateCommitted
+	stateDone
+)
+
+// IssuerSession is the state of the issuer in a signing session.
+type IssuerSession struct {
+	sk    *big.Int
+	pk    *babyjub.Point
+	k     [2]*big.Int
+	state int
+}
+
+// NewIssuerSession returns a new signing session of the issuer with private
+// key sk.  A session can only sign once.
+func NewIssuerSession(sk *babyjub.PrivateKey) *IssuerSession {
+	return &IssuerSession{
+		sk: new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder),
+		pk: sk.Public().Point(),
+	}
+}
+
+// Commit generates the random nonces of the two sessions and returns their
+// commitment.
+func (s *IssuerSession) Commit() (*Commitment, error) {
+	if s.state != stateStart {
+		return nil, fmt.Errorf("issuer session already committed")
+	}
+	var c Commitment
+	for i := range s.k {
+		k, err := babyjub.NewRandScalar()
+		if err != nil {
+			return nil, err
+		}
+		s.k[i] = k
+		c.R[i] = babyjub.NewPoint().Mul(k, babyjub.B8)
+	}
+	s.state = stateCommitted
+	return &c, nil
+}
+
+// Respond chooses one of the two sessions at random and returns its response
+// to the blinded challenge.  The nonces are erased, so the session can't
+// respond again.
+func (s *IssuerSession) Respond(ch *Challenge) (*Response, error) {
+	if s.state != stateCommitted {
+		return nil, fmt.Errorf("issuer session not committed or already done")
+	}
+	for _, c := range ch.C {
+		if !checkScalar(c) {
+			return nil, fmt.Errorf("challenge not in [0, SubOrder)")
+		}
+	}
+	b, err := rand.Int(rand.Reader, big.NewInt(2)) //nolint:gomnd
+	if err != nil {
+		return nil, err
+	}
+	i := int(b.Int64())
+	z := new(big.Int).Mul(ch.C[i], s.sk)
+	z.Add(z, s.k[i])
+	z.Mod(z, babyjub.SubOrder)
+	s.k = [2]*big.Int{}
+	s.state = stateDone
+	return &Response{Session: i, S: z}, nil
+}
+
+// UserSession is the state of the user in a signing session.
+type UserSession struct {
+	pk     *babyjub.Point
+	msg    *big.Int
+	r      [2]*babyjub.Point
+	rPrime [2]*babyjub.Point
+	alpha  [2]*big.Int
+	c      [2]*big.Int
+	state  int
+}
+
+// NewUserSession returns a new signing session of the user to obtain the
+// signature of msg under the public key of the issuer.
+func NewUserSession(pk *babyjub.PublicKey, msg *big.Int) (*UserSession, error) {
+	if !checkPoint(pk.Point()) {
+		return nil, fmt.Errorf("public key not in the subgroup")
+	}
+	return &UserSession{pk: pk.Point(), msg: msg}, nil
+}
+
+// Challenge blinds the commitment of the issuer and returns the blinded
+// challenges of the two sessions.
+func (u *UserSession) Challenge(cm *Commitment) (*Challenge, error) {
+	if u.state != stateStart {
+		return nil, fmt.Errorf("user session already challenged")
+	}
+	var ch Challenge
+	for i := range cm.R {
+		if !checkPoint(cm.R[i]) {
+			return nil, fmt.Errorf("commitment not in the subgroup")
+		}
+		alpha, err := babyjub.NewRandScalar()
+		if err != nil {
+			return nil, err
+		}
+		beta, err := babyjub.NewRandScalar()
+		if err != nil {
+			return nil, err
+		}
+		// R' = R + alpha * B8 + beta * A
+		rPrime := babyjub.NewPoint().Add(cm.R[i], babyjub.NewPoint().Mul(alpha, babyjub.B8))
+		rPrime.Add(rPrime, babyjub.NewPoint().Mul(beta, u.pk))
+		hm, err := poseidon.Hash([]*big.Int{rPrime.X, rPrime.Y, u.pk.X, u.pk.Y, u.msg})
+		if err != nil {
+			return nil, err
+		}
+		// c = c' + beta
+		c := hm.Mod(hm, babyjub.SubOrder)
+		c.Add(c, beta)
+		c.Mod(c, babyjub.SubOrder)
+		u.r[i], u.rPrime[i], u.alpha[i], u.c[i] = cm.R[i], rPrime, alpha, c
+		ch.C[i] = new(big.Int).Set(c)
+	}
+	u.state = stateCommitted
+	return &ch, nil
+}
+
+// Unblind checks the response of the issuer and returns the unblinded
+// signature of the session chosen by the issuer.
+func (u *UserSession) Unblind(r *Response) (*babyjub.Signature, error) {
+	if u.state != stateCommitted {
+		return nil, fmt.Errorf("user session not challenged or already done")
+	}
+	if r.Session != 0 && r.Session != 1 {
+		return nil, fmt.Errorf("invalid session %d", r.Session)
+	}
+	if !checkScalar(r.S) {
+		return nil, fmt.Errorf("response not in [0, SubOrder)")
+	}
+	i := r.Session
+	// s * B8 == R + c * A
+	right := babyjub.NewPoint().Add(u.r[i], babyjub.NewPoint().Mul(u.c[i], u.pk))
+	if !babyjub.NewPoint().Mul(r.S, babyjub.B8).Equal(right) {
+		return nil, fmt.Errorf("invalid response")
+	}
+	s := new(big.Int).Add(r.S, u.alpha[i])
+	s.Mod(s, babyjub.SubOrder)
+	u.state = stateDone
+	return &babyjub.Signature{R8: u.rPrime[i], S: s}, nil
+}
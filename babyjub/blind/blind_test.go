@@ -0,0 +1,92 @@
+package blind
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTrip serializes and deserializes the message into dst, as it would be
+// sent over the network.
+func roundTrip(t *testing.T, src, dst interface{}) {
+	b, err := json.Marshal(src)
+	require.Nil(t, err)
+	require.Nil(t, json.Unmarshal(b, dst))
+}
+
+func TestBlindSignature(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+	msg := big.NewInt(31337)
+
+	for i := 0; i < 8; i++ {
+		issuer := NewIssuerSession(&sk)
+		user, err := NewUserSession(pk, msg)
+		require.Nil(t, err)
+
+		cm, err := issuer.Commit()
+		require.Nil(t, err)
+		var cm2 Commitment
+		roundTrip(t, cm, &cm2)
+		ch, err := user.Challenge(&cm2)
+		require.Nil(t, err)
+		var ch2 Challenge
+		roundTrip(t, ch, &ch2)
+		resp, err := issuer.Respond(&ch2)
+		require.Nil(t, err)
+		var resp2 Response
+		roundTrip(t, resp, &resp2)
+		sig, err := user.Unblind(&resp2)
+		require.Nil(t, err)
+
+		assert.True(t, pk.VerifyPoseidon(msg, sig))
+		assert.False(t, pk.VerifyPoseidon(big.NewInt(1), sig))
+		// the signature nonce is blinded
+		assert.False(t, sig.R8.Equal(cm.R[resp.Session]))
+	}
+}
+
+func TestBlindStateMachine(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+	msg := big.NewInt(1)
+
+	issuer := NewIssuerSession(&sk)
+	user, err := NewUserSession(pk, msg)
+	require.Nil(t, err)
+
+	_, err = issuer.Respond(&Challenge{C: [2]*big.Int{big.NewInt(1), big.NewInt(2)}})
+	assert.NotNil(t, err)
+	_, err = user.Unblind(&Response{S: big.NewInt(1)})
+	assert.NotNil(t, err)
+
+	cm, err := issuer.Commit()
+	require.Nil(t, err)
+	_, err = issuer.Commit()
+	assert.NotNil(t, err)
+	ch, err := user.Challenge(cm)
+	require.Nil(t, err)
+	_, err = user.Challenge(cm)
+	assert.NotNil(t, err)
+
+	// a tampered response is detected
+	resp, err := issuer.Respond(ch)
+	require.Nil(t, err)
+	_, err = issuer.Respond(ch)
+	assert.NotNil(t, err)
+	bad := &Response{Session: resp.Session, S: new(big.Int).Add(resp.S, big.NewInt(1))}
+	_, err = user.Unblind(bad)
+	assert.NotNil(t, err)
+	_, err = user.Unblind(&Response{Session: 2, S: resp.S})
+	assert.NotNil(t, err)
+
+	sig, err := user.Unblind(resp)
+	require.Nil(t, err)
+	assert.True(t, pk.VerifyPoseidon(msg, sig))
+	_, err = user.Unblind(resp)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,119 @@
+package dkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Types of the messages of an Envelope.
+const (
+	TypeRound1Broadcast = "round1_broadcast"
+	TypeRound1Share     = "round1_share"
+	TypeComplaint       = "complaint"
+	TypeJustification   = "justification"
+)
+
+// Envelope is a serialized DKG message together with its type.
+type Envelope struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope serializes the message, which must be one of the DKG messages,
+// into an Envelope.
+func NewEnvelope(msg interface{}) (*Envelope, error) {
+	var typ string
+	switch msg.(type) {
+	case *Round1Broadcast:
+		typ = TypeRound1Broadcast
+	case *Round1Share:
+		typ = TypeRound1Share
+	case *Complaint:
+		typ = TypeComplaint
+	case *Justification:
+		typ = TypeJustification
+	default:
+		return nil, fmt.Errorf("unknown message type %T", msg)
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{Type: typ, Payload: payload}, nil
+}
+
+// Decode deserializes the message of the Envelope.
+func (e *Envelope) Decode() (interface{}, error) {
+	var msg interface{}
+	switch e.Type {
+	case TypeRound1Broadcast:
+		msg = &Round1Broadcast{}
+	case TypeRound1Share:
+		msg = &Round1Share{}
+	case TypeComplaint:
+		msg = &Complaint{}
+	case TypeJustification:
+		msg = &Justification{}
+	default:
+		return nil, fmt.Errorf("unknown message type %q", e.Type)
+	}
+	if err := json.Unmarshal(e.Payload, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Bus is an in-memory message bus to run a DKG in process, in tests and
+// simulations.  The messages are serialized into envelopes, as they would be
+// sent over the network, and queued for their recipients.
+type Bus struct {
+	mu     sync.Mutex
+	n      int
+	queues map[int][]*Envelope
+}
+
+// NewBus returns a bus for the participants with IDs 1 to n.
+func NewBus(n int) *Bus {
+	return &Bus{n: n, queues: make(map[int][]*Envelope, n)}
+}
+
+// Broadcast sends the message from the participant to all the others.
+func (b *Bus) Broadcast(from int, msg interface{}) error {
+	e, err := NewEnvelope(msg)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := 1; i <= b.n; i++ {
+		if i != from {
+			b.queues[i] = append(b.queues[i], e)
+		}
+	}
+	return nil
+}
+
+// Send sends the message to the participant to.
+func (b *Bus) Send(to int, msg interface{}) error {
+	if to < 1 || to > b.n {
+		return fmt.Errorf("invalid recipient %d", to)
+	}
+	e, err := NewEnvelope(msg)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queues[to] = append(b.queues[to], e)
+	return nil
+}
+
+// Receive returns and removes the messages queued for the participant.
+func (b *Bus) Receive(id int) []*Envelope {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msgs := b.queues[id]
+	delete(b.queues, id)
+	return msgs
+}
@@ -0,0 +1,394 @@
+// Package dkg implements a Pedersen distributed key generation (Joint-Feldman
+// with complaints, as in Gennaro et al. "Secure Distributed Key Generation
+// for Discrete-Log Based Cryptosystems") over the BabyJubJub subgroup
+// generated by babyjub.B8, so that a group of n participants obtains a joint
+// public key and threshold-of-n shares of its private key, which is never
+// known by anyone.
+//
+// The protocol runs as follows:
+//   - Round 1: each participant samples a polynomial of degree threshold - 1,
+//     broadcasts a Round1Broadcast with the Feldman commitments to its
+//     coefficients and a Schnorr proof of knowledge of the constant term,
+//     bound to the session ID of the DKG and the participant ID, and sends
+//     to each other participant its Round1Share.  The shares must be sent
+//     over confidential and authenticated channels.
+//   - Round 2: each participant checks the shares it received against the
+//     commitments, and broadcasts a Complaint against each participant whose
+//     share is missing or invalid.
+//   - Round 3: each accused participant answers every complaint against it
+//     with a Justification that reveals the disputed share.
+//   - Finalize: the participants with invalid broadcasts, or with complaints
+//     that were not answered with a valid share, are disqualified, and the
+//     key share is the sum of the shares of the qualified participants.
+//
+// The session ID must be unique to each run of the DKG, for example a random
+// field element agreed by the participants, so that the proofs of knowledge
+// of one run can't be replayed in another one.
+//
+// All the messages are plain structs that can be serialized to JSON, see Bus
+// for an in-memory transport.
+package dkg
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/babyjub/frost"
+	"github.com/iden3/go-iden3-crypto/babyjub/sigma"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// Round1Broadcast is the broadcast message of a participant in round 1: the
+// commitments a_k * B8 to the coefficients of its polynomial, and a proof of
+// knowledge of a_0 bound to the session ID and the participant ID.
+type Round1Broadcast struct {
+	From        int                  `json:"from"`
+	Commitments []*babyjub.PublicKey `json:"commitments"`
+	Proof       *sigma.SchnorrProof  `json:"proof"`
+}
+
+// Round1Share is the private message from a participant to another one in
+// round 1: the evaluation of the polynomial of the sender at the ID of the
+// recipient.
+type Round1Share struct {
+	From  int      `json:"from"`
+	To    int      `json:"to"`
+	Share *big.Int `json:"share"`
+}
+
+// Complaint is the broadcast message of a participant in round 2 accusing
+// another one of having sent a missing or invalid share.
+type Complaint struct {
+	From    int `json:"from"`
+	Against int `json:"against"`
+}
+
+// Justification is the broadcast message of an accused participant in round
+// 3, revealing the share it sent to the participant that complained.
+type Justification struct {
+	From  int      `json:"from"`
+	To    int      `json:"to"`
+	Share *big.Int `json:"share"`
+}
+
+// Result is the output of the DKG for a participant.
+type Result struct {
+	ID        int
+	Threshold int
+	// Secret is the share of the joint private key of the participant.
+	Secret *big.Int
+	// PublicKey is the joint public key.
+	PublicKey *babyjub.PublicKey
+	// VerificationShares are the public keys Secret * B8 of the shares of
+	// all the participants.
+	VerificationShares map[int]*babyjub.Point
+	// Qualified are the IDs of the participants that were not
+	// disqualified.
+	Qualified []int
+}
+
+// KeyShare returns the key share of the participant, to sign with frost.
+func (r *Result) KeyShare() *frost.KeyShare {
+	return &frost.KeyShare{ID: r.ID, Secret: new(big.Int).Set(r.Secret), GroupKey: r.PublicKey}
+}
+
+// Group returns the public information of the group, to aggregate frost
+// signatures.
+func (r *Result) Group() *frost.Group {
+	return &frost.Group{Threshold: r.Threshold, Key: r.PublicKey, Shares: r.VerificationShares}
+}
+
+// Participant is the state of a participant of the DKG.
+type Participant struct {
+	sessionID *big.Int
+	id        int
+	threshold int
+	n         int
+	coefs     []*big.Int
+
+	commitments    map[int][]*babyjub.Point
+	shares         map[int]*big.Int
+	disqualified   map[int]bool
+	complaints     map[[2]int]bool
+	justifications map[[2]int]*big.Int
+}
+
+// NewParticipant returns the participant id, in [1, n], of the DKG with the
+// session ID for a threshold of n participants.
+func NewParticipant(sessionID *big.Int, id, threshold, n int) (*Participant, error) {
+	if sessionID == nil || !utils.CheckBigIntInField(sessionID) {
+		return nil, fmt.Errorf("session id must be inside the finite field")
+	}
+	if threshold < 1 || threshold > n {
+		return nil, fmt.Errorf("invalid threshold %d of %d", threshold, n)
+	}
+	if id < 1 || id > n {
+		return nil, fmt.Errorf("invalid participant id %d", id)
+	}
+	return &Participant{
+		sessionID:      new(big.Int).Set(sessionID),
+		id:             id,
+		threshold:      threshold,
+		n:              n,
+		commitments:    make(map[int][]*babyjub.Point),
+		shares:         make(map[int]*big.Int),
+		disqualified:   make(map[int]bool),
+		complaints:     make(map[[2]int]bool),
+		justifications: make(map[[2]int]*big.Int),
+	}, nil
+}
+
+// ID returns the participant ID.
+func (p *Participant) ID() int {
+	return p.id
+}
+
+// proofContext returns the message Poseidon(sessionID, id) the proof of
+// knowledge of the participant id is bound to.
+func (p *Participant) proofContext(id int) (*big.Int, error) {
+	return poseidon.Hash([]*big.Int{p.sessionID, big.NewInt(int64(id))})
+}
+
+// evalCommitments returns sum(C_k * x^k), the commitment to the evaluation of
+// the committed polynomial at x.
+func evalCommitments(cs []*babyjub.Point, x int) *babyjub.Point {
+	xb := big.NewInt(int64(x))
+	xk := big.NewInt(1)
+	acc := babyjub.NewPointProjective()
+	for _, c := range cs {
+		acc = babyjub.NewPointProjective().Add(acc, babyjub.NewPoint().Mul(xk, c).Projective())
+		xk = new(big.Int).Mul(xk, xb)
+		xk.Mod(xk, babyjub.SubOrder)
+	}
+	return acc.Affine()
+}
+
+func checkShare(cs []*babyjub.Point, x int, share *big.Int) bool {
+	if share == nil || share.Sign() < 0 || share.Cmp(babyjub.SubOrder) >= 0 {
+		return false
+	}
+	return babyjub.NewPoint().Mul(share, babyjub.B8).Equal(evalCommitments(cs, x))
+}
+
+// Round1 samples the polynomial of the participant, and returns its broadcast
+// message together with the shares for the other participants.
+func (p *Participant) Round1() (*Round1Broadcast, []*Round1Share, error) {
+	if p.coefs != nil {
+		return nil, nil, fmt.Errorf("round 1 already done")
+	}
+	coefs := make([]*big.Int, p.threshold)
+	for i := range coefs {
+		var err error
+		if coefs[i], err = rand.Int(rand.Reader, babyjub.SubOrder); err != nil {
+			return nil, nil, err
+		}
+	}
+	a0 := babyjub.PrivateKey(utils.BigIntLEBytes(coefs[0]))
+	ctx, err := p.proofContext(p.id)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := sigma.ProveSchnorr(&a0, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.coefs = coefs
+
+	b := &Round1Broadcast{From: p.id, Proof: proof}
+	cs := make([]*babyjub.Point, len(coefs))
+	for i, a := range coefs {
+		cs[i] = babyjub.NewPoint().Mul(a, babyjub.B8)
+		pk := babyjub.PublicKey(*cs[i])
+		b.Commitments = append(b.Commitments, &pk)
+	}
+	p.commitments[p.id] = cs
+	p.shares[p.id] = babyjub.EvalPolynomial(coefs, p.id)
+
+	var shares []*Round1Share
+	for j := 1; j <= p.n; j++ {
+		if j != p.id {
+			shares = append(shares, &Round1Share{From: p.id, To: j, Share: babyjub.EvalPolynomial(coefs, j)})
+		}
+	}
+	return b, shares, nil
+}
+
+// ReceiveBroadcast processes the round 1 broadcast of another participant.  A
+// participant with an invalid broadcast is disqualified, and the error is
+// returned.
+func (p *Participant) ReceiveBroadcast(b *Round1Broadcast) error {
+	if b.From < 1 || b.From > p.n || b.From == p.id {
+		return fmt.Errorf("broadcast from invalid participant %d", b.From)
+	}
+	if _, ok := p.commitments[b.From]; ok {
+		return fmt.Errorf("duplicated broadcast from participant %d", b.From)
+	}
+	if len(b.Commitments) != p.threshold {
+		p.disqualified[b.From] = true
+		return fmt.Errorf("participant %d sent %d commitments, want %d", b.From,
+			len(b.Commitments), p.threshold)
+	}
+	cs := make([]*babyjub.Point, len(b.Commitments))
+	for i, c := range b.Commitments {
+		if c == nil || c.X == nil || c.Y == nil || !c.Point().InSubGroup() {
+			p.disqualified[b.From] = true
+			return fmt.Errorf("participant %d sent an invalid commitment", b.From)
+		}
+		cs[i] = c.Point()
+	}
+	ctx, err := p.proofContext(b.From)
+	if err != nil {
+		return err
+	}
+	if b.Proof == nil || sigma.VerifySchnorr(b.Commitments[0], ctx, b.Proof) != nil {
+		p.disqualified[b.From] = true
+		return fmt.Errorf("participant %d sent an invalid proof of knowledge", b.From)
+	}
+	p.commitments[b.From] = cs
+	return nil
+}
+
+// ReceiveShare processes the round 1 share sent by another participant.  The
+// share is checked in Round2, once the broadcast of the sender is known.
+func (p *Participant) ReceiveShare(s *Round1Share) error {
+	if s.To != p.id {
+		return fmt.Errorf("share for participant %d", s.To)
+	}
+	if s.From < 1 || s.From > p.n || s.From == p.id {
+		return fmt.Errorf("share from invalid participant %d", s.From)
+	}
+	if _, ok := p.shares[s.From]; ok {
+		return fmt.Errorf("duplicated share from participant %d", s.From)
+	}
+	p.shares[s.From] = s.Share
+	return nil
+}
+
+// Round2 checks the received shares and returns the complaints to broadcast
+// against the participants whose share is missing or doesn't match their
+// commitments.  Participants without a valid broadcast are disqualified
+// instead.
+func (p *Participant) Round2() []*Complaint {
+	var complaints []*Complaint
+	for i := 1; i <= p.n; i++ {
+		if i == p.id {
+			continue
+		}
+		cs, ok := p.commitments[i]
+		if !ok {
+			p.disqualified[i] = true
+			continue
+		}
+		if !checkShare(cs, p.id, p.shares[i]) {
+			c := &Complaint{From: p.id, Against: i}
+			p.complaints[[2]int{c.From, c.Against}] = true
+			complaints = append(complaints, c)
+		}
+	}
+	return complaints
+}
+
+// ReceiveComplaint processes a complaint broadcast by another participant.
+// If the complaint is against this participant, it returns the justification
+// to broadcast.
+func (p *Participant) ReceiveComplaint(c *Complaint) (*Justification, error) {
+	if c.From < 1 || c.From > p.n || c.Against < 1 || c.Against > p.n || c.From == c.Against {
+		return nil, fmt.Errorf("invalid complaint from %d against %d", c.From, c.Against)
+	}
+	p.complaints[[2]int{c.From, c.Against}] = true
+	if c.Against != p.id {
+		return nil, nil
+	}
+	if p.coefs == nil {
+		return nil, fmt.Errorf("round 1 not done")
+	}
+	j := &Justification{From: p.id, To: c.From, Share: babyjub.EvalPolynomial(p.coefs, c.From)}
+	p.justifications[[2]int{j.To, j.From}] = j.Share
+	return j, nil
+}
+
+// ReceiveJustification processes a justification broadcast by an accused
+// participant.  Invalid justifications are ignored, so the accused
+// participant is disqualified in Finalize.
+func (p *Participant) ReceiveJustification(j *Justification) error {
+	key := [2]int{j.To, j.From}
+	if !p.complaints[key] {
+		return fmt.Errorf("justification from %d to %d without complaint", j.From, j.To)
+	}
+	cs, ok := p.commitments[j.From]
+	if !ok {
+		return fmt.Errorf("justification from disqualified participant %d", j.From)
+	}
+	if !checkShare(cs, j.To, j.Share) {
+		return fmt.Errorf("invalid justification from %d to %d", j.From, j.To)
+	}
+	p.justifications[key] = j.Share
+	return nil
+}
+
+// Finalize disqualifies the participants with unanswered complaints, and
+// returns the result of the DKG.  It returns error if this participant is
+// disqualified or if fewer than threshold participants are qualified.
+func (p *Participant) Finalize() (*Result, error) {
+	if p.coefs == nil {
+		return nil, fmt.Errorf("round 1 not done")
+	}
+	for key := range p.complaints {
+		accuser, accused := key[0], key[1]
+		share, ok := p.justifications[key]
+		if !ok {
+			p.disqualified[accused] = true
+			continue
+		}
+		if accuser == p.id {
+			p.shares[accused] = share
+		}
+	}
+	if p.disqualified[p.id] {
+		return nil, fmt.Errorf("participant %d disqualified", p.id)
+	}
+
+	var qual []int
+	for i := 1; i <= p.n; i++ {
+		if _, ok := p.commitments[i]; ok && !p.disqualified[i] {
+			qual = append(qual, i)
+		}
+	}
+	sort.Ints(qual)
+	if len(qual) < p.threshold {
+		return nil, fmt.Errorf("%d qualified participants, threshold is %d", len(qual), p.threshold)
+	}
+
+	secret := big.NewInt(0)
+	pk := babyjub.NewPointProjective()
+	for _, i := range qual {
+		if !checkShare(p.commitments[i], p.id, p.shares[i]) {
+			return nil, fmt.Errorf("invalid share from qualified participant %d", i)
+		}
+		secret.Add(secret, p.shares[i])
+		pk = babyjub.NewPointProjective().Add(pk, p.commitments[i][0].Projective())
+	}
+	secret.Mod(secret, babyjub.SubOrder)
+
+	vShares := make(map[int]*babyjub.Point, p.n)
+	for j := 1; j <= p.n; j++ {
+		acc := babyjub.NewPointProjective()
+		for _, i := range qual {
+			acc = babyjub.NewPointProjective().Add(acc, evalCommitments(p.commitments[i], j).Projective())
+		}
+		vShares[j] = acc.Affine()
+	}
+	publicKey := babyjub.PublicKey(*pk.Affine())
+	return &Result{
+		ID:                 p.id,
+		Threshold:          p.threshold,
+		Secret:             secret,
+		PublicKey:          &publicKey,
+		VerificationShares: vShares,
+		Qualified:          qual,
+	}, nil
+}
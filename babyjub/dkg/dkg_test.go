@@ -0,0 +1,211 @@
+package dkg
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/babyjub/frost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deliver decodes and processes the messages queued for the participant,
+// broadcasting the justifications it has to answer with.
+func deliver(t *testing.T, bus *Bus, p *Participant) {
+	for _, e := range bus.Receive(p.ID()) {
+		msg, err := e.Decode()
+		require.Nil(t, err)
+		switch m := msg.(type) {
+		case *Round1Broadcast:
+			_ = p.ReceiveBroadcast(m)
+		case *Round1Share:
+			require.Nil(t, p.ReceiveShare(m))
+		case *Complaint:
+			j, err := p.ReceiveComplaint(m)
+			require.Nil(t, err)
+			if j != nil {
+				require.Nil(t, bus.Broadcast(p.ID(), j))
+			}
+		case *Justification:
+			_ = p.ReceiveJustification(m)
+		}
+	}
+}
+
+// testSessionID is the session ID of the DKG runs of the tests.
+var testSessionID = big.NewInt(42)
+
+// run runs the DKG, letting tamper modify the round 1 messages of each
+// participant before they are sent.
+func run(t *testing.T, threshold, n int,
+	tamper func(b *Round1Broadcast, shares []*Round1Share) []*Round1Share) []*Result {
+	bus := NewBus(n)
+	ps := make([]*Participant, n)
+	for i := range ps {
+		var err error
+		ps[i], err = NewParticipant(testSessionID, i+1, threshold, n)
+		require.Nil(t, err)
+	}
+	for _, p := range ps {
+		b, shares, err := p.Round1()
+		require.Nil(t, err)
+		if tamper != nil {
+			shares = tamper(b, shares)
+		}
+		require.Nil(t, bus.Broadcast(p.ID(), b))
+		for _, s := range shares {
+			require.Nil(t, bus.Send(s.To, s))
+		}
+	}
+	for _, p := range ps {
+		deliver(t, bus, p)
+	}
+	for _, p := range ps {
+		for _, c := range p.Round2() {
+			require.Nil(t, bus.Broadcast(p.ID(), c))
+		}
+	}
+	// complaints, and then the justifications they trigger
+	for _, p := range ps {
+		deliver(t, bus, p)
+	}
+	for _, p := range ps {
+		deliver(t, bus, p)
+	}
+	results := make([]*Result, n)
+	for i, p := range ps {
+		results[i], _ = p.Finalize()
+	}
+	return results
+}
+
+// reconstruct interpolates the secret from the shares of the results.
+func reconstruct(results []*Result) *big.Int {
+	xs := make([]int, len(results))
+	ys := make([]*big.Int, len(results))
+	for i, r := range results {
+		xs[i], ys[i] = r.ID, r.Secret
+	}
+	return babyjub.Interpolate(xs, ys, 0)
+}
+
+func checkResults(t *testing.T, results []*Result, qualified []int) {
+	for _, r := range results {
+		require.NotNil(t, r)
+		assert.Equal(t, qualified, r.Qualified)
+		assert.True(t, r.PublicKey.Point().Equal(results[0].PublicKey.Point()))
+		assert.True(t, babyjub.NewPoint().Mul(r.Secret, babyjub.B8).Equal(r.VerificationShares[r.ID]))
+	}
+	s := reconstruct(results[:results[0].Threshold])
+	assert.True(t, babyjub.NewPoint().Mul(s, babyjub.B8).Equal(results[0].PublicKey.Point()))
+	assert.Equal(t, s, reconstruct(results[len(results)-results[0].Threshold:]))
+}
+
+func TestDKG(t *testing.T) {
+	results := run(t, 3, 5, nil)
+	checkResults(t, results, []int{1, 2, 3, 4, 5})
+
+	// the key shares sign with frost for the joint public key
+	msg := big.NewInt(1234)
+	signers := []*frost.KeyShare{results[0].KeyShare(), results[2].KeyShare(), results[4].KeyShare()}
+	var nonces []*frost.Nonces
+	var commitments []*frost.Commitment
+	for _, ks := range signers {
+		n, err := frost.Commit(ks)
+		require.Nil(t, err)
+		nonces = append(nonces, n)
+		commitments = append(commitments, n.Commitment)
+	}
+	var shares []*frost.SignatureShare
+	for i, ks := range signers {
+		sh, err := frost.Sign(ks, nonces[i], msg, commitments)
+		require.Nil(t, err)
+		shares = append(shares, sh)
+	}
+	sig, err := frost.Aggregate(results[1].Group(), msg, commitments, shares)
+	require.Nil(t, err)
+	assert.True(t, results[3].PublicKey.VerifyPoseidon(msg, sig))
+}
+
+func TestDKGJustifiedComplaint(t *testing.T) {
+	// participant 2 sends a wrong share to participant 4, which complains,
+	// and then reveals the right share
+	results := run(t, 2, 4, func(b *Round1Broadcast, shares []*Round1Share) []*Round1Share {
+		for _, s := range shares {
+			if s.From == 2 && s.To == 4 {
+				s.Share = new(big.Int).Add(s.Share, big.NewInt(1))
+			}
+		}
+		return shares
+	})
+	checkResults(t, results, []int{1, 2, 3, 4})
+}
+
+func TestDKGDisqualification(t *testing.T) {
+	// participant 3 doesn't send its share to participant 1, and
+	// participant 4 sends an invalid proof of knowledge
+	results := run(t, 2, 4, func(b *Round1Broadcast, shares []*Round1Share) []*Round1Share {
+		if b.From == 4 {
+			b.Proof.Z = new(big.Int).Add(b.Proof.Z, big.NewInt(1))
+		}
+		if b.From == 3 {
+			return shares[1:]
+		}
+		return shares
+	})
+	// participant 3 answers the complaint of 1 and stays qualified, the
+	// view of the dishonest participant 4 is irrelevant
+	checkResults(t, results[:3], []int{1, 2, 3})
+}
+
+func TestDKGErrors(t *testing.T) {
+	_, err := NewParticipant(testSessionID, 1, 0, 3)
+	assert.NotNil(t, err)
+	_, err = NewParticipant(testSessionID, 4, 2, 3)
+	assert.NotNil(t, err)
+	_, err = NewParticipant(nil, 1, 2, 3)
+	assert.NotNil(t, err)
+
+	p, err := NewParticipant(testSessionID, 1, 2, 3)
+	require.Nil(t, err)
+	_, err = p.Finalize()
+	assert.NotNil(t, err)
+	_, _, err = p.Round1()
+	require.Nil(t, err)
+	_, _, err = p.Round1()
+	assert.NotNil(t, err)
+
+	// without broadcasts every other participant is disqualified
+	assert.Empty(t, p.Round2())
+	_, err = p.Finalize()
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, p.ReceiveShare(&Round1Share{From: 2, To: 3, Share: big.NewInt(1)}))
+	_, err = p.ReceiveComplaint(&Complaint{From: 2, Against: 2})
+	assert.NotNil(t, err)
+	assert.NotNil(t, p.ReceiveJustification(&Justification{From: 2, To: 3, Share: big.NewInt(1)}))
+
+	_, err = NewEnvelope(42)
+	assert.NotNil(t, err)
+	_, err = (&Envelope{Type: "unknown"}).Decode()
+	assert.NotNil(t, err)
+}
+
+func TestDKGSessionID(t *testing.T) {
+	p1, err := NewParticipant(testSessionID, 1, 2, 3)
+	require.Nil(t, err)
+	b, _, err := p1.Round1()
+	require.Nil(t, err)
+
+	// the broadcast is accepted in the same session
+	p2, err := NewParticipant(testSessionID, 2, 2, 3)
+	require.Nil(t, err)
+	require.Nil(t, p2.ReceiveBroadcast(b))
+
+	// but the proof of knowledge can't be replayed in another session
+	other, err := NewParticipant(big.NewInt(43), 2, 2, 3)
+	require.Nil(t, err)
+	assert.NotNil(t, other.ReceiveBroadcast(b))
+	assert.True(t, other.disqualified[1])
+}
@@ -0,0 +1,30 @@
+package babyjub
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// isIdentity returns true when the Point p is the identity (0, 1).
+func (p *Point) isIdentity() bool {
+	return p.Equal(NewPoint())
+}
+
+// SharedSecret computes the ECDH shared secret point between the private key
+// and the public key pk: s * pk, where s is the scalar of the private key.
+// Returns error if pk is not in the subgroup generated by B8, if pk is the
+// identity or if the resulting shared point is the identity.
+func (k *PrivateKey) SharedSecret(pk *PublicKey) (*Point, error) {
+	if !pk.Point().InSubGroup() {
+		return nil, fmt.Errorf("public key not in the subgroup")
+	}
+	if pk.Point().isIdentity() {
+		return nil, fmt.Errorf("public key is the identity")
+	}
+	s := new(big.Int).Mod(k.Scalar().BigInt(), SubOrder)
+	shared := NewPoint().Mul(s, pk.Point())
+	if shared.isIdentity() {
+		return nil, fmt.Errorf("shared secret is the identity")
+	}
+	return shared, nil
+}
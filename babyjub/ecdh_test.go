@@ -0,0 +1,49 @@
+package babyjub
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedSecret(t *testing.T) {
+	var ka, kb PrivateKey
+	ka = utils.BigIntLEBytes(big.NewInt(56869496543825))
+	kb = utils.BigIntLEBytes(big.NewInt(1234567890))
+
+	sab, err := ka.SharedSecret(kb.Public())
+	require.Nil(t, err)
+	sba, err := kb.SharedSecret(ka.Public())
+	require.Nil(t, err)
+	assert.Equal(t, sab, sba)
+	assert.True(t, sab.InSubGroup())
+
+	// (a * b) * B8
+	ab := new(big.Int).Mul(big.NewInt(56869496543825), big.NewInt(1234567890))
+	assert.Equal(t, NewPoint().Mul(ab, B8), sab)
+}
+
+func TestSharedSecretErrors(t *testing.T) {
+	k := NewRandPrivKey()
+
+	// identity
+	id := PublicKey(*NewPoint())
+	_, err := k.SharedSecret(&id)
+	assert.Equal(t, "public key is the identity", err.Error())
+
+	// point in the curve but not in the subgroup (order 2)
+	low := PublicKey(Point{X: big.NewInt(0), Y: new(big.Int).Sub(constants.Q, big.NewInt(1))})
+	require.True(t, low.Point().InCurve())
+	_, err = k.SharedSecret(&low)
+	assert.Equal(t, "public key not in the subgroup", err.Error())
+
+	// private key multiple of SubOrder
+	var kz PrivateKey
+	kz = utils.BigIntLEBytes(SubOrder)
+	_, err = kz.SharedSecret(k.Public())
+	assert.Equal(t, "shared secret is the identity", err.Error())
+}
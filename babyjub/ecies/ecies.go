@@ -0,0 +1,100 @@
+// Package ecies implements ECIES hybrid encryption to BabyJubJub public keys:
+// an ephemeral BabyJubJub key agrees a shared point with the recipient public
+// key, a symmetric key is derived from it with HKDF-SHA256 and the plaintext
+// is encrypted with ChaCha20-Poly1305.
+//
+// The ciphertext is the compressed ephemeral public key (32 bytes) followed by
+// the AEAD ciphertext and tag.  As every message uses a fresh ephemeral key,
+// and thus a fresh symmetric key, the AEAD nonce is fixed to zero.
+package ecies
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Info is the HKDF info string that binds the derived keys to this scheme.
+const Info = "babyjub-ecies-hkdf-sha256-chacha20poly1305"
+
+// tagSize is the size of the ChaCha20-Poly1305 authentication tag.
+const tagSize = 16
+
+// Overhead is the number of bytes that a ciphertext has in addition to the
+// plaintext: the ephemeral public key and the AEAD tag.
+const Overhead = 32 + tagSize
+
+// KDF derives n bytes of key material from the shared point with
+// HKDF-SHA256.  The input key material is the compressed shared point, and
+// salt and info are passed to HKDF.
+func KDF(shared *babyjub.Point, salt, info []byte, n int) []byte {
+	ikm := shared.Compress()
+	r := hkdf.New(sha256.New, ikm[:], salt, info)
+	out := make([]byte, n)
+	if _, err := io.ReadFull(r, out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// deriveKey derives the AEAD key from the shared point, binding it to the
+// ephemeral and recipient public keys.
+func deriveKey(shared *babyjub.Point, ephemeral babyjub.PublicKeyComp,
+	recipient babyjub.PublicKeyComp) []byte {
+	salt := make([]byte, 0, 64) //nolint:gomnd
+	salt = append(salt, ephemeral[:]...)
+	salt = append(salt, recipient[:]...)
+	return KDF(shared, salt, []byte(Info), chacha20poly1305.KeySize)
+}
+
+// Encrypt encrypts the plaintext to the public key pk.
+func Encrypt(pk *babyjub.PublicKey, plaintext []byte) ([]byte, error) {
+	ek := babyjub.NewRandPrivKey()
+	shared, err := ek.SharedSecret(pk)
+	if err != nil {
+		return nil, err
+	}
+	ePkComp := ek.Public().Compress()
+	key := deriveKey(shared, ePkComp, pk.Compress())
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	out := make([]byte, 0, len(plaintext)+Overhead)
+	out = append(out, ePkComp[:]...)
+	return aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts the ciphertext with the private key sk.
+func Decrypt(sk *babyjub.PrivateKey, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < Overhead {
+		return nil, fmt.Errorf("ciphertext too short: %d bytes, min %d", len(ciphertext),
+			Overhead)
+	}
+	var ePkComp babyjub.PublicKeyComp
+	copy(ePkComp[:], ciphertext[:32])
+	ePk, err := ePkComp.Decompress()
+	if err != nil {
+		return nil, err
+	}
+	shared, err := sk.SharedSecret(ePk)
+	if err != nil {
+		return nil, err
+	}
+	key := deriveKey(shared, ePkComp, sk.Public().Compress())
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	plaintext, err := aead.Open([]byte{}, nonce, ciphertext[32:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("ciphertext authentication failed")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,63 @@
+package ecies
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKDF(t *testing.T) {
+	var k babyjub.PrivateKey
+	k = utils.BigIntLEBytes(big.NewInt(56869496543825))
+	out := KDF(k.Public().Point(), []byte("salt"), []byte("info"), 32)
+	assert.Equal(t,
+		"5b5a8a4b4b87395176005737d8ad1d7b83a14ae595bd840939bc8f4f10399f97",
+		hex.EncodeToString(out))
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+
+	for _, msg := range [][]byte{{}, []byte("hello babyjub"), make([]byte, 1000)} {
+		ct, err := Encrypt(pk, msg)
+		require.Nil(t, err)
+		assert.Equal(t, len(msg)+Overhead, len(ct))
+
+		pt, err := Decrypt(&sk, ct)
+		require.Nil(t, err)
+		assert.Equal(t, msg, pt)
+	}
+
+	// two encryptions of the same message differ
+	ct1, err := Encrypt(pk, []byte("msg"))
+	require.Nil(t, err)
+	ct2, err := Encrypt(pk, []byte("msg"))
+	require.Nil(t, err)
+	assert.NotEqual(t, ct1, ct2)
+
+	// wrong key
+	other := babyjub.NewRandPrivKey()
+	_, err = Decrypt(&other, ct1)
+	assert.Equal(t, "ciphertext authentication failed", err.Error())
+
+	// tampered ciphertext
+	ct1[len(ct1)-1] ^= 1
+	_, err = Decrypt(&sk, ct1)
+	assert.Equal(t, "ciphertext authentication failed", err.Error())
+
+	// too short
+	_, err = Decrypt(&sk, ct1[:Overhead-1])
+	assert.NotNil(t, err)
+}
+
+func TestEncryptInvalidPublicKey(t *testing.T) {
+	id := babyjub.PublicKey(*babyjub.NewPoint())
+	_, err := Encrypt(&id, []byte("msg"))
+	assert.NotNil(t, err)
+}
@@ -36,6 +36,21 @@ func NewRandPrivKey() PrivateKey {
 	return k
 }
 
+// NewRandScalar returns a random scalar in [1, SubOrder) (using
+// cryptographically secure randomness), to be used as a nonce or a blinding
+// factor in the subgroup generated by B8.
+func NewRandScalar() (*big.Int, error) {
+	for {
+		r, err := rand.Int(rand.Reader, SubOrder)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() != 0 {
+			return r, nil
+		}
+	}
+}
+
 // Scalar converts a private key into the scalar value s following the EdDSA
 // standard, and using blake-512 hash.
 func (k *PrivateKey) Scalar() *PrivKeyScalar {
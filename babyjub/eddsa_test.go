@@ -309,3 +309,12 @@ func BenchmarkBabyjubEddsa(b *testing.B) {
 		}
 	})
 }
+
+func TestNewRandScalar(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		r, err := NewRandScalar()
+		require.Nil(t, err)
+		assert.Equal(t, 1, r.Sign())
+		assert.Equal(t, -1, r.Cmp(SubOrder))
+	}
+}
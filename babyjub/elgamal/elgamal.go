@@ -0,0 +1,154 @@
+// Package elgamal implements exponential ElGamal encryption over the
+// BabyJubJub subgroup generated by babyjub.B8.
+//
+// A plaintext m is encrypted to the public key PK = s * B8 as the pair of
+// points (C1, C2) = (r * B8, m * B8 + r * PK).  Ciphertexts are additively
+// homomorphic: adding two ciphertexts gives an encryption of the sum of the
+// plaintexts.  Decryption recovers m * B8, and m is found by solving the
+// discrete logarithm with a baby-step giant-step Table, so only bounded
+// plaintexts can be decrypted.
+package elgamal
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// Ciphertext is an ElGamal ciphertext.
+type Ciphertext struct {
+	C1 *babyjub.Point
+	C2 *babyjub.Point
+}
+
+// checkPublicKey returns error if the public key is not a valid encryption
+// key: a point of the subgroup other than the identity.
+func checkPublicKey(pk *babyjub.PublicKey) error {
+	if !pk.Point().InSubGroup() || pk.Point().Equal(babyjub.NewPoint()) {
+		return fmt.Errorf("public key not in the subgroup")
+	}
+	return nil
+}
+
+// Encrypt encrypts the plaintext m to the public key pk with fresh
+// randomness.
+func Encrypt(pk *babyjub.PublicKey, m *big.Int) (*Ciphertext, error) {
+	r, err := babyjub.NewRandScalar()
+	if err != nil {
+		return nil, err
+	}
+	return EncryptWithRandomness(pk, m, r)
+}
+
+// EncryptWithRandomness encrypts the plaintext m to the public key pk using
+// r as the randomness.  r must be secret and never reused.
+func EncryptWithRandomness(pk *babyjub.PublicKey, m, r *big.Int) (*Ciphertext, error) {
+	if err := checkPublicKey(pk); err != nil {
+		return nil, err
+	}
+	mr := new(big.Int).Mod(m, babyjub.SubOrder)
+	rr := new(big.Int).Mod(r, babyjub.SubOrder)
+	c1 := babyjub.NewPoint().Mul(rr, babyjub.B8)
+	c2 := babyjub.NewPoint().Mul(mr, babyjub.B8)
+	c2.Add(c2, babyjub.NewPoint().Mul(rr, pk.Point()))
+	return &Ciphertext{C1: c1, C2: c2}, nil
+}
+
+// DecryptPoint decrypts the ciphertext with the private key sk, returning the
+// point m * B8.
+func DecryptPoint(sk *babyjub.PrivateKey, ct *Ciphertext) *babyjub.Point {
+	s := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	sc1 := babyjub.NewPoint().Mul(s, ct.C1)
+	return babyjub.NewPoint().Add(ct.C2, babyjub.NewPoint().Neg(sc1))
+}
+
+// Decrypt decrypts the ciphertext with the private key sk, and solves the
+// discrete logarithm of the plaintext point with the table.  Returns error if
+// the plaintext is not in the range of the table.
+func Decrypt(sk *babyjub.PrivateKey, ct *Ciphertext, table *Table) (*big.Int, error) {
+	return table.Solve(DecryptPoint(sk, ct))
+}
+
+// Add returns the ciphertext of the sum of the plaintexts of a and b.
+func Add(a, b *Ciphertext) *Ciphertext {
+	return &Ciphertext{
+		C1: babyjub.NewPoint().Add(a.C1, b.C1),
+		C2: babyjub.NewPoint().Add(a.C2, b.C2),
+	}
+}
+
+// Sub returns the ciphertext of the difference of the plaintexts of a and b.
+func Sub(a, b *Ciphertext) *Ciphertext {
+	return &Ciphertext{
+		C1: babyjub.NewPoint().Add(a.C1, babyjub.NewPoint().Neg(b.C1)),
+		C2: babyjub.NewPoint().Add(a.C2, babyjub.NewPoint().Neg(b.C2)),
+	}
+}
+
+// ScalarMul returns the ciphertext of the plaintext of a multiplied by k.
+func ScalarMul(k *big.Int, a *Ciphertext) *Ciphertext {
+	kr := new(big.Int).Mod(k, babyjub.SubOrder)
+	return &Ciphertext{
+		C1: babyjub.NewPoint().Mul(kr, a.C1),
+		C2: babyjub.NewPoint().Mul(kr, a.C2),
+	}
+}
+
+// Rerandomize returns a new ciphertext of the same plaintext as a, which is
+// unlinkable to a, by adding an encryption of zero to the public key pk.
+func Rerandomize(pk *babyjub.PublicKey, a *Ciphertext) (*Ciphertext, error) {
+	zero, err := Encrypt(pk, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	return Add(a, zero), nil
+}
+
+// CiphertextComp represents a compressed ElGamal ciphertext: the
+// concatenation of the compressed points C1 and C2.
+type CiphertextComp [64]byte
+
+// MarshalText implements the marshaler for the CiphertextComp
+func (cComp CiphertextComp) MarshalText() ([]byte, error) {
+	return utils.Hex(cComp[:]).MarshalText()
+}
+
+// String returns the string representation of the CiphertextComp
+func (cComp CiphertextComp) String() string { return utils.Hex(cComp[:]).String() }
+
+// UnmarshalText implements the unmarshaler for the CiphertextComp
+func (cComp *CiphertextComp) UnmarshalText(h []byte) error {
+	return utils.HexDecodeInto(cComp[:], h)
+}
+
+// Compress the ciphertext by concatenating the compression of its points.
+func (ct *Ciphertext) Compress() CiphertextComp {
+	var buf CiphertextComp
+	c1 := ct.C1.Compress()
+	c2 := ct.C2.Compress()
+	copy(buf[:32], c1[:])
+	copy(buf[32:], c2[:])
+	return buf
+}
+
+// Decompress a compressed ciphertext.  Returns error if the points
+// decompression fails or if the points are not in the subgroup.
+func (cComp *CiphertextComp) Decompress() (*Ciphertext, error) {
+	var c1, c2 [32]byte
+	copy(c1[:], cComp[:32])
+	copy(c2[:], cComp[32:])
+	p1, err := babyjub.NewPoint().Decompress(c1)
+	if err != nil {
+		return nil, err
+	}
+	p2, err := babyjub.NewPoint().Decompress(c2)
+	if err != nil {
+		return nil, err
+	}
+	if !p1.InSubGroup() || !p2.InSubGroup() {
+		return nil, fmt.Errorf("ciphertext points not in the subgroup")
+	}
+	return &Ciphertext{C1: p1, C2: p2}, nil
+}
@@ -0,0 +1,131 @@
+package elgamal
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var table = NewTable(1 << 16)
+
+func TestEncryptDecrypt(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+
+	for _, m := range []int64{0, 1, 255, 256, 65535} {
+		ct, err := Encrypt(pk, big.NewInt(m))
+		require.Nil(t, err)
+		d, err := Decrypt(&sk, ct, table)
+		require.Nil(t, err)
+		assert.Equal(t, big.NewInt(m), d)
+	}
+
+	ct, err := Encrypt(pk, big.NewInt(65536))
+	require.Nil(t, err)
+	_, err = Decrypt(&sk, ct, table)
+	assert.Equal(t, "plaintext out of range [0, 65536)", err.Error())
+
+	// wrong key
+	other := babyjub.NewRandPrivKey()
+	ct, err = Encrypt(pk, big.NewInt(42))
+	require.Nil(t, err)
+	_, err = Decrypt(&other, ct, table)
+	assert.NotNil(t, err)
+}
+
+func TestEncryptWithRandomness(t *testing.T) {
+	var sk babyjub.PrivateKey
+	sk = utils.BigIntLEBytes(big.NewInt(56869496543825))
+	pk := sk.Public()
+	ct, err := EncryptWithRandomness(pk, big.NewInt(10), big.NewInt(3))
+	require.Nil(t, err)
+	assert.True(t, ct.C1.Equal(babyjub.NewPoint().Mul(big.NewInt(3), babyjub.B8)))
+	c2 := babyjub.NewPoint().Mul(big.NewInt(10), babyjub.B8)
+	c2.Add(c2, babyjub.NewPoint().Mul(big.NewInt(3), pk.Point()))
+	assert.True(t, ct.C2.Equal(c2))
+
+	id := babyjub.PublicKey(*babyjub.NewPoint())
+	_, err = EncryptWithRandomness(&id, big.NewInt(10), big.NewInt(3))
+	assert.NotNil(t, err)
+}
+
+func TestHomomorphism(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+
+	a, err := Encrypt(pk, big.NewInt(1200))
+	require.Nil(t, err)
+	b, err := Encrypt(pk, big.NewInt(34))
+	require.Nil(t, err)
+
+	d, err := Decrypt(&sk, Add(a, b), table)
+	require.Nil(t, err)
+	assert.Equal(t, big.NewInt(1234), d)
+
+	d, err = Decrypt(&sk, Sub(a, b), table)
+	require.Nil(t, err)
+	assert.Equal(t, big.NewInt(1166), d)
+
+	d, err = Decrypt(&sk, ScalarMul(big.NewInt(3), b), table)
+	require.Nil(t, err)
+	assert.Equal(t, big.NewInt(102), d)
+
+	r, err := Rerandomize(pk, a)
+	require.Nil(t, err)
+	assert.False(t, r.C1.Equal(a.C1))
+	d, err = Decrypt(&sk, r, table)
+	require.Nil(t, err)
+	assert.Equal(t, big.NewInt(1200), d)
+}
+
+func TestCompressDecompress(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	ct, err := Encrypt(sk.Public(), big.NewInt(77))
+	require.Nil(t, err)
+
+	cComp := ct.Compress()
+	ct2, err := cComp.Decompress()
+	require.Nil(t, err)
+	assert.True(t, ct.C1.Equal(ct2.C1))
+	assert.True(t, ct.C2.Equal(ct2.C2))
+
+	b, err := json.Marshal(cComp)
+	require.Nil(t, err)
+	var cComp2 CiphertextComp
+	require.Nil(t, json.Unmarshal(b, &cComp2))
+	assert.Equal(t, cComp, cComp2)
+
+	// point out of the subgroup, of order 2
+	low := babyjub.Point{X: big.NewInt(0), Y: new(big.Int).Sub(constants.Q, big.NewInt(1))}
+	lowComp := low.Compress()
+	copy(cComp2[:32], lowComp[:])
+	_, err = cComp2.Decompress()
+	assert.Equal(t, "ciphertext points not in the subgroup", err.Error())
+}
+
+func TestTable(t *testing.T) {
+	tbl := NewTable(1000)
+	assert.Equal(t, uint64(1000), tbl.Max())
+	for _, m := range []int64{0, 31, 32, 999} {
+		x, err := tbl.Solve(babyjub.NewPoint().Mul(big.NewInt(m), babyjub.B8))
+		require.Nil(t, err)
+		assert.Equal(t, big.NewInt(m), x)
+	}
+	_, err := tbl.Solve(babyjub.NewPoint().Mul(big.NewInt(1000), babyjub.B8))
+	assert.NotNil(t, err)
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	sk := babyjub.NewRandPrivKey()
+	ct, err := Encrypt(sk.Public(), big.NewInt(60000))
+	require.Nil(b, err)
+	for i := 0; i < b.N; i++ {
+		Decrypt(&sk, ct, table) //nolint:errcheck,gosec
+	}
+}
@@ -0,0 +1,70 @@
+package elgamal
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+)
+
+// Table is a precomputed baby-step giant-step table to solve the discrete
+// logarithm of points m * B8 for m in [0, max).
+type Table struct {
+	max   uint64
+	m     uint64
+	baby  map[[32]byte]uint64
+	giant *babyjub.PointProjective
+}
+
+// isqrtCeil returns the ceiling of the square root of n.
+func isqrtCeil(n uint64) uint64 {
+	r := new(big.Int).Sqrt(new(big.Int).SetUint64(n)).Uint64()
+	if r*r < n {
+		r++
+	}
+	return r
+}
+
+// NewTable precomputes the table for plaintexts in [0, max).  It stores
+// ceil(sqrt(max)) points, and solving takes up to as many point additions.
+func NewTable(max uint64) *Table {
+	m := isqrtCeil(max)
+	if m == 0 {
+		m = 1
+	}
+	baby := make(map[[32]byte]uint64, m)
+	cur := babyjub.NewPointProjective()
+	b8 := babyjub.B8.Projective()
+	for j := uint64(0); j < m; j++ {
+		baby[cur.Affine().Compress()] = j
+		cur = babyjub.NewPointProjective().Add(cur, b8)
+	}
+	giant := babyjub.NewPoint().Mul(new(big.Int).SetUint64(m), babyjub.B8)
+	return &Table{
+		max:   max,
+		m:     m,
+		baby:  baby,
+		giant: babyjub.NewPoint().Neg(giant).Projective(),
+	}
+}
+
+// Max returns the bound of the plaintexts that the table can solve.
+func (t *Table) Max() uint64 {
+	return t.max
+}
+
+// Solve returns m such that p = m * B8, for m in [0, max).  Returns error if
+// there is no such m.
+func (t *Table) Solve(p *babyjub.Point) (*big.Int, error) {
+	cur := p.Projective()
+	for i := uint64(0); i*t.m < t.max; i++ {
+		if j, ok := t.baby[cur.Affine().Compress()]; ok {
+			x := i*t.m + j
+			if x < t.max {
+				return new(big.Int).SetUint64(x), nil
+			}
+		}
+		cur = babyjub.NewPointProjective().Add(cur, t.giant)
+	}
+	return nil, fmt.Errorf("plaintext out of range [0, %d)", t.max)
+}
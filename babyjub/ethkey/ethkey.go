@@ -0,0 +1,89 @@
+// Package ethkey derives BabyJubJub EdDSA keys from Ethereum ECDSA keys in
+// the same way as the Loopring wallets and SDK do: the user signs a fixed
+// message with the secp256k1 Ethereum key, and the signature is hashed into
+// the BabyJubJub private key.
+package ethkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// SignatureLength is the length in bytes of an Ethereum signature in the
+// [R || S || V] format.
+const SignatureLength = 65
+
+// MessageTemplate is the message signed with the Ethereum key to derive the
+// BabyJubJub key, parametrized by the exchange address and the key nonce.
+const MessageTemplate = "Sign this message to access Loopring Exchange: %s with key nonce: %d"
+
+// Message returns the message that the Ethereum key signs to derive the
+// BabyJubJub key for the given exchange address and account key nonce.
+func Message(exchange common.Address, nonce uint32) string {
+	return fmt.Sprintf(MessageTemplate, exchange.Hex(), nonce)
+}
+
+// textHash returns the hash of msg following the Ethereum personal_sign
+// convention (EIP-191 version 0x45).
+func textHash(msg string) []byte {
+	prefixed := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(msg), msg)
+	return crypto.Keccak256([]byte(prefixed))
+}
+
+// SignMessage signs the key derivation message with the Ethereum private key
+// as a wallet does with personal_sign, returning a 65 byte signature whose V
+// value is 27 or 28.
+func SignMessage(key *ecdsa.PrivateKey, exchange common.Address,
+	nonce uint32) ([]byte, error) {
+	sig, err := crypto.Sign(textHash(Message(exchange, nonce)), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27 //nolint:gomnd
+	return sig, nil
+}
+
+// FromSignature derives the BabyJubJub private key from a 65 byte Ethereum
+// signature of the key derivation message.  The V value of the signature can
+// be given either as 0/1 or as 27/28, it is normalized to 27/28 before
+// hashing, as wallets return it.
+func FromSignature(sig []byte) (babyjub.PrivateKey, error) {
+	var k babyjub.PrivateKey
+	if len(sig) != SignatureLength {
+		return k, fmt.Errorf("invalid signature length %d, want %d", len(sig),
+			SignatureLength)
+	}
+	buf := make([]byte, SignatureLength)
+	copy(buf, sig)
+	switch buf[64] {
+	case 0, 1:
+		buf[64] += 27 //nolint:gomnd
+	case 27, 28: //nolint:gomnd
+	default:
+		return k, fmt.Errorf("invalid signature V value %d", buf[64])
+	}
+	seed := sha256.Sum256(buf)
+	s := utils.SetBigIntFromLEBytes(new(big.Int), seed[:])
+	s.Mod(s, constants.Q)
+	k = utils.BigIntLEBytes(s)
+	return k, nil
+}
+
+// FromECDSA derives the BabyJubJub private key of the Ethereum private key
+// for the given exchange address and account key nonce.
+func FromECDSA(key *ecdsa.PrivateKey, exchange common.Address,
+	nonce uint32) (babyjub.PrivateKey, error) {
+	sig, err := SignMessage(key, exchange, nonce)
+	if err != nil {
+		return babyjub.PrivateKey{}, err
+	}
+	return FromSignature(sig)
+}
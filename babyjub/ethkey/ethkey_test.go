@@ -0,0 +1,87 @@
+package ethkey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exchange = common.HexToAddress("0x0BABA1Ad5bE3a5C0a66E7ac838a129Bf948f1eA4")
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t,
+		"Sign this message to access Loopring Exchange: "+
+			"0x0BABA1Ad5bE3a5C0a66E7ac838a129Bf948f1eA4 with key nonce: 1",
+		Message(exchange, 1))
+}
+
+func TestFromECDSA(t *testing.T) {
+	key, err := crypto.HexToECDSA(
+		"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.Nil(t, err)
+
+	sig, err := SignMessage(key, exchange, 1)
+	require.Nil(t, err)
+	assert.Equal(t, ""+
+		"359c3a2bb87134f0dc1480e5f4d2c9827d90cd4210f9d3fda7e0647211ef8058"+
+		"0bdb375db01fb31f682e6f6bda27f600f58ca1856a931a4bdf670719ca10e219"+
+		"1c",
+		hex.EncodeToString(sig))
+
+	// the signature recovers to the Ethereum address of the key
+	recSig := append([]byte{}, sig...)
+	recSig[64] -= 27
+	pub, err := crypto.SigToPub(textHash(Message(exchange, 1)), recSig)
+	require.Nil(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pub))
+
+	// The expected key follows the generateKeyPair of the Loopring SDK,
+	// computed outside of this package: the seed is the sha256 of the 65
+	// signature bytes, which EdDSA.generateKeyPair reads little-endian with
+	// leBuff2int and reduces modulo SNARK_SCALAR_FIELD, and the public key
+	// is the secret times the ethsnarks base point.
+	seed := sha256.Sum256(sig)
+	assert.Equal(t,
+		"fbcd6b628a04e48a1f0dfcebbf18343add5e83fe3790ed1b9c8059151f775550",
+		hex.EncodeToString(seed[:]))
+	k, err := FromECDSA(key, exchange, 1)
+	require.Nil(t, err)
+	assert.Equal(t,
+		"14447789161150190543817071476802719930656169564053875189985030146554556304890",
+		k.Scalar().BigInt().String())
+	assert.Equal(t,
+		"facd6b72f60e02478e9c4272773000128006027d814a9d6372e02734ac28f11f",
+		hex.EncodeToString(k[:]))
+	pk := k.Public()
+	assert.Equal(t,
+		"20349161380180994645078826863981066756421327895849589612962170473574705359583",
+		pk.X.String())
+	assert.Equal(t,
+		"5956686131653866978603255508465160687141040292052825475211284681006509994123",
+		pk.Y.String())
+
+	// V given as 0/1 derives the same key
+	k2, err := FromSignature(recSig)
+	require.Nil(t, err)
+	assert.Equal(t, k, k2)
+
+	// a different nonce derives a different key
+	k3, err := FromECDSA(key, exchange, 2)
+	require.Nil(t, err)
+	assert.NotEqual(t, k, k3)
+}
+
+func TestFromSignatureErrors(t *testing.T) {
+	_, err := FromSignature(make([]byte, 64))
+	assert.Equal(t, "invalid signature length 64, want 65", err.Error())
+
+	sig := make([]byte, 65)
+	sig[64] = 2
+	_, err = FromSignature(sig)
+	assert.Equal(t, "invalid signature V value 2", err.Error())
+}
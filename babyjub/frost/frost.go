@@ -0,0 +1,129 @@
+// Package frost implements FROST two-round threshold Schnorr signatures
+// (RFC 9591) over the BabyJubJub subgroup generated by babyjub.B8.
+//
+// The challenge of the signatures is the one of the Poseidon EdDSA of
+// babyjub, c = Poseidon(R.x, R.y, A.x, A.y, msg), so the aggregated signature
+// is a babyjub.Signature accepted by PublicKey.VerifyPoseidon for the group
+// public key A.  The binding factors are computed with a poseidon.Transcript.
+//
+// The key shares are generated by a trusted dealer with Deal.  Signing takes
+// two rounds: each signer generates single-use nonces and publishes their
+// commitments with Commit, and once the commitments of the signers are known
+// each signer computes its signature share with Sign.  Aggregate verifies the
+// signature shares, identifying the signers that sent invalid shares, and
+// combines them into the signature.
+package frost
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// KeyShare is the secret key share of a participant: the evaluation at ID of
+// the polynomial whose constant term is the group private key.
+type KeyShare struct {
+	ID       int
+	Secret   *big.Int
+	GroupKey *babyjub.PublicKey
+}
+
+// Public returns the verification share of the key share, Secret * B8.
+func (ks *KeyShare) Public() *babyjub.Point {
+	return babyjub.NewPoint().Mul(ks.Secret, babyjub.B8)
+}
+
+// Group holds the public information of a group of signers: the threshold,
+// the group public key and the verification share of each participant.
+type Group struct {
+	Threshold int
+	Key       *babyjub.PublicKey
+	Shares    map[int]*babyjub.Point
+}
+
+// Deal splits the private key sk into n key shares, with IDs 1 to n, any
+// threshold of which can sign for the public key of sk, and returns the key
+// shares together with the public information of the group.
+func Deal(sk *babyjub.PrivateKey, threshold, n int) ([]*KeyShare, *Group, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, fmt.Errorf("invalid threshold %d of %d", threshold, n)
+	}
+	s := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	if s.Sign() == 0 {
+		return nil, nil, fmt.Errorf("private key is zero modulo SubOrder")
+	}
+	coefs := []*big.Int{s}
+	for i := 1; i < threshold; i++ {
+		a, err := babyjub.NewRandScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+		coefs = append(coefs, a)
+	}
+	groupKey := sk.Public()
+	group := &Group{
+		Threshold: threshold,
+		Key:       groupKey,
+		Shares:    make(map[int]*babyjub.Point, n),
+	}
+	shares := make([]*KeyShare, n)
+	for i := range shares {
+		id := i + 1
+		shares[i] = &KeyShare{ID: id, Secret: babyjub.EvalPolynomial(coefs, id), GroupKey: groupKey}
+		group.Shares[id] = shares[i].Public()
+	}
+	return shares, group, nil
+}
+
+// nonceGenerate returns the nonce H(random || secret) mod SubOrder, with 32
+// random bytes, as nonce_generate of RFC 9591 using blake-512, so that a
+// weak random source alone doesn't reveal the secret.
+func nonceGenerate(secret *big.Int) (*big.Int, error) {
+	for {
+		buf := make([]byte, 64) //nolint:gomnd
+		if _, err := rand.Read(buf[:32]); err != nil {
+			return nil, err
+		}
+		secretBuf := utils.BigIntLEBytes(secret)
+		copy(buf[32:], secretBuf[:])
+		k := utils.SetBigIntFromLEBytes(new(big.Int), babyjub.Blake512(buf))
+		k.Mod(k, babyjub.SubOrder)
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// checkPoint returns true if the point is in the subgroup and is not the
+// identity.
+func checkPoint(p *babyjub.Point) bool {
+	return p != nil && p.X != nil && p.Y != nil && p.InSubGroup() &&
+		!p.Equal(babyjub.NewPoint())
+}
+
+// sortCommitments returns the commitments sorted by ID, checking that the IDs
+// are positive and unique and that the points are valid.
+func sortCommitments(commitments []*Commitment) ([]*Commitment, error) {
+	if len(commitments) == 0 {
+		return nil, fmt.Errorf("no commitments")
+	}
+	sorted := make([]*Commitment, len(commitments))
+	copy(sorted, commitments)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for i, c := range sorted {
+		if c.ID < 1 {
+			return nil, fmt.Errorf("invalid participant id %d", c.ID)
+		}
+		if i > 0 && sorted[i-1].ID == c.ID {
+			return nil, fmt.Errorf("duplicated commitment of participant %d", c.ID)
+		}
+		if !checkPoint(c.D) || !checkPoint(c.E) {
+			return nil, fmt.Errorf("invalid commitment of participant %d", c.ID)
+		}
+	}
+	return sorted, nil
+}
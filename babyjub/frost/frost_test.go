@@ -0,0 +1,154 @@
+package frost
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signSession runs the two rounds of signing with the key shares, returning
+// the commitments and the signature shares.
+func signSession(t *testing.T, signers []*KeyShare, msg *big.Int) ([]*Commitment,
+	[]*SignatureShare) {
+	nonces := make([]*Nonces, len(signers))
+	commitments := make([]*Commitment, len(signers))
+	for i, ks := range signers {
+		var err error
+		nonces[i], err = Commit(ks)
+		require.Nil(t, err)
+		commitments[i] = nonces[i].Commitment
+	}
+	shares := make([]*SignatureShare, len(signers))
+	for i, ks := range signers {
+		var err error
+		shares[i], err = Sign(ks, nonces[i], msg, commitments)
+		require.Nil(t, err)
+	}
+	return commitments, shares
+}
+
+func TestDeal(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	shares, group, err := Deal(&sk, 3, 5)
+	require.Nil(t, err)
+	assert.Equal(t, 5, len(shares))
+	assert.True(t, group.Key.Point().Equal(sk.Public().Point()))
+
+	// any 3 shares reconstruct the key, which is never done when signing
+	ids := []int{2, 4, 5}
+	ys := []*big.Int{shares[1].Secret, shares[3].Secret, shares[4].Secret}
+	s := babyjub.Interpolate(ids, ys, 0)
+	assert.Equal(t, new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder), s)
+
+	_, _, err = Deal(&sk, 0, 5)
+	assert.NotNil(t, err)
+	_, _, err = Deal(&sk, 6, 5)
+	assert.NotNil(t, err)
+}
+
+func TestSignAggregate(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	shares, group, err := Deal(&sk, 3, 5)
+	require.Nil(t, err)
+	msg := big.NewInt(123456789)
+
+	for _, signers := range [][]*KeyShare{
+		{shares[0], shares[1], shares[2]},
+		{shares[4], shares[1], shares[3]},
+		shares,
+	} {
+		commitments, sigShares := signSession(t, signers, msg)
+		sig, err := Aggregate(group, msg, commitments, sigShares)
+		require.Nil(t, err)
+		assert.True(t, group.Key.VerifyPoseidon(msg, sig))
+		assert.True(t, sk.Public().VerifyPoseidon(msg, sig))
+		assert.False(t, group.Key.VerifyPoseidon(big.NewInt(1), sig))
+
+		// the compressed signature round trips
+		sigComp := sig.Compress()
+		sig2, err := sigComp.Decompress()
+		require.Nil(t, err)
+		assert.True(t, group.Key.VerifyPoseidon(msg, sig2))
+	}
+
+	// below the threshold
+	commitments, sigShares := signSession(t, shares[:2], msg)
+	_, err = Aggregate(group, msg, commitments, sigShares)
+	assert.NotNil(t, err)
+}
+
+func TestIdentifiableAbort(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	shares, group, err := Deal(&sk, 2, 3)
+	require.Nil(t, err)
+	msg := big.NewInt(42)
+
+	signers := []*KeyShare{shares[0], shares[2]}
+	commitments, sigShares := signSession(t, signers, msg)
+	sigShares[1].Z = new(big.Int).Add(sigShares[1].Z, big.NewInt(1))
+	_, err = Aggregate(group, msg, commitments, sigShares)
+	require.NotNil(t, err)
+	culprit, ok := err.(*CulpritError)
+	require.True(t, ok)
+	assert.Equal(t, []int{3}, culprit.IDs)
+
+	// share signed for another message
+	nonces := make([]*Nonces, 2)
+	for i, ks := range signers {
+		nonces[i], err = Commit(ks)
+		require.Nil(t, err)
+		commitments[i] = nonces[i].Commitment
+	}
+	sigShares[0], err = Sign(signers[0], nonces[0], big.NewInt(43), commitments)
+	require.Nil(t, err)
+	sigShares[1], err = Sign(signers[1], nonces[1], msg, commitments)
+	require.Nil(t, err)
+	_, err = Aggregate(group, msg, commitments, sigShares)
+	require.NotNil(t, err)
+	culprit, ok = err.(*CulpritError)
+	require.True(t, ok)
+	assert.Equal(t, []int{1}, culprit.IDs)
+}
+
+func TestSignErrors(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	shares, _, err := Deal(&sk, 2, 3)
+	require.Nil(t, err)
+	msg := big.NewInt(42)
+
+	n1, err := Commit(shares[0])
+	require.Nil(t, err)
+	n2, err := Commit(shares[1])
+	require.Nil(t, err)
+	commitments := []*Commitment{n1.Commitment, n2.Commitment}
+
+	// nonces of another participant
+	_, err = Sign(shares[0], n2, msg, commitments)
+	assert.NotNil(t, err)
+	// own commitment missing
+	_, err = Sign(shares[0], n1, msg, commitments[1:])
+	assert.NotNil(t, err)
+	// duplicated commitment
+	_, err = Sign(shares[0], n1, msg, []*Commitment{n1.Commitment, n1.Commitment})
+	assert.NotNil(t, err)
+	// invalid commitment point
+	bad := &Commitment{ID: 2, D: babyjub.NewPoint(), E: n2.Commitment.E}
+	_, err = Sign(shares[0], n1, msg, []*Commitment{n1.Commitment, bad})
+	assert.NotNil(t, err)
+
+	// the message must be inside the field
+	_, err = Sign(shares[0], n1, nil, commitments)
+	assert.NotNil(t, err)
+	_, err = Sign(shares[0], n1, constants.Q, commitments)
+	assert.NotNil(t, err)
+
+	// the nonces can only be used once
+	_, err = Sign(shares[0], n1, msg, commitments)
+	require.Nil(t, err)
+	_, err = Sign(shares[0], n1, msg, commitments)
+	assert.NotNil(t, err)
+}
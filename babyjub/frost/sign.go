@@ -0,0 +1,232 @@
+package frost
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// DomainBinding is the label of the transcript of the binding factors.
+const DomainBinding = "iden3_frost_binding"
+
+// Commitment is the public commitment to the nonces of a participant for a
+// signing session: D = d * B8 and E = e * B8.
+type Commitment struct {
+	ID int
+	D  *babyjub.Point
+	E  *babyjub.Point
+}
+
+// Nonces are the secret single-use nonces d and e of a participant for a
+// signing session.  They are erased by Sign, and must never be reused.
+type Nonces struct {
+	id   int
+	d, e *big.Int
+	// Commitment is the public commitment of the nonces.
+	Commitment *Commitment
+}
+
+// SignatureShare is the signature share of a participant.
+type SignatureShare struct {
+	ID int
+	Z  *big.Int
+}
+
+// Commit generates the nonces of the key share for a signing session.  Their
+// Commitment must be sent to the other signers, while the nonces are kept
+// secret until Sign.
+func Commit(ks *KeyShare) (*Nonces, error) {
+	d, err := nonceGenerate(ks.Secret)
+	if err != nil {
+		return nil, err
+	}
+	e, err := nonceGenerate(ks.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Nonces{
+		id: ks.ID,
+		d:  d,
+		e:  e,
+		Commitment: &Commitment{
+			ID: ks.ID,
+			D:  babyjub.NewPoint().Mul(d, babyjub.B8),
+			E:  babyjub.NewPoint().Mul(e, babyjub.B8),
+		},
+	}, nil
+}
+
+// session holds the values derived from the commitments of a signing
+// session, which are shared by Sign and Aggregate.
+type session struct {
+	commitments []*Commitment
+	ids         []int
+	rho         map[int]*big.Int
+	r           *babyjub.Point
+	c           *big.Int
+}
+
+// newSession computes the binding factors, the group commitment R and the
+// challenge c of the signing session of msg.  The binding factor of each
+// participant is squeezed from a transcript of the group key, msg and all the
+// commitments, extended with the participant ID.  msg must be inside the
+// finite field.
+func newSession(groupKey *babyjub.PublicKey, msg *big.Int,
+	commitments []*Commitment) (*session, error) {
+	if msg == nil || !utils.CheckBigIntInField(msg) {
+		return nil, fmt.Errorf("msg must be inside the finite field")
+	}
+	sorted, err := sortCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+	t := poseidon.NewTranscript(DomainBinding)
+	t.AppendPoint("A", groupKey.Point())
+	t.AppendScalar("msg", msg)
+	t.AppendScalar("n", big.NewInt(int64(len(sorted))))
+	for _, c := range sorted {
+		t.AppendScalar("id", big.NewInt(int64(c.ID)))
+		t.AppendPoint("D", c.D)
+		t.AppendPoint("E", c.E)
+	}
+
+	s := &session{commitments: sorted, rho: make(map[int]*big.Int, len(sorted))}
+	r := babyjub.NewPointProjective()
+	for _, c := range sorted {
+		s.ids = append(s.ids, c.ID)
+		ti := t.Clone()
+		ti.AppendScalar("i", big.NewInt(int64(c.ID)))
+		s.rho[c.ID] = ti.ChallengeScalar("rho", babyjub.SubOrder)
+		ri := babyjub.NewPoint().Add(c.D, babyjub.NewPoint().Mul(s.rho[c.ID], c.E))
+		r = babyjub.NewPointProjective().Add(r, ri.Projective())
+	}
+	s.r = r.Affine()
+
+	// c = Poseidon(R.x, R.y, A.x, A.y, msg), as in SignPoseidon
+	a := groupKey.Point()
+	hm, err := poseidon.Hash([]*big.Int{s.r.X, s.r.Y, a.X, a.Y, msg})
+	if err != nil {
+		return nil, err
+	}
+	s.c = hm.Mod(hm, babyjub.SubOrder)
+	return s, nil
+}
+
+// Sign computes the signature share of the key share for msg with the nonces,
+// given the commitments of all the signers of the session, including the own
+// one.  The nonces are erased, so a second call with them returns error.
+func Sign(ks *KeyShare, nonces *Nonces, msg *big.Int,
+	commitments []*Commitment) (*SignatureShare, error) {
+	if nonces.d == nil || nonces.e == nil {
+		return nil, fmt.Errorf("nonces already used")
+	}
+	if nonces.id != ks.ID {
+		return nil, fmt.Errorf("nonces of participant %d, not %d", nonces.id, ks.ID)
+	}
+	s, err := newSession(ks.GroupKey, msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	var own *Commitment
+	for _, c := range s.commitments {
+		if c.ID == ks.ID {
+			own = c
+		}
+	}
+	if own == nil || !own.D.Equal(nonces.Commitment.D) || !own.E.Equal(nonces.Commitment.E) {
+		return nil, fmt.Errorf("own commitment missing or modified")
+	}
+
+	// z = d + e * rho + lambda * s * c
+	z := new(big.Int).Mul(nonces.e, s.rho[ks.ID])
+	z.Add(z, nonces.d)
+	lsc := babyjub.LagrangeCoefficient(ks.ID, s.ids, 0)
+	lsc.Mul(lsc, ks.Secret)
+	lsc.Mul(lsc, s.c)
+	z.Add(z, lsc)
+	z.Mod(z, babyjub.SubOrder)
+	nonces.d, nonces.e = nil, nil
+	return &SignatureShare{ID: ks.ID, Z: z}, nil
+}
+
+// CulpritError is returned by Aggregate when some signature shares are
+// invalid, and holds the IDs of the participants that sent them.
+type CulpritError struct {
+	IDs []int
+}
+
+// Error implements the error interface.
+func (e *CulpritError) Error() string {
+	return fmt.Sprintf("invalid signature shares from participants %v", e.IDs)
+}
+
+// verifyShare checks z * B8 == D + rho * E + c * lambda * Y for the signature
+// share of the participant with verification share Y.
+func (s *session) verifyShare(c *Commitment, y *babyjub.Point, z *big.Int) bool {
+	if z == nil || z.Sign() < 0 || z.Cmp(babyjub.SubOrder) >= 0 {
+		return false
+	}
+	left := babyjub.NewPoint().Mul(z, babyjub.B8)
+	cl := babyjub.LagrangeCoefficient(c.ID, s.ids, 0)
+	cl.Mul(cl, s.c)
+	cl.Mod(cl, babyjub.SubOrder)
+	right := babyjub.NewPoint().Add(c.D, babyjub.NewPoint().Mul(s.rho[c.ID], c.E))
+	right.Add(right, babyjub.NewPoint().Mul(cl, y))
+	return left.Equal(right)
+}
+
+// Aggregate verifies the signature shares of the signers of msg and combines
+// them into a signature that verifies with VerifyPoseidon under the group
+// key.  There must be one share for each commitment of the session, and at
+// least the threshold of the group.  If some shares are invalid it returns a
+// *CulpritError with the IDs of the participants that sent them.
+func Aggregate(group *Group, msg *big.Int, commitments []*Commitment,
+	shares []*SignatureShare) (*babyjub.Signature, error) {
+	s, err := newSession(group.Key, msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.commitments) < group.Threshold {
+		return nil, fmt.Errorf("%d signers, threshold is %d", len(s.commitments), group.Threshold)
+	}
+	byID := make(map[int]*SignatureShare, len(shares))
+	for _, sh := range shares {
+		if _, ok := byID[sh.ID]; ok {
+			return nil, fmt.Errorf("duplicated signature share of participant %d", sh.ID)
+		}
+		byID[sh.ID] = sh
+	}
+	if len(byID) != len(s.commitments) {
+		return nil, fmt.Errorf("%d signature shares for %d commitments", len(byID), len(s.commitments))
+	}
+
+	var culprits []int
+	z := big.NewInt(0)
+	for _, c := range s.commitments {
+		sh, ok := byID[c.ID]
+		if !ok {
+			return nil, fmt.Errorf("missing signature share of participant %d", c.ID)
+		}
+		y, ok := group.Shares[c.ID]
+		if !ok {
+			return nil, fmt.Errorf("unknown participant %d", c.ID)
+		}
+		if !s.verifyShare(c, y, sh.Z) {
+			culprits = append(culprits, c.ID)
+			continue
+		}
+		z.Add(z, sh.Z)
+	}
+	if len(culprits) > 0 {
+		return nil, &CulpritError{IDs: culprits}
+	}
+	z.Mod(z, babyjub.SubOrder)
+	sig := &babyjub.Signature{R8: s.r, S: z}
+	if !group.Key.VerifyPoseidon(msg, sig) {
+		return nil, fmt.Errorf("aggregated signature does not verify")
+	}
+	return sig, nil
+}
@@ -0,0 +1,178 @@
+package babyjub
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+)
+
+// Hash to curve for BabyJubJub following RFC 9380
+// (https://www.rfc-editor.org/rfc/rfc9380.html): the field elements are
+// obtained with expand_message_xmd using SHA-256, they are mapped to the
+// birationally equivalent Montgomery curve v^2 = u^3 + 168698 u^2 + u with
+// Elligator 2, and then to BabyJubJub with the rational map
+// (x, y) = (u / v, (u - 1) / (u + 1)).  The cofactor is cleared by
+// multiplying by 8, so the results are in the subgroup generated by B8.
+
+const (
+	// h2cL is the number of bytes expanded for each field element:
+	// ceil((ceil(log2(Q)) + k) / 8) with the security parameter k = 128.
+	h2cL = 48
+	// h2cMaxDSTLen is the maximum length of a DST, longer DSTs are hashed.
+	h2cMaxDSTLen = 255
+)
+
+var (
+	// MontgomeryA is the A coefficient of the Montgomery curve
+	// v^2 = u^3 + A u^2 + u birationally equivalent to BabyJubJub.
+	MontgomeryA = big.NewInt(168698) //nolint:gomnd
+	// elligator2Z is the non-square Z of Elligator 2, chosen following the
+	// find_z_ell2 procedure of RFC 9380.
+	elligator2Z = big.NewInt(5) //nolint:gomnd
+)
+
+// expandMessageXMD implements expand_message_xmd of RFC 9380 with SHA-256.
+// lenInBytes must be at most 8160.
+func expandMessageXMD(msg, dst []byte, lenInBytes int) []byte {
+	const bInBytes = sha256.Size
+	const sInBytes = 64
+	if len(dst) > h2cMaxDSTLen {
+		h := sha256.Sum256(append([]byte("H2C-OVERSIZE-DST-"), dst...))
+		dst = h[:]
+	}
+	ell := (lenInBytes + bInBytes - 1) / bInBytes
+	if ell > 255 || lenInBytes > 65535 { //nolint:gomnd
+		panic("expand_message_xmd: requested length too big")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, sInBytes))                             //nolint:errcheck,gosec
+	h.Write(msg)                                                //nolint:errcheck,gosec
+	h.Write([]byte{byte(lenInBytes >> 8), byte(lenInBytes), 0}) //nolint:errcheck,gosec,gomnd
+	h.Write(dstPrime)                                           //nolint:errcheck,gosec
+	b0 := h.Sum(nil)
+
+	out := make([]byte, 0, ell*bInBytes)
+	bi := make([]byte, bInBytes)
+	for i := 1; i <= ell; i++ {
+		in := make([]byte, bInBytes)
+		for j := range in {
+			in[j] = b0[j] ^ bi[j]
+		}
+		h.Reset()
+		h.Write(in)              //nolint:errcheck,gosec
+		h.Write([]byte{byte(i)}) //nolint:errcheck,gosec
+		h.Write(dstPrime)        //nolint:errcheck,gosec
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:lenInBytes]
+}
+
+// hashToField implements hash_to_field of RFC 9380 for the field Q,
+// returning count field elements.
+func hashToField(msg, dst []byte, count int) []*big.Int {
+	uniform := expandMessageXMD(msg, dst, count*h2cL)
+	us := make([]*big.Int, count)
+	for i := range us {
+		us[i] = new(big.Int).SetBytes(uniform[i*h2cL : (i+1)*h2cL])
+		us[i].Mod(us[i], constants.Q)
+	}
+	return us
+}
+
+func isSquare(x *big.Int) bool {
+	return big.Jacobi(x, constants.Q) >= 0
+}
+
+// mapToMontgomery implements the Elligator 2 map of RFC 9380 (section 6.7.1)
+// for the Montgomery curve with K = 1 and J = MontgomeryA.
+func mapToMontgomery(u *big.Int) (*big.Int, *big.Int) {
+	q := constants.Q
+	// x1 = -J / (1 + Z * u^2), or -J if the denominator is 0
+	den := new(big.Int).Mul(u, u)
+	den.Mul(den, elligator2Z)
+	den.Add(den, constants.One)
+	den.Mod(den, q)
+	x1 := new(big.Int).Neg(MontgomeryA)
+	if den.Sign() != 0 {
+		x1.Mul(x1, den.ModInverse(den, q))
+	}
+	x1.Mod(x1, q)
+	// x2 = -x1 - J
+	x2 := new(big.Int).Neg(x1)
+	x2.Sub(x2, MontgomeryA)
+	x2.Mod(x2, q)
+
+	x, odd := x1, true
+	gx := montgomeryRHS(x1)
+	if !isSquare(gx) {
+		x, odd = x2, false
+		gx = montgomeryRHS(x2)
+	}
+	y := new(big.Int).ModSqrt(gx, q)
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(q, y)
+		y.Mod(y, q)
+	}
+	return x, y
+}
+
+// montgomeryRHS returns x^3 + A x^2 + x.
+func montgomeryRHS(x *big.Int) *big.Int {
+	r := new(big.Int).Add(x, MontgomeryA)
+	r.Mul(r, x)
+	r.Add(r, constants.One)
+	r.Mul(r, x)
+	return r.Mod(r, constants.Q)
+}
+
+// montgomeryToEdwards implements the rational map from the Montgomery curve
+// to BabyJubJub: (x, y) = (u / v, (u - 1) / (u + 1)), mapping the
+// exceptional cases to the identity.
+func montgomeryToEdwards(u, v *big.Int) *Point {
+	q := constants.Q
+	u1 := new(big.Int).Add(u, constants.One)
+	u1.Mod(u1, q)
+	if v.Sign() == 0 || u1.Sign() == 0 {
+		return NewPoint()
+	}
+	x := new(big.Int).ModInverse(v, q)
+	x.Mul(x, u)
+	x.Mod(x, q)
+	y := new(big.Int).Sub(u, constants.One)
+	y.Mul(y, u1.ModInverse(u1, q))
+	y.Mod(y, q)
+	return &Point{X: x, Y: y}
+}
+
+// MapToCurve maps the field element u to a point of the curve, which is not
+// necessarily in the subgroup, with Elligator 2.
+func MapToCurve(u *big.Int) *Point {
+	return montgomeryToEdwards(mapToMontgomery(new(big.Int).Mod(u, constants.Q)))
+}
+
+// clearCofactor multiplies the point by the cofactor 8.
+func clearCofactor(p *Point) *Point {
+	return NewPoint().Mul(big.NewInt(8), p) //nolint:gomnd
+}
+
+// HashToCurve hashes the message to a point of the subgroup generated by B8
+// whose discrete logarithm is unknown, with the domain separation tag dst,
+// following the hash_to_curve random oracle encoding of RFC 9380.
+func HashToCurve(msg, dst []byte) *Point {
+	us := hashToField(msg, dst, 2) //nolint:gomnd
+	q0 := MapToCurve(us[0])
+	q1 := MapToCurve(us[1])
+	return clearCofactor(NewPoint().Add(q0, q1))
+}
+
+// EncodeToCurve encodes the message to a point of the subgroup generated by
+// B8 with the domain separation tag dst, following the encode_to_curve
+// nonuniform encoding of RFC 9380.
+func EncodeToCurve(msg, dst []byte) *Point {
+	us := hashToField(msg, dst, 1)
+	return clearCofactor(MapToCurve(us[0]))
+}
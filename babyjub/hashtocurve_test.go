@@ -0,0 +1,82 @@
+package babyjub
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	// RFC 9380 appendix K.1 test vectors
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	assert.Equal(t,
+		"68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235",
+		hex.EncodeToString(expandMessageXMD([]byte(""), dst, 0x20)))
+	assert.Equal(t,
+		"d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615",
+		hex.EncodeToString(expandMessageXMD([]byte("abc"), dst, 0x20)))
+	assert.Equal(t, 0x80, len(expandMessageXMD([]byte("abc"), dst, 0x80)))
+}
+
+func TestMapToCurve(t *testing.T) {
+	for i := int64(0); i < 32; i++ {
+		p := MapToCurve(big.NewInt(i))
+		assert.True(t, p.InCurve())
+	}
+	p := MapToCurve(big.NewInt(7))
+	assert.Equal(t,
+		"3817347745900761212264685878715686706258191367249405220897575995175280973256",
+		p.X.String())
+	assert.Equal(t,
+		"9897753547473352585008794513112642660876782329910405249047551693699069142071",
+		p.Y.String())
+
+	// u is reduced modulo Q
+	assert.True(t, MapToCurve(constants.Q).Equal(MapToCurve(big.NewInt(0))))
+}
+
+func TestHashToCurve(t *testing.T) {
+	dst := []byte("BABYJUB_XMD:SHA-256_ELL2_RO_")
+	vectors := []struct {
+		msg, x, y string
+	}{
+		{"",
+			"14171538016826925580787601458457881826016200738121676087338413420362832852510",
+			"21026603225306638151560388287768285512434535882897907068728622636991534878966"},
+		{"abc",
+			"18644141719360400528628477296917183344796215096783700726973716249661880303364",
+			"17504087495512961713762833522583722144302777251725767821477820217980874469897"},
+	}
+	for _, v := range vectors {
+		p := HashToCurve([]byte(v.msg), dst)
+		assert.Equal(t, v.x, p.X.String())
+		assert.Equal(t, v.y, p.Y.String())
+		assert.True(t, p.InSubGroup())
+	}
+
+	// different dst give different points
+	assert.False(t, HashToCurve([]byte("abc"), dst).Equal(
+		HashToCurve([]byte("abc"), []byte("OTHER_DST"))))
+
+	// oversized dst
+	p := HashToCurve([]byte("abc"), []byte(strings.Repeat("x", 300)))
+	assert.True(t, p.InSubGroup())
+}
+
+func TestEncodeToCurve(t *testing.T) {
+	p := EncodeToCurve([]byte("abc"), []byte("BABYJUB_XMD:SHA-256_ELL2_NU_"))
+	assert.Equal(t,
+		"2443337092499322603402983500212499431639578766128221828748382375506637105529",
+		p.X.String())
+	assert.Equal(t,
+		"649288464824844963586167130925110816376343053352157685986022029483998401826",
+		p.Y.String())
+	assert.True(t, p.InSubGroup())
+	for i := 0; i < 16; i++ {
+		assert.True(t, EncodeToCurve([]byte{byte(i)}, []byte("dst")).InSubGroup())
+	}
+}
@@ -0,0 +1,213 @@
+// Package hd implements hierarchical deterministic derivation of BabyJubJub
+// private keys, following SLIP-10 (the BIP32 variant for non-secp256k1
+// curves) with hardened derivation only.
+//
+// As in SLIP-10, the master key and chain code are the two halves of
+// HMAC-SHA512("Babyjub seed", seed), and the child key and chain code of the
+// index i are the two halves of HMAC-SHA512(chainCode, 0x00 || k || ser32(i)).
+// Each 32 byte half used as key is interpreted as a little-endian integer and
+// reduced by babyjub.SubOrder to obtain the scalar of the PrivateKey; in the
+// negligible case that the result is zero, the derivation is retried with
+// HMAC-SHA512(chainCode, 0x01 || IR || ser32(i)).
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// HardenedKeyStart is the index of the first hardened child key.
+const HardenedKeyStart uint32 = 0x80000000
+
+// MasterSecret is the HMAC key used to derive the master key from the seed.
+const MasterSecret = "Babyjub seed"
+
+const (
+	minSeedLen = 16
+	maxSeedLen = 64
+)
+
+var (
+	// ErrInvalidSeedLen is returned when the seed is not between 16 and 64
+	// bytes long.
+	ErrInvalidSeedLen = errors.New("seed length must be between 128 and 512 bits")
+	// ErrNotHardened is returned when deriving a non hardened child key,
+	// which is not supported for BabyJubJub.
+	ErrNotHardened = errors.New("only hardened derivation is supported")
+)
+
+// Key is an extended BabyJubJub private key: a private key together with the
+// chain code used to derive its children.
+type Key struct {
+	key       babyjub.PrivateKey
+	chainCode [32]byte
+	depth     uint8
+	index     uint32
+}
+
+// fromHMAC builds the key from the HMAC-SHA512 output I, retrying with the
+// HMAC of 0x01 || IR || ser32(index) while the reduced scalar is zero.
+func fromHMAC(i []byte, hmacKey []byte, index uint32) (babyjub.PrivateKey, [32]byte) {
+	for {
+		s := utils.SetBigIntFromLEBytes(new(big.Int), i[:32])
+		s.Mod(s, babyjub.SubOrder)
+		if s.Sign() != 0 {
+			var chainCode [32]byte
+			copy(chainCode[:], i[32:])
+			return babyjub.PrivateKey(utils.BigIntLEBytes(s)), chainCode
+		}
+		data := make([]byte, 0, 37) //nolint:gomnd
+		data = append(data, 0x01)
+		data = append(data, i[32:]...)
+		data = appendUint32(data, index)
+		i = hmacSHA512(hmacKey, data)
+	}
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	_, err := mac.Write(data)
+	if err != nil {
+		panic(err)
+	}
+	return mac.Sum(nil)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// NewMaster derives the master key from the seed, which must be between 16
+// and 64 bytes long.
+func NewMaster(seed []byte) (*Key, error) {
+	if len(seed) < minSeedLen || len(seed) > maxSeedLen {
+		return nil, ErrInvalidSeedLen
+	}
+	i := hmacSHA512([]byte(MasterSecret), seed)
+	key, chainCode := fromHMAC(i, []byte(MasterSecret), 0)
+	return &Key{key: key, chainCode: chainCode}, nil
+}
+
+// Child derives the child key of the given index, which must be hardened
+// (>= HardenedKeyStart).
+func (k *Key) Child(index uint32) (*Key, error) {
+	if index < HardenedKeyStart {
+		return nil, ErrNotHardened
+	}
+	data := make([]byte, 0, 37) //nolint:gomnd
+	data = append(data, 0x00)
+	data = append(data, k.key[:]...)
+	data = appendUint32(data, index)
+	i := hmacSHA512(k.chainCode[:], data)
+	key, chainCode := fromHMAC(i, k.chainCode[:], index)
+	return &Key{key: key, chainCode: chainCode, depth: k.depth + 1, index: index}, nil
+}
+
+// Derive derives the descendant key following the path.
+func (k *Key) Derive(path DerivationPath) (*Key, error) {
+	var err error
+	key := k
+	for _, index := range path {
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// PrivateKey returns the BabyJubJub private key.
+func (k *Key) PrivateKey() babyjub.PrivateKey {
+	return k.key
+}
+
+// Public returns the public key corresponding to the private key.
+func (k *Key) Public() *babyjub.PublicKey {
+	return k.key.Public()
+}
+
+// ChainCode returns the chain code of the key.
+func (k *Key) ChainCode() [32]byte {
+	return k.chainCode
+}
+
+// Depth returns the depth of the key in the derivation tree, 0 for the
+// master key.
+func (k *Key) Depth() uint8 {
+	return k.depth
+}
+
+// Index returns the index of the key in its parent, 0 for the master key.
+func (k *Key) Index() uint32 {
+	return k.index
+}
+
+// DerivationPath is a path of child indexes from the master key.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a derivation path such as "m/44'/60'/0'/0'".
+// Hardened indexes are marked with ', h or H; as only hardened derivation is
+// supported, an error is returned for non hardened indexes.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path must start with m: %q", s)
+	}
+	path := DerivationPath{}
+	for _, part := range parts[1:] {
+		hardened := false
+		for _, suffix := range []string{"'", "h", "H"} {
+			if strings.HasSuffix(part, suffix) {
+				part = strings.TrimSuffix(part, suffix)
+				hardened = true
+				break
+			}
+		}
+		if !hardened {
+			return nil, ErrNotHardened
+		}
+		v, err := strconv.ParseUint(part, 10, 32) //nolint:gomnd
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q in derivation path: %v", part, err)
+		}
+		if uint32(v) >= HardenedKeyStart {
+			return nil, fmt.Errorf("index %d out of range in derivation path", v)
+		}
+		path = append(path, uint32(v)+HardenedKeyStart)
+	}
+	return path, nil
+}
+
+// String returns the derivation path in the "m/44'/60'/0'" notation.
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		if index >= HardenedKeyStart {
+			fmt.Fprintf(&b, "/%d'", index-HardenedKeyStart)
+		} else {
+			fmt.Fprintf(&b, "/%d", index)
+		}
+	}
+	return b.String()
+}
+
+// MnemonicToSeed returns the BIP39 seed of a mnemonic sentence and an
+// optional passphrase.  The mnemonic and passphrase are used as given, so
+// they must already be in Unicode NFKD form (which is always the case for
+// the English wordlist); the mnemonic checksum is not verified.
+func MnemonicToSeed(mnemonic, passphrase string) []byte {
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, //nolint:gomnd
+		64, sha512.New) //nolint:gomnd
+}
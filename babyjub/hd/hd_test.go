@@ -0,0 +1,107 @@
+package hd
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMasterAndDerive(t *testing.T) {
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.Nil(t, err)
+	m, err := NewMaster(seed)
+	require.Nil(t, err)
+	k := m.PrivateKey()
+	c := m.ChainCode()
+	assert.Equal(t,
+		"5391ffabbb67eadb2e09d1f6052896d08a7d90aa0cd5d8d152843db3d131e801",
+		hex.EncodeToString(k[:]))
+	assert.Equal(t,
+		"696522a96169b6e75d4e8ce0778efaadea3bd7a66f01884ee6f9eca4861b1d42",
+		hex.EncodeToString(c[:]))
+
+	path, err := ParseDerivationPath("m/44'/60'/0'/0'")
+	require.Nil(t, err)
+	d, err := m.Derive(path)
+	require.Nil(t, err)
+	k = d.PrivateKey()
+	c = d.ChainCode()
+	assert.Equal(t,
+		"57a7d1a60e1e380b574d97f1e556c6ea7e5fce6b4fa65e04af2312a1b68f9202",
+		hex.EncodeToString(k[:]))
+	assert.Equal(t,
+		"a66409a4438c66d9c77572fe2e93c05e7ea9c22909965143d17637d0c0e8dc2a",
+		hex.EncodeToString(c[:]))
+	assert.Equal(t,
+		"8639ce24f5dc60dcdd08cf862a5f2b1451e82c37a595e41f4bcb699a6bbdb09e",
+		d.Public().String())
+	assert.Equal(t, uint8(4), d.Depth())
+	assert.Equal(t, HardenedKeyStart, d.Index())
+
+	// deriving step by step gives the same key
+	key := m
+	for _, index := range path {
+		key, err = key.Child(index)
+		require.Nil(t, err)
+	}
+	assert.Equal(t, d, key)
+
+	_, err = m.Child(0)
+	assert.Equal(t, ErrNotHardened, err)
+}
+
+func TestNewMasterSeedLen(t *testing.T) {
+	_, err := NewMaster(make([]byte, 15))
+	assert.Equal(t, ErrInvalidSeedLen, err)
+	_, err = NewMaster(make([]byte, 65))
+	assert.Equal(t, ErrInvalidSeedLen, err)
+	_, err = NewMaster(make([]byte, 64))
+	assert.Nil(t, err)
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	path, err := ParseDerivationPath("m/44'/60h/0H/7'")
+	require.Nil(t, err)
+	assert.Equal(t, DerivationPath{HardenedKeyStart + 44, HardenedKeyStart + 60,
+		HardenedKeyStart, HardenedKeyStart + 7}, path)
+	assert.Equal(t, "m/44'/60'/0'/7'", path.String())
+
+	path, err = ParseDerivationPath("m")
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(path))
+
+	_, err = ParseDerivationPath("m/44'/60'/0'/0")
+	assert.Equal(t, ErrNotHardened, err)
+	_, err = ParseDerivationPath("44'/60'")
+	assert.NotNil(t, err)
+	_, err = ParseDerivationPath("m/x'")
+	assert.NotNil(t, err)
+	_, err = ParseDerivationPath("m/2147483648'")
+	assert.NotNil(t, err)
+}
+
+func TestMnemonicToSeed(t *testing.T) {
+	// BIP39 test vector
+	seed := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon "+
+		"abandon abandon abandon abandon about", "TREZOR")
+	assert.Equal(t, ""+
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e5349553"+
+		"1f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		hex.EncodeToString(seed))
+
+	m, err := NewMaster(seed)
+	require.Nil(t, err)
+	path, err := ParseDerivationPath("m/44'/60'/0'/0'")
+	require.Nil(t, err)
+	d, err := m.Derive(path)
+	require.Nil(t, err)
+	k := d.PrivateKey()
+	assert.Equal(t,
+		"b668eebd38381dc34915ddd2e941598df3d4e434b40d98c3f5935b848b808501",
+		hex.EncodeToString(k[:]))
+	assert.Equal(t,
+		"d9d505c77f2dfd73c3086728f7aa869bbc473ccb5ab67ecb840cd33f4c16d425",
+		d.Public().String())
+}
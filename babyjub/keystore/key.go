@@ -0,0 +1,304 @@
+// Package keystore implements an encrypted key file format for BabyJubJub
+// private keys, modelled on the Ethereum keystore v3 JSON format, and a
+// directory backed KeyStore to manage them.
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Version is the version of the key file format.
+	Version = 3
+
+	// KDFScrypt identifies the scrypt key derivation function.
+	KDFScrypt = "scrypt"
+	// KDFArgon2id identifies the argon2id key derivation function.
+	KDFArgon2id = "argon2id"
+
+	cipherName = "aes-128-ctr"
+	dkLen      = 32
+	saltLen    = 32
+
+	// MaxScryptMemory is the maximum memory in bytes, 128*N*r, that scrypt
+	// may use to decrypt a key file, so that a crafted key file can't
+	// exhaust the memory.
+	MaxScryptMemory = 1 << 30
+	// MaxScryptCost is the maximum CPU cost, N*r*p, of scrypt to decrypt a
+	// key file.
+	MaxScryptCost = 1 << 24
+	// MaxArgon2Memory is the maximum memory in KiB that argon2id may use to
+	// decrypt a key file.
+	MaxArgon2Memory = 1 << 20
+	// MaxArgon2Time is the maximum number of argon2id passes to decrypt a
+	// key file.
+	MaxArgon2Time = 16
+)
+
+var (
+	// ErrDecrypt is returned when the key file can not be decrypted with the
+	// given passphrase.
+	ErrDecrypt = errors.New("could not decrypt key with given passphrase")
+)
+
+// Params are the key derivation parameters used to encrypt a key.
+type Params struct {
+	KDF string
+	// scrypt parameters
+	ScryptN int
+	ScryptR int
+	ScryptP int
+	// argon2id parameters
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+var (
+	// StandardParams uses scrypt with the same cost as the standard Ethereum
+	// keystore, taking about 1s of CPU and 256MB of memory.
+	StandardParams = Params{KDF: KDFScrypt, ScryptN: 1 << 18, ScryptR: 8, ScryptP: 1}
+	// LightParams uses scrypt with the same cost as the light Ethereum
+	// keystore, taking about 100ms of CPU and 4MB of memory.
+	LightParams = Params{KDF: KDFScrypt, ScryptN: 1 << 12, ScryptR: 8, ScryptP: 6}
+	// Argon2idParams uses argon2id with the RFC 9106 second recommended
+	// option, taking 64MB of memory.
+	Argon2idParams = Params{KDF: KDFArgon2id, Argon2Time: 3, Argon2Memory: 64 * 1024,
+		Argon2Threads: 4}
+)
+
+// keyJSON is the key file JSON representation.
+type keyJSON struct {
+	PublicKey babyjub.PublicKeyComp `json:"publicKey"`
+	Crypto    cryptoJSON            `json:"crypto"`
+	ID        string                `json:"id"`
+	Version   int                   `json:"version"`
+}
+
+type cryptoJSON struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams cipherParamsJSON       `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+type cipherParamsJSON struct {
+	IV string `json:"iv"`
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID.
+func newUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40 //nolint:gomnd
+	u[8] = (u[8] & 0x3f) | 0x80 //nolint:gomnd
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
+
+// deriveKey derives the encryption key from the passphrase, following the
+// kdf parameters.
+func deriveKey(passphrase []byte, kdf string, params map[string]interface{}) ([]byte, error) {
+	salt, err := hex.DecodeString(paramString(params, "salt"))
+	if err != nil {
+		return nil, err
+	}
+	if n := paramInt(params, "dklen"); n != dkLen {
+		return nil, fmt.Errorf("unsupported dklen %d", n)
+	}
+	switch kdf {
+	case KDFScrypt:
+		n := paramInt(params, "n")
+		r := paramInt(params, "r")
+		p := paramInt(params, "p")
+		// the parameters come from the key file, so they are bounded before
+		// deriving; 128*N*r is the memory and N*r*p the cost of scrypt
+		if n <= 1 || n&(n-1) != 0 || r <= 0 || p <= 0 ||
+			n > MaxScryptMemory/128/r || n*r > MaxScryptCost/p {
+			return nil, fmt.Errorf("invalid or too costly scrypt parameters")
+		}
+		return scrypt.Key(passphrase, salt, n, r, p, dkLen)
+	case KDFArgon2id:
+		iters := paramInt(params, "t")
+		memory := paramInt(params, "m")
+		threads := paramInt(params, "p")
+		if iters <= 0 || memory <= 0 || threads <= 0 || threads > 255 ||
+			iters > MaxArgon2Time || memory > MaxArgon2Memory {
+			return nil, fmt.Errorf("invalid or too costly argon2id parameters")
+		}
+		return argon2.IDKey(passphrase, salt, uint32(iters), uint32(memory),
+			uint8(threads), uint32(dkLen)), nil
+	default:
+		return nil, fmt.Errorf("unsupported kdf %q", kdf)
+	}
+}
+
+func paramString(params map[string]interface{}, name string) string {
+	s, _ := params[name].(string)
+	return s
+}
+
+// paramInt returns the integer parameter, or 0 if it is missing or is not an
+// integer that fits in 32 bits.
+func paramInt(params map[string]interface{}, name string) int {
+	f, _ := params[name].(float64)
+	if f != float64(int64(f)) || f < 0 || f > 1<<32-1 {
+		return 0
+	}
+	return int(f)
+}
+
+func aesCTRXOR(key, inText, iv []byte) ([]byte, error) {
+	aesBlock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	stream := cipher.NewCTR(aesBlock, iv)
+	outText := make([]byte, len(inText))
+	stream.XORKeyStream(outText, inText)
+	return outText, nil
+}
+
+// Encrypt encrypts the private key with the passphrase, using the given key
+// derivation parameters, and returns the key file JSON.
+func Encrypt(sk *babyjub.PrivateKey, passphrase string, params Params) ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	kdfParams := map[string]interface{}{
+		"salt":  hex.EncodeToString(salt),
+		"dklen": dkLen,
+	}
+	switch params.KDF {
+	case KDFScrypt:
+		kdfParams["n"] = params.ScryptN
+		kdfParams["r"] = params.ScryptR
+		kdfParams["p"] = params.ScryptP
+	case KDFArgon2id:
+		kdfParams["t"] = params.Argon2Time
+		kdfParams["m"] = params.Argon2Memory
+		kdfParams["p"] = params.Argon2Threads
+	default:
+		return nil, fmt.Errorf("unsupported kdf %q", params.KDF)
+	}
+	// roundtrip the parameters through json so that they are read back in
+	// the same way as when decrypting
+	b, err := json.Marshal(kdfParams)
+	if err != nil {
+		return nil, err
+	}
+	kdfParams = map[string]interface{}{}
+	if err := json.Unmarshal(b, &kdfParams); err != nil {
+		return nil, err
+	}
+	derivedKey, err := deriveKey([]byte(passphrase), params.KDF, kdfParams)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	cipherText, err := aesCTRXOR(derivedKey[:16], sk[:], iv)
+	if err != nil {
+		return nil, err
+	}
+	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(keyJSON{
+		PublicKey: sk.Public().Compress(),
+		Crypto: cryptoJSON{
+			Cipher:       cipherName,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: cipherParamsJSON{IV: hex.EncodeToString(iv)},
+			KDF:          params.KDF,
+			KDFParams:    kdfParams,
+			MAC:          hex.EncodeToString(mac),
+		},
+		ID:      id,
+		Version: Version,
+	})
+}
+
+// Decrypt decrypts the key file JSON with the passphrase, and returns the
+// private key.  It returns ErrDecrypt if the passphrase is wrong, and an error
+// if the decrypted key does not match the public key of the key file.
+func Decrypt(keyJSONBytes []byte, passphrase string) (*babyjub.PrivateKey, error) {
+	var k keyJSON
+	if err := json.Unmarshal(keyJSONBytes, &k); err != nil {
+		return nil, err
+	}
+	if k.Version != Version {
+		return nil, fmt.Errorf("unsupported key file version %d", k.Version)
+	}
+	if k.Crypto.Cipher != cipherName {
+		return nil, fmt.Errorf("unsupported cipher %q", k.Crypto.Cipher)
+	}
+	mac, err := hex.DecodeString(k.Crypto.MAC)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(k.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid iv length %d", len(iv))
+	}
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	if len(cipherText) != len(babyjub.PrivateKey{}) {
+		return nil, fmt.Errorf("invalid ciphertext length %d", len(cipherText))
+	}
+	derivedKey, err := deriveKey([]byte(passphrase), k.Crypto.KDF, k.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	calculatedMAC := crypto.Keccak256(derivedKey[16:32], cipherText)
+	if !bytes.Equal(calculatedMAC, mac) {
+		return nil, ErrDecrypt
+	}
+	plainText, err := aesCTRXOR(derivedKey[:16], cipherText, iv)
+	if err != nil {
+		return nil, err
+	}
+	var sk babyjub.PrivateKey
+	copy(sk[:], plainText)
+	if sk.Public().Compress() != k.PublicKey {
+		return nil, fmt.Errorf("decrypted key does not match public key %v", k.PublicKey)
+	}
+	return &sk, nil
+}
+
+// PublicKey returns the public key stored in plain text in the key file JSON,
+// without decrypting it.
+func PublicKey(keyJSONBytes []byte) (babyjub.PublicKeyComp, error) {
+	var k keyJSON
+	if err := json.Unmarshal(keyJSONBytes, &k); err != nil {
+		return babyjub.PublicKeyComp{}, err
+	}
+	return k.PublicKey, nil
+}
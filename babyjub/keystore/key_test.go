@@ -0,0 +1,149 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testParams = Params{KDF: KDFScrypt, ScryptN: 1 << 10, ScryptR: 8, ScryptP: 1}
+
+var testArgon2Params = Params{KDF: KDFArgon2id, Argon2Time: 1, Argon2Memory: 1024,
+	Argon2Threads: 1}
+
+func testKey() babyjub.PrivateKey {
+	var sk babyjub.PrivateKey
+	for i := range sk {
+		sk[i] = byte(i)
+	}
+	return sk
+}
+
+func TestDecryptVectors(t *testing.T) {
+	scryptJSON := `{"publicKey":"7f574dc9eee9f988b3e2b662a4ba3e0bfbbde12a98f7d9641b89084cb530a987",` +
+		`"crypto":{"cipher":"aes-128-ctr",` +
+		`"ciphertext":"387e1e98aa0e3d65771ab614506d6932084e983926291590220e6ea576d7b958",` +
+		`"cipherparams":{"iv":"f4827a489f2d4be26e262ccc52d09d4a"},"kdf":"scrypt",` +
+		`"kdfparams":{"dklen":32,"n":1024,"p":1,"r":8,` +
+		`"salt":"640ceb11e0bb0b08d131e6628f5f3b1e0dca1db4191679653699872e2c9891ec"},` +
+		`"mac":"409c1187697363516b149ff8a25d2c94cfece044c6f946d88f9795ebfef2e958"},` +
+		`"id":"bc5e91c1-9f45-4495-ab08-70e5646a9fee","version":3}`
+	argon2JSON := `{"publicKey":"7f574dc9eee9f988b3e2b662a4ba3e0bfbbde12a98f7d9641b89084cb530a987",` +
+		`"crypto":{"cipher":"aes-128-ctr",` +
+		`"ciphertext":"81e30f3c0b21e651bead631882c7d0327fcf8a5fc98629f2afcd318388ed08a7",` +
+		`"cipherparams":{"iv":"2c00dbdf50cd90c2c6e459fa472e9154"},"kdf":"argon2id",` +
+		`"kdfparams":{"dklen":32,"m":1024,"p":1,` +
+		`"salt":"2d64d370084192c07e036a6c2a18d27341f78c3eec715248eab774082690e0d5","t":1},` +
+		`"mac":"8053e3fefdb4dcb6df1f2a165cf4ec61d477d70b882f46f408b22dac583ba95f"},` +
+		`"id":"23aa1e7b-8b97-4b04-9e77-124e021985aa","version":3}`
+
+	sk := testKey()
+	for _, keyJSON := range []string{scryptJSON, argon2JSON} {
+		pkComp, err := PublicKey([]byte(keyJSON))
+		require.Nil(t, err)
+		assert.Equal(t,
+			"7f574dc9eee9f988b3e2b662a4ba3e0bfbbde12a98f7d9641b89084cb530a987",
+			pkComp.String())
+
+		sk2, err := Decrypt([]byte(keyJSON), "testpassword")
+		require.Nil(t, err)
+		assert.Equal(t, sk, *sk2)
+
+		_, err = Decrypt([]byte(keyJSON), "wrongpassword")
+		assert.Equal(t, ErrDecrypt, err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	sk := testKey()
+	for _, params := range []Params{testParams, testArgon2Params} {
+		keyJSON, err := Encrypt(&sk, "pass", params)
+		require.Nil(t, err)
+		sk2, err := Decrypt(keyJSON, "pass")
+		require.Nil(t, err)
+		assert.Equal(t, sk, *sk2)
+	}
+
+	_, err := Encrypt(&sk, "pass", Params{KDF: "pbkdf2"})
+	assert.Equal(t, `unsupported kdf "pbkdf2"`, err.Error())
+}
+
+func TestDecryptTampered(t *testing.T) {
+	sk := testKey()
+	keyJSON, err := Encrypt(&sk, "pass", testParams)
+	require.Nil(t, err)
+
+	// a public key that doesn't match the encrypted key is rejected
+	var k map[string]interface{}
+	require.Nil(t, json.Unmarshal(keyJSON, &k))
+	other := babyjub.NewRandPrivKey()
+	k["publicKey"] = hex.EncodeToString(func() []byte {
+		c := other.Public().Compress()
+		return c[:]
+	}())
+	tampered, err := json.Marshal(k)
+	require.Nil(t, err)
+	_, err = Decrypt(tampered, "pass")
+	assert.NotNil(t, err)
+
+	// a modified ciphertext fails the mac check
+	require.Nil(t, json.Unmarshal(keyJSON, &k))
+	c := k["crypto"].(map[string]interface{})
+	c["ciphertext"] = "00" + c["ciphertext"].(string)[2:]
+	tampered, err = json.Marshal(k)
+	require.Nil(t, err)
+	_, err = Decrypt(tampered, "pass")
+	assert.Equal(t, ErrDecrypt, err)
+}
+
+func TestDecryptCostlyParams(t *testing.T) {
+	sk := testKey()
+	for _, params := range []Params{testParams, testArgon2Params} {
+		keyJSON, err := Encrypt(&sk, "pass", params)
+		require.Nil(t, err)
+		var costly []map[string]interface{}
+		if params.KDF == KDFScrypt {
+			costly = []map[string]interface{}{
+				{"n": float64(1 << 30)},
+				{"n": float64(1 << 20), "r": float64(16)},
+				{"p": float64(1 << 20)},
+				{"n": float64(3)},
+				{"r": float64(1e300)},
+			}
+		} else {
+			costly = []map[string]interface{}{
+				{"m": float64(1 << 30)},
+				{"t": float64(1 << 20)},
+				{"m": float64(-1)},
+			}
+		}
+		for _, c := range costly {
+			var k map[string]interface{}
+			require.Nil(t, json.Unmarshal(keyJSON, &k))
+			kdfParams := k["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})
+			for name, v := range c {
+				kdfParams[name] = v
+			}
+			crafted, err := json.Marshal(k)
+			require.Nil(t, err)
+			_, err = Decrypt(crafted, "pass")
+			assert.NotNil(t, err)
+			assert.NotEqual(t, ErrDecrypt, err)
+		}
+	}
+
+	// the presets are within the limits
+	for _, params := range []Params{StandardParams, LightParams, Argon2idParams} {
+		if params.KDF == KDFScrypt {
+			assert.LessOrEqual(t, 128*params.ScryptN*params.ScryptR, MaxScryptMemory)
+			assert.LessOrEqual(t, params.ScryptN*params.ScryptR*params.ScryptP, MaxScryptCost)
+		} else {
+			assert.LessOrEqual(t, int(params.Argon2Memory), MaxArgon2Memory)
+			assert.LessOrEqual(t, int(params.Argon2Time), MaxArgon2Time)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+)
+
+var (
+	// ErrNoMatch is returned when there is no key file for a public key.
+	ErrNoMatch = errors.New("no key for given public key")
+	// ErrLocked is returned when signing with a key that is not unlocked.
+	ErrLocked = errors.New("key is locked")
+	// ErrExists is returned when importing a key that is already stored.
+	ErrExists = errors.New("key already exists")
+)
+
+// KeyStore manages a directory of encrypted key files, keeping in memory the
+// keys that have been unlocked.
+type KeyStore struct {
+	dir      string
+	params   Params
+	mu       sync.RWMutex
+	unlocked map[babyjub.PublicKeyComp]*babyjub.PrivateKey
+}
+
+// NewKeyStore creates a KeyStore over the directory dir, creating it if it
+// doesn't exist.  New keys are encrypted with the given parameters.
+func NewKeyStore(dir string, params Params) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil { //nolint:gomnd
+		return nil, err
+	}
+	return &KeyStore{
+		dir:      dir,
+		params:   params,
+		unlocked: make(map[babyjub.PublicKeyComp]*babyjub.PrivateKey),
+	}, nil
+}
+
+// keyFileName returns the file name for a key, following the Ethereum
+// keystore convention of UTC--<created_at UTC ISO8601>--<public key>.
+func keyFileName(pkComp babyjub.PublicKeyComp) string {
+	ts := time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z")
+	return fmt.Sprintf("UTC--%s--%s", ts, pkComp.String())
+}
+
+// files returns the key files in the directory by public key.
+func (ks *KeyStore) files() (map[babyjub.PublicKeyComp]string, error) {
+	infos, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[babyjub.PublicKeyComp]string)
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(ks.dir, name)
+		keyJSON, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+		pkComp, err := PublicKey(keyJSON)
+		if err != nil {
+			// skip files that are not key files
+			continue
+		}
+		files[pkComp] = path
+	}
+	return files, nil
+}
+
+func (ks *KeyStore) find(pkComp babyjub.PublicKeyComp) (string, error) {
+	files, err := ks.files()
+	if err != nil {
+		return "", err
+	}
+	path, ok := files[pkComp]
+	if !ok {
+		return "", ErrNoMatch
+	}
+	return path, nil
+}
+
+// List returns the public keys of all the keys in the KeyStore, sorted by
+// their compressed representation.
+func (ks *KeyStore) List() ([]babyjub.PublicKeyComp, error) {
+	files, err := ks.files()
+	if err != nil {
+		return nil, err
+	}
+	pks := make([]babyjub.PublicKeyComp, 0, len(files))
+	for pkComp := range files {
+		pks = append(pks, pkComp)
+	}
+	sort.Slice(pks, func(i, j int) bool {
+		return bytes.Compare(pks[i][:], pks[j][:]) < 0
+	})
+	return pks, nil
+}
+
+// store encrypts the private key and writes it into a new key file.
+func (ks *KeyStore) store(sk *babyjub.PrivateKey, passphrase string) (babyjub.PublicKeyComp,
+	error) {
+	pkComp := sk.Public().Compress()
+	keyJSON, err := Encrypt(sk, passphrase, ks.params)
+	if err != nil {
+		return pkComp, err
+	}
+	// hold the lock from the lookup to the write, so that concurrent stores
+	// of the same key don't both write a key file
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, err := ks.find(pkComp); err == nil {
+		return pkComp, ErrExists
+	} else if err != ErrNoMatch {
+		return pkComp, err
+	}
+	// write to a temporary file and rename it so that partially written
+	// files are never listed
+	path := filepath.Join(ks.dir, keyFileName(pkComp))
+	tmp, err := ioutil.TempFile(ks.dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return pkComp, err
+	}
+	if _, err := tmp.Write(keyJSON); err != nil {
+		tmp.Close()           //nolint:errcheck,gosec
+		os.Remove(tmp.Name()) //nolint:errcheck,gosec
+		return pkComp, err
+	}
+	if err := tmp.Close(); err != nil {
+		return pkComp, err
+	}
+	return pkComp, os.Rename(tmp.Name(), path)
+}
+
+// NewKey generates a new random key, stores it encrypted with the passphrase
+// and returns its public key.
+func (ks *KeyStore) NewKey(passphrase string) (babyjub.PublicKeyComp, error) {
+	sk := babyjub.NewRandPrivKey()
+	return ks.store(&sk, passphrase)
+}
+
+// ImportPrivateKey stores the private key encrypted with the passphrase.
+func (ks *KeyStore) ImportPrivateKey(sk *babyjub.PrivateKey,
+	passphrase string) (babyjub.PublicKeyComp, error) {
+	return ks.store(sk, passphrase)
+}
+
+// Import decrypts the key file JSON with passphrase and stores it encrypted
+// with newPassphrase.
+func (ks *KeyStore) Import(keyJSON []byte, passphrase,
+	newPassphrase string) (babyjub.PublicKeyComp, error) {
+	sk, err := Decrypt(keyJSON, passphrase)
+	if err != nil {
+		return babyjub.PublicKeyComp{}, err
+	}
+	return ks.store(sk, newPassphrase)
+}
+
+// Export decrypts the key of the public key with passphrase and returns its
+// key file JSON encrypted with newPassphrase.
+func (ks *KeyStore) Export(pkComp babyjub.PublicKeyComp, passphrase,
+	newPassphrase string) ([]byte, error) {
+	sk, err := ks.decrypt(pkComp, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(sk, newPassphrase, ks.params)
+}
+
+// Delete decrypts the key of the public key with passphrase to check that it
+// is correct, locks it and removes its key file.
+func (ks *KeyStore) Delete(pkComp babyjub.PublicKeyComp, passphrase string) error {
+	if _, err := ks.decrypt(pkComp, passphrase); err != nil {
+		return err
+	}
+	ks.Lock(pkComp)
+	path, err := ks.find(pkComp)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (ks *KeyStore) decrypt(pkComp babyjub.PublicKeyComp,
+	passphrase string) (*babyjub.PrivateKey, error) {
+	path, err := ks.find(pkComp)
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(keyJSON, passphrase)
+}
+
+// Unlock decrypts the key of the public key with passphrase and keeps it in
+// memory until Lock is called.
+func (ks *KeyStore) Unlock(pkComp babyjub.PublicKeyComp, passphrase string) error {
+	sk, err := ks.decrypt(pkComp, passphrase)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if old, ok := ks.unlocked[pkComp]; ok {
+		zeroKey(old)
+	}
+	ks.unlocked[pkComp] = sk
+	return nil
+}
+
+// Lock removes the private key of the public key from memory.
+func (ks *KeyStore) Lock(pkComp babyjub.PublicKeyComp) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if sk, ok := ks.unlocked[pkComp]; ok {
+		zeroKey(sk)
+		delete(ks.unlocked, pkComp)
+	}
+}
+
+// IsUnlocked returns true if the key of the public key is unlocked.
+func (ks *KeyStore) IsUnlocked(pkComp babyjub.PublicKeyComp) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	_, ok := ks.unlocked[pkComp]
+	return ok
+}
+
+func zeroKey(sk *babyjub.PrivateKey) {
+	for i := range sk {
+		sk[i] = 0
+	}
+}
+
+// Signer returns a Signer for the key of the public key.  The key must be
+// unlocked when signing.
+func (ks *KeyStore) Signer(pkComp babyjub.PublicKeyComp) (*Signer, error) {
+	pk, err := pkComp.Decompress()
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{ks: ks, pkComp: pkComp, pk: pk}, nil
+}
+
+// Signer signs messages with a key of a KeyStore while it is unlocked.
+type Signer struct {
+	ks     *KeyStore
+	pkComp babyjub.PublicKeyComp
+	pk     *babyjub.PublicKey
+}
+
+// Public returns the public key of the Signer.
+func (s *Signer) Public() *babyjub.PublicKey {
+	return s.pk
+}
+
+// SignPoseidon signs the message with the unlocked key using Poseidon, see
+// babyjub.PrivateKey.SignPoseidon.  Returns ErrLocked if the key is locked.
+func (s *Signer) SignPoseidon(msg *big.Int) (*babyjub.Signature, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	sk, ok := s.ks.unlocked[s.pkComp]
+	if !ok {
+		return nil, ErrLocked
+	}
+	return sk.SignPoseidon(msg), nil
+}
+
+// SignMimc7 signs the message with the unlocked key using MiMC7, see
+// babyjub.PrivateKey.SignMimc7.  Returns ErrLocked if the key is locked.
+func (s *Signer) SignMimc7(msg *big.Int) (*babyjub.Signature, error) {
+	s.ks.mu.RLock()
+	defer s.ks.mu.RUnlock()
+	sk, ok := s.ks.unlocked[s.pkComp]
+	if !ok {
+		return nil, ErrLocked
+	}
+	return sk.SignMimc7(msg), nil
+}
@@ -0,0 +1,129 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyStore(t *testing.T) (*KeyStore, func()) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.Nil(t, err)
+	ks, err := NewKeyStore(dir, testParams)
+	require.Nil(t, err)
+	return ks, func() { os.RemoveAll(dir) } //nolint:errcheck,gosec
+}
+
+func TestKeyStore(t *testing.T) {
+	ks, cleanup := newTestKeyStore(t)
+	defer cleanup()
+
+	pks, err := ks.List()
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(pks))
+
+	pk1, err := ks.NewKey("pass1")
+	require.Nil(t, err)
+	sk := testKey()
+	pk2, err := ks.ImportPrivateKey(&sk, "pass2")
+	require.Nil(t, err)
+	assert.Equal(t, sk.Public().Compress(), pk2)
+
+	_, err = ks.ImportPrivateKey(&sk, "pass2")
+	assert.Equal(t, ErrExists, err)
+
+	pks, err = ks.List()
+	require.Nil(t, err)
+	assert.Equal(t, 2, len(pks))
+	assert.Contains(t, pks, pk1)
+	assert.Contains(t, pks, pk2)
+
+	// export and import into another KeyStore with a new passphrase
+	keyJSON, err := ks.Export(pk2, "pass2", "pass3")
+	require.Nil(t, err)
+	_, err = ks.Export(pk2, "wrong", "pass3")
+	assert.Equal(t, ErrDecrypt, err)
+
+	ks2, cleanup2 := newTestKeyStore(t)
+	defer cleanup2()
+	pk, err := ks2.Import(keyJSON, "pass3", "pass4")
+	require.Nil(t, err)
+	assert.Equal(t, pk2, pk)
+	require.Nil(t, ks2.Unlock(pk2, "pass4"))
+
+	// delete
+	require.Nil(t, ks.Delete(pk1, "pass1"))
+	pks, err = ks.List()
+	require.Nil(t, err)
+	assert.Equal(t, []babyjub.PublicKeyComp{pk2}, pks)
+	assert.Equal(t, ErrNoMatch, ks.Unlock(pk1, "pass1"))
+}
+
+func TestKeyStoreSigner(t *testing.T) {
+	ks, cleanup := newTestKeyStore(t)
+	defer cleanup()
+
+	sk := testKey()
+	pkComp, err := ks.ImportPrivateKey(&sk, "pass")
+	require.Nil(t, err)
+	signer, err := ks.Signer(pkComp)
+	require.Nil(t, err)
+	assert.Equal(t, sk.Public(), signer.Public())
+
+	msg := big.NewInt(42)
+	_, err = signer.SignPoseidon(msg)
+	assert.Equal(t, ErrLocked, err)
+
+	assert.Equal(t, ErrDecrypt, ks.Unlock(pkComp, "wrong"))
+	require.Nil(t, ks.Unlock(pkComp, "pass"))
+	assert.True(t, ks.IsUnlocked(pkComp))
+
+	sig, err := signer.SignPoseidon(msg)
+	require.Nil(t, err)
+	assert.Equal(t, sk.SignPoseidon(msg), sig)
+	assert.True(t, signer.Public().VerifyPoseidon(msg, sig))
+
+	sig, err = signer.SignMimc7(msg)
+	require.Nil(t, err)
+	assert.True(t, signer.Public().VerifyMimc7(msg, sig))
+
+	ks.Lock(pkComp)
+	assert.False(t, ks.IsUnlocked(pkComp))
+	_, err = signer.SignPoseidon(msg)
+	assert.Equal(t, ErrLocked, err)
+}
+
+func TestKeyStoreConcurrentImport(t *testing.T) {
+	ks, cleanup := newTestKeyStore(t)
+	defer cleanup()
+
+	sk := testKey()
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = ks.ImportPrivateKey(&sk, "pass")
+		}(i)
+	}
+	wg.Wait()
+	stored := 0
+	for _, err := range errs {
+		if err == nil {
+			stored++
+		} else {
+			assert.Equal(t, ErrExists, err)
+		}
+	}
+	assert.Equal(t, 1, stored)
+	infos, err := ioutil.ReadDir(ks.dir)
+	require.Nil(t, err)
+	assert.Len(t, infos, 1)
+}
@@ -0,0 +1,165 @@
+package babyjub
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// bigIntBEBytes encodes a big.Int into a 32 byte array in Big-Endian.
+func bigIntBEBytes(v *big.Int) [32]byte {
+	res := [32]byte{}
+	b := v.Bytes()
+	copy(res[32-len(b):], b)
+	return res
+}
+
+// decodeCoord decodes a 32 byte Big-Endian hex string into a coordinate,
+// checking that it is a canonical element of the field Q.
+func decodeCoord(h string) (*big.Int, error) {
+	var buf [32]byte
+	if err := utils.HexDecodeInto(buf[:], []byte(h)); err != nil {
+		return nil, err
+	}
+	v := new(big.Int).SetBytes(buf[:])
+	if !utils.CheckBigIntInField(v) {
+		return nil, fmt.Errorf("coordinate %s is not canonical, must be < Q", v)
+	}
+	return v, nil
+}
+
+// SignatureLoopring represents an EdDSA signature in the format used by the
+// Loopring API: the Big-Endian concatenation of R8.X, R8.Y and S, 32 bytes
+// each.
+type SignatureLoopring [96]byte
+
+// MarshalText implements the marshaler for the SignatureLoopring
+func (sLr SignatureLoopring) MarshalText() ([]byte, error) {
+	return []byte(utils.HexEncode(sLr[:])), nil
+}
+
+// String returns the string representation of the SignatureLoopring
+func (sLr SignatureLoopring) String() string { return utils.HexEncode(sLr[:]) }
+
+// UnmarshalText implements the unmarshaler for the SignatureLoopring,
+// validating the decoded signature.
+func (sLr *SignatureLoopring) UnmarshalText(h []byte) error {
+	if len(h)%2 != 0 {
+		return fmt.Errorf("odd length hex string")
+	}
+	var buf SignatureLoopring
+	if err := utils.HexDecodeInto(buf[:], h); err != nil {
+		return err
+	}
+	if _, err := buf.Decode(); err != nil {
+		return err
+	}
+	*sLr = buf
+	return nil
+}
+
+// Loopring encodes an EdDSA signature in the format used by the Loopring API.
+func (s *Signature) Loopring() SignatureLoopring {
+	var buf SignatureLoopring
+	rx := bigIntBEBytes(s.R8.X)
+	ry := bigIntBEBytes(s.R8.Y)
+	sb := bigIntBEBytes(s.S)
+	copy(buf[:32], rx[:])
+	copy(buf[32:64], ry[:])
+	copy(buf[64:], sb[:])
+	return buf
+}
+
+// Decode returns the Signature for the given SignatureLoopring.  Returns
+// error if any of the values is not canonical, if R8 is not in the curve or
+// not in the subgroup, or if S is not in [0, SubOrder), so that each
+// signature has a single encoding.
+func (sLr *SignatureLoopring) Decode() (*Signature, error) {
+	x := new(big.Int).SetBytes(sLr[:32])
+	y := new(big.Int).SetBytes(sLr[32:64])
+	s := new(big.Int).SetBytes(sLr[64:])
+	if !utils.CheckBigIntInField(x) || !utils.CheckBigIntInField(y) {
+		return nil, fmt.Errorf("R8 coordinates are not canonical, must be < Q")
+	}
+	if s.Cmp(SubOrder) >= 0 {
+		return nil, fmt.Errorf("S is not canonical, must be < SubOrder")
+	}
+	r8 := &Point{X: x, Y: y}
+	if !r8.InCurve() {
+		return nil, fmt.Errorf("R8 is not in the curve")
+	}
+	if !r8.InSubGroup() {
+		return nil, fmt.Errorf("R8 is not in the subgroup")
+	}
+	return &Signature{R8: r8, S: s}, nil
+}
+
+// NewSignatureFromLoopring parses a signature in the Loopring API string
+// format ("0x" followed by 192 hex characters).
+func NewSignatureFromLoopring(h string) (*Signature, error) {
+	var sLr SignatureLoopring
+	if err := sLr.UnmarshalText([]byte(h)); err != nil {
+		return nil, err
+	}
+	return sLr.Decode()
+}
+
+// PublicKeyLoopring represents an EdDSA public key in the format used by the
+// Loopring API: the X and Y coordinates as separate 32 byte Big-Endian hex
+// strings.
+type PublicKeyLoopring struct {
+	X string `json:"publicKeyX"`
+	Y string `json:"publicKeyY"`
+}
+
+// Loopring encodes the PublicKey in the format used by the Loopring API.
+func (pk *PublicKey) Loopring() PublicKeyLoopring {
+	x := bigIntBEBytes(pk.X)
+	y := bigIntBEBytes(pk.Y)
+	return PublicKeyLoopring{
+		X: utils.HexEncode(x[:]),
+		Y: utils.HexEncode(y[:]),
+	}
+}
+
+// Decode returns the PublicKey for the given PublicKeyLoopring.  Returns error
+// if any coordinate is not canonical or if the point is not in the curve.
+func (pkLr *PublicKeyLoopring) Decode() (*PublicKey, error) {
+	x, err := decodeCoord(pkLr.X)
+	if err != nil {
+		return nil, fmt.Errorf("publicKeyX: %v", err)
+	}
+	y, err := decodeCoord(pkLr.Y)
+	if err != nil {
+		return nil, fmt.Errorf("publicKeyY: %v", err)
+	}
+	p := &Point{X: x, Y: y}
+	if !p.InCurve() {
+		return nil, fmt.Errorf("public key is not in the curve")
+	}
+	pk := PublicKey(*p)
+	return &pk, nil
+}
+
+// NewPublicKeyFromLoopring parses a public key from the X and Y hex strings
+// returned by the Loopring API.
+func NewPublicKeyFromLoopring(x, y string) (*PublicKey, error) {
+	return (&PublicKeyLoopring{X: x, Y: y}).Decode()
+}
+
+// UnmarshalJSON implements the json unmarshaler for the PublicKeyLoopring,
+// validating the decoded coordinates.
+func (pkLr *PublicKeyLoopring) UnmarshalJSON(b []byte) error {
+	type plain PublicKeyLoopring
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	if _, err := (*PublicKeyLoopring)(&p).Decode(); err != nil {
+		return err
+	}
+	*pkLr = PublicKeyLoopring(p)
+	return nil
+}
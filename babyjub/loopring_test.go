@@ -0,0 +1,130 @@
+package babyjub
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureLoopring(t *testing.T) {
+	msg := utils.NewIntFromString(
+		"18907120458743615336946847248227397370763473802204269898187195559525130063203")
+	var k PrivateKey
+	k = utils.BigIntLEBytes(big.NewInt(56869496543825))
+	pk := k.Public()
+
+	sig := &Signature{
+		R8: &Point{
+			X: utils.NewIntFromString(
+				"12752937249904285198676276090843566060401682639184875784873451302664399892304"),
+			Y: utils.NewIntFromString(
+				"13530361082613950739674235863189737173485045373827356210876301607961589355327"),
+		},
+		S: utils.NewIntFromString(
+			"2144194128120841925370615083456418354883527833409110536653071950220286134490"),
+	}
+
+	sLr := sig.Loopring()
+	assert.Equal(t, "0x"+
+		"1c31e81cdde3c9f92e31ab35733e3403de45325cb5f90832c9d4f8673ec22f50"+
+		"1de9e9b97c8d7b475ab12836d87c9c6f2a78a91cd650bc77ec4079ffd966933f"+
+		"04bd92247ba1bef5a5ddc6ebcc10190ecd52deabcb8ae02a5148864e48be88da",
+		sLr.String())
+
+	sig2, err := NewSignatureFromLoopring(sLr.String())
+	require.Nil(t, err)
+	assert.Equal(t, sig, sig2)
+	assert.True(t, pk.VerifyPoseidon(msg, sig2))
+
+	// text and json round trip
+	b, err := json.Marshal(sLr)
+	require.Nil(t, err)
+	assert.Equal(t, `"`+sLr.String()+`"`, string(b))
+	var sLr2 SignatureLoopring
+	require.Nil(t, json.Unmarshal(b, &sLr2))
+	assert.Equal(t, sLr, sLr2)
+
+	// wrong length
+	_, err = NewSignatureFromLoopring(sLr.String()[:len(sLr.String())-2])
+	assert.NotNil(t, err)
+	_, err = NewSignatureFromLoopring(sLr.String() + "0")
+	assert.NotNil(t, err)
+
+	// S not reduced by SubOrder, which would verify too
+	sBad := *sig
+	sBad.S = new(big.Int).Add(sig.S, SubOrder)
+	assert.True(t, pk.VerifyPoseidon(msg, &sBad))
+	sLrBad := sBad.Loopring()
+	_, err = sLrBad.Decode()
+	assert.Equal(t, "S is not canonical, must be < SubOrder", err.Error())
+	// malformed signatures are rejected when unmarshaling
+	assert.NotNil(t, json.Unmarshal([]byte(`"`+sLrBad.String()+`"`), &sLr2))
+	assert.Equal(t, sLr, sLr2)
+
+	// R8 coordinate not reduced by Q
+	sBad = *sig
+	sBad.R8 = &Point{X: new(big.Int).Add(sig.R8.X, constants.Q), Y: sig.R8.Y}
+	sLrBad = sBad.Loopring()
+	_, err = sLrBad.Decode()
+	assert.Equal(t, "R8 coordinates are not canonical, must be < Q", err.Error())
+
+	// R8 not in the curve
+	sBad = *sig
+	sBad.R8 = &Point{X: big.NewInt(1), Y: sig.R8.Y}
+	sLrBad = sBad.Loopring()
+	_, err = sLrBad.Decode()
+	assert.Equal(t, "R8 is not in the curve", err.Error())
+
+	// R8 of order 2, outside of the subgroup
+	sBad = *sig
+	sBad.R8 = &Point{X: big.NewInt(0), Y: new(big.Int).Sub(constants.Q, big.NewInt(1))}
+	sLrBad = sBad.Loopring()
+	_, err = sLrBad.Decode()
+	assert.Equal(t, "R8 is not in the subgroup", err.Error())
+	assert.NotNil(t, sLr2.UnmarshalText([]byte(sLrBad.String())))
+}
+
+func TestPublicKeyLoopring(t *testing.T) {
+	var k PrivateKey
+	k = utils.BigIntLEBytes(big.NewInt(56869496543825))
+	pk := k.Public()
+
+	pkLr := pk.Loopring()
+	assert.Equal(t,
+		"0x14763264c2e25f64e2dec407322d38b66fce3c5a92c3ea4a7767dda552f1fa7f",
+		pkLr.X)
+	assert.Equal(t,
+		"0x12b46668825532da3809fe42b7f9d80d5a730cc29fce908c4f573dca3cb05613",
+		pkLr.Y)
+
+	pk2, err := NewPublicKeyFromLoopring(pkLr.X, pkLr.Y)
+	require.Nil(t, err)
+	assert.Equal(t, pk, pk2)
+
+	b, err := json.Marshal(pkLr)
+	require.Nil(t, err)
+	assert.Equal(t, `{"publicKeyX":"`+pkLr.X+`","publicKeyY":"`+pkLr.Y+`"}`, string(b))
+	var pkLr2 PublicKeyLoopring
+	require.Nil(t, json.Unmarshal(b, &pkLr2))
+	assert.Equal(t, pkLr, pkLr2)
+
+	// short coordinate
+	_, err = NewPublicKeyFromLoopring(strings.Replace(pkLr.X, "0x14", "0x", 1), pkLr.Y)
+	assert.NotNil(t, err)
+
+	// non canonical coordinate
+	xBad := bigIntBEBytes(new(big.Int).Add(pk.X, constants.Q))
+	_, err = NewPublicKeyFromLoopring(utils.HexEncode(xBad[:]), pkLr.Y)
+	assert.NotNil(t, err)
+
+	// point not in the curve
+	err = json.Unmarshal([]byte(`{"publicKeyX":"`+pkLr.Y+`","publicKeyY":"`+pkLr.X+`"}`),
+		&pkLr2)
+	assert.Equal(t, "public key is not in the curve", err.Error())
+}
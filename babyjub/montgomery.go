@@ -0,0 +1,160 @@
+package babyjub
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/ff"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// BabyJubJub is birationally equivalent to the Montgomery curve
+// v^2 = u^3 + MontgomeryA u^2 + u, with the maps
+// (u, v) = ((1 + y) / (1 - y), (1 + y) / ((1 - y) x)) and
+// (x, y) = (u / v, (u - 1) / (u + 1)).
+
+// montgomeryA24 is (MontgomeryA - 2) / 4, used by the Montgomery ladder.
+var montgomeryA24 = ff.NewElement().SetUint64(42174) //nolint:gomnd
+
+// ToMontgomery returns the coordinates (u, v) of the point in the Montgomery
+// form of the curve.  The identity, which is the point at infinity of the
+// Montgomery curve, has no affine representation and returns error.  The
+// point (0, -1) of order 2 maps to (0, 0).
+func (p *Point) ToMontgomery() (*big.Int, *big.Int, error) {
+	q := constants.Q
+	den := new(big.Int).Sub(constants.One, p.Y)
+	den.Mod(den, q)
+	if den.Sign() == 0 {
+		return nil, nil, fmt.Errorf("the identity is the point at infinity")
+	}
+	if p.X.Sign() == 0 {
+		return big.NewInt(0), big.NewInt(0), nil
+	}
+	u := new(big.Int).Add(constants.One, p.Y)
+	u.Mul(u, den.ModInverse(den, q))
+	u.Mod(u, q)
+	v := new(big.Int).ModInverse(p.X, q)
+	v.Mul(v, u)
+	v.Mod(v, q)
+	return u, v, nil
+}
+
+// PointFromMontgomery returns the point of the curve corresponding to the
+// coordinates (u, v) in the Montgomery form.  Returns error if (u, v) is not
+// in the Montgomery curve.
+func PointFromMontgomery(u, v *big.Int) (*Point, error) {
+	q := constants.Q
+	if u.Sign() < 0 || u.Cmp(q) >= 0 || v.Sign() < 0 || v.Cmp(q) >= 0 {
+		return nil, fmt.Errorf("coordinates not in [0, Q)")
+	}
+	v2 := new(big.Int).Mul(v, v)
+	v2.Mod(v2, q)
+	if v2.Cmp(montgomeryRHS(u)) != 0 {
+		return nil, fmt.Errorf("point not in the Montgomery curve")
+	}
+	if v.Sign() == 0 {
+		// (0, 0) is the only point with v == 0, as u^2 + A u + 1 has no
+		// roots
+		return &Point{X: big.NewInt(0), Y: new(big.Int).Sub(q, constants.One)}, nil
+	}
+	return montgomeryToEdwards(u, v), nil
+}
+
+// MontgomeryU returns the u-coordinate of the point in the Montgomery form,
+// as a 32 byte little-endian array.  The identity returns the all zero array,
+// as in X25519.
+func (p *Point) MontgomeryU() [32]byte {
+	u, _, err := p.ToMontgomery()
+	if err != nil {
+		return [32]byte{}
+	}
+	return utils.BigIntLEBytes(u)
+}
+
+// cswap swaps a and b in constant time when swap is 1.
+func cswap(swap uint64, a, b *ff.Element) {
+	mask := -swap
+	for i := range a {
+		t := mask & (a[i] ^ b[i])
+		a[i] ^= t
+		b[i] ^= t
+	}
+}
+
+// ScalarMultU computes the u-coordinate of k * P given the u-coordinate of
+// the point P, with an x-only Montgomery ladder (as X25519 does in RFC 7748)
+// that always runs 256 iterations.  k and u are little-endian, k is used as
+// given (unlike X25519 it is not clamped) and u must be canonical (< Q) and
+// belong to a point of the curve, not of its quadratic twist.  Returns error
+// if the result is the point at infinity, which encodes as all zeros.
+func ScalarMultU(k, u [32]byte) ([32]byte, error) {
+	var zero [32]byte
+	uBig := utils.SetBigIntFromLEBytes(new(big.Int), u[:])
+	if !utils.CheckBigIntInField(uBig) {
+		return zero, fmt.Errorf("u not canonical, must be < Q")
+	}
+	if !isSquare(montgomeryRHS(uBig)) {
+		return zero, fmt.Errorf("u not in the curve")
+	}
+
+	x1 := ff.NewElement().SetBigInt(uBig)
+	x2 := ff.NewElement().SetOne()
+	z2 := ff.NewElement().SetZero()
+	x3 := ff.NewElement()
+	*x3 = *x1
+	z3 := ff.NewElement().SetOne()
+
+	a := ff.NewElement()
+	aa := ff.NewElement()
+	b := ff.NewElement()
+	bb := ff.NewElement()
+	e := ff.NewElement()
+	c := ff.NewElement()
+	d := ff.NewElement()
+	da := ff.NewElement()
+	cb := ff.NewElement()
+	var swap uint64
+	for t := 255; t >= 0; t-- {
+		kt := uint64(k[t/8]>>(uint(t)%8)) & 1 //nolint:gomnd
+		swap ^= kt
+		cswap(swap, x2, x3)
+		cswap(swap, z2, z3)
+		swap = kt
+
+		a.Add(x2, z2)
+		aa.Square(a)
+		b.Sub(x2, z2)
+		bb.Square(b)
+		e.Sub(aa, bb)
+		c.Add(x3, z3)
+		d.Sub(x3, z3)
+		da.Mul(d, a)
+		cb.Mul(c, b)
+		x3.Add(da, cb)
+		x3.Square(x3)
+		z3.Sub(da, cb)
+		z3.Square(z3)
+		z3.Mul(z3, x1)
+		x2.Mul(aa, bb)
+		z2.Mul(montgomeryA24, e)
+		z2.Add(z2, aa)
+		z2.Mul(z2, e)
+	}
+	cswap(swap, x2, x3)
+	cswap(swap, z2, z3)
+
+	if z2.IsZero() {
+		return zero, fmt.Errorf("result is the point at infinity")
+	}
+	z2.Inverse(z2)
+	x2.Mul(x2, z2)
+	r := big.NewInt(0)
+	x2.ToBigIntRegular(r)
+	return utils.BigIntLEBytes(r), nil
+}
+
+// ScalarBaseMultU computes the u-coordinate of k * B8, see ScalarMultU.
+func ScalarBaseMultU(k [32]byte) ([32]byte, error) {
+	return ScalarMultU(k, B8.MontgomeryU())
+}
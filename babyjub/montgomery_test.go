@@ -0,0 +1,104 @@
+package babyjub
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMontgomeryRoundTrip(t *testing.T) {
+	u, v, err := B8.ToMontgomery()
+	require.Nil(t, err)
+	v2 := new(big.Int).Mul(v, v)
+	v2.Mod(v2, constants.Q)
+	assert.Equal(t, montgomeryRHS(u), v2)
+
+	for i := int64(1); i < 20; i++ {
+		p := NewPoint().Mul(big.NewInt(i*7919), B8)
+		u, v, err := p.ToMontgomery()
+		require.Nil(t, err)
+		p2, err := PointFromMontgomery(u, v)
+		require.Nil(t, err)
+		assert.True(t, p.Equal(p2))
+	}
+
+	// point of order 2
+	low := &Point{X: big.NewInt(0), Y: new(big.Int).Sub(constants.Q, big.NewInt(1))}
+	u, v, err = low.ToMontgomery()
+	require.Nil(t, err)
+	assert.Equal(t, big.NewInt(0), u)
+	assert.Equal(t, big.NewInt(0), v)
+	p, err := PointFromMontgomery(u, v)
+	require.Nil(t, err)
+	assert.True(t, p.Equal(low))
+
+	// identity
+	_, _, err = NewPoint().ToMontgomery()
+	assert.NotNil(t, err)
+	assert.Equal(t, [32]byte{}, NewPoint().MontgomeryU())
+
+	_, err = PointFromMontgomery(big.NewInt(1), big.NewInt(1))
+	assert.Equal(t, "point not in the Montgomery curve", err.Error())
+	_, err = PointFromMontgomery(constants.Q, big.NewInt(1))
+	assert.NotNil(t, err)
+}
+
+func TestScalarMultU(t *testing.T) {
+	for _, k := range []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3),
+		big.NewInt(56869496543825), new(big.Int).Sub(SubOrder, big.NewInt(1)),
+		utils.NewIntFromString(
+			"7616254846080660730932216519770737127037155777726245055053503272117180880572")} {
+		expected := NewPoint().Mul(k, B8).MontgomeryU()
+		r, err := ScalarBaseMultU(utils.BigIntLEBytes(k))
+		require.Nil(t, err)
+		assert.Equal(t, expected, r)
+	}
+
+	// k * B8 == infinity
+	_, err := ScalarBaseMultU(utils.BigIntLEBytes(SubOrder))
+	assert.Equal(t, "result is the point at infinity", err.Error())
+
+	// x-only Diffie-Hellman agrees with the Edwards shared secret
+	var ka, kb PrivateKey
+	ka = utils.BigIntLEBytes(big.NewInt(56869496543825))
+	kb = utils.BigIntLEBytes(big.NewInt(1234567890))
+	ua, err := ScalarBaseMultU(ka)
+	require.Nil(t, err)
+	ub, err := ScalarBaseMultU(kb)
+	require.Nil(t, err)
+	sab, err := ScalarMultU(ka, ub)
+	require.Nil(t, err)
+	sba, err := ScalarMultU(kb, ua)
+	require.Nil(t, err)
+	assert.Equal(t, sab, sba)
+	shared, err := ka.SharedSecret(kb.Public())
+	require.Nil(t, err)
+	assert.Equal(t, shared.MontgomeryU(), sab)
+
+	// u not canonical
+	_, err = ScalarMultU(ka, utils.BigIntLEBytes(constants.Q))
+	assert.Equal(t, "u not canonical, must be < Q", err.Error())
+
+	// u in the twist
+	var uTwist *big.Int
+	for i := int64(2); ; i++ {
+		if !isSquare(montgomeryRHS(big.NewInt(i))) {
+			uTwist = big.NewInt(i)
+			break
+		}
+	}
+	_, err = ScalarMultU(ka, utils.BigIntLEBytes(uTwist))
+	assert.Equal(t, "u not in the curve", err.Error())
+}
+
+func BenchmarkScalarMultU(b *testing.B) {
+	k := NewRandPrivKey()
+	u := B8.MontgomeryU()
+	for i := 0; i < b.N; i++ {
+		ScalarMultU(k, u) //nolint:errcheck,gosec
+	}
+}
@@ -0,0 +1,353 @@
+// Package musig2 implements MuSig2 n-of-n multi-signatures (Nick, Ruffing and
+// Seurin, "MuSig2: Simple Two-Round Schnorr Multi-Signatures", following the
+// structure of BIP-327) over the BabyJubJub subgroup generated by babyjub.B8.
+//
+// The keys of the signers are aggregated into a babyjub.PublicKey, and the
+// challenge of the signatures is the one of the Poseidon EdDSA of babyjub,
+// c = Poseidon(R.x, R.y, X.x, X.y, msg), so the aggregated signature is a
+// babyjub.Signature accepted by PublicKey.VerifyPoseidon for the aggregate
+// key X.  The key aggregation and nonce coefficients are computed with
+// poseidon.Transcript.
+//
+// Signing takes two rounds: each signer generates its nonces with NonceGen
+// and publishes its PubNonce, and once all the nonces are aggregated with
+// AggregateNonces each signer computes its partial signature in a Session.
+// The partial signatures are checked with Session.VerifyPartial and combined
+// with Session.Aggregate.
+package musig2
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// Domain labels of the transcripts of the coefficients.
+const (
+	DomainKeyAgg    = "iden3_musig2_keyagg"
+	DomainNonceCoef = "iden3_musig2_noncecoef"
+)
+
+// KeyAggContext holds the public keys of the signers, their key aggregation
+// coefficients and the aggregate key X = sum(a_i * X_i).
+type KeyAggContext struct {
+	keys  []*babyjub.Point
+	coefs []*big.Int
+	key   *babyjub.PublicKey
+}
+
+// AggregateKeys aggregates the public keys of the signers, in the given
+// order, which must be the same for all the signers.  The coefficient of
+// each key is squeezed from a transcript of all the keys and the key itself,
+// except for the second distinct key, whose coefficient is 1.
+func AggregateKeys(pks []*babyjub.PublicKey) (*KeyAggContext, error) {
+	if len(pks) == 0 {
+		return nil, fmt.Errorf("no public keys")
+	}
+	t := poseidon.NewTranscript(DomainKeyAgg)
+	t.AppendScalar("n", big.NewInt(int64(len(pks))))
+	ctx := &KeyAggContext{}
+	for i, pk := range pks {
+		p := pk.Point()
+		if p == nil || p.X == nil || p.Y == nil || !p.InSubGroup() ||
+			p.Equal(babyjub.NewPoint()) {
+			return nil, fmt.Errorf("invalid public key %d", i)
+		}
+		ctx.keys = append(ctx.keys, p)
+		t.AppendPoint("pk", p)
+	}
+	var second *babyjub.Point
+	for _, p := range ctx.keys[1:] {
+		if !p.Equal(ctx.keys[0]) {
+			second = p
+			break
+		}
+	}
+	acc := babyjub.NewPointProjective()
+	for _, p := range ctx.keys {
+		var a *big.Int
+		if second != nil && p.Equal(second) {
+			a = big.NewInt(1)
+		} else {
+			ti := t.Clone()
+			ti.AppendPoint("X", p)
+			a = ti.ChallengeScalar("a", babyjub.SubOrder)
+		}
+		ctx.coefs = append(ctx.coefs, a)
+		acc = babyjub.NewPointProjective().Add(acc, babyjub.NewPoint().Mul(a, p).Projective())
+	}
+	key := babyjub.PublicKey(*acc.Affine())
+	if key.Point().Equal(babyjub.NewPoint()) {
+		return nil, fmt.Errorf("aggregate key is the identity")
+	}
+	ctx.key = &key
+	return ctx, nil
+}
+
+// PublicKey returns the aggregate public key.
+func (ctx *KeyAggContext) PublicKey() *babyjub.PublicKey {
+	return ctx.key
+}
+
+// index returns the index of the public key among the signers, or -1.
+func (ctx *KeyAggContext) index(pk *babyjub.Point) int {
+	for i, p := range ctx.keys {
+		if p.Equal(pk) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SecNonce holds the secret nonces r1 and r2 of a signer for a signing
+// session.  It can't be serialized, and it is erased by Session.Sign, so the
+// same nonces can't be used to sign twice.
+type SecNonce struct {
+	r1, r2 *big.Int
+	pk     *babyjub.Point
+}
+
+// PubNonce is the public nonce of a signer, R1 = r1 * B8 and R2 = r2 * B8, or
+// the aggregate of the public nonces of all the signers.
+type PubNonce struct {
+	R1 *babyjub.Point
+	R2 *babyjub.Point
+}
+
+// nonceHash returns H(rand || H_{32..63}(sk) || msg || i) mod SubOrder with
+// blake-512, so that the nonces are unpredictable even with a weak random
+// source.
+func nonceHash(random []byte, sk *babyjub.PrivateKey, msg *big.Int, i byte) *big.Int {
+	h1 := babyjub.Blake512(sk[:])
+	buf := append([]byte{}, random...)
+	buf = append(buf, h1[32:]...)
+	if msg != nil {
+		msgBuf := utils.BigIntLEBytes(msg)
+		buf = append(buf, msgBuf[:]...)
+	}
+	buf = append(buf, i)
+	r := utils.SetBigIntFromLEBytes(new(big.Int), babyjub.Blake512(buf))
+	return r.Mod(r, babyjub.SubOrder)
+}
+
+// NonceGen generates the nonces of the signer with private key sk for a
+// signing session, optionally bound to the message msg, which can be nil for
+// nonces that are not bound to a message, and otherwise must be inside the
+// finite field as in NewSession.  The PubNonce must be sent to the other
+// signers, while the SecNonce is kept secret until the signer signs.
+func NonceGen(sk *babyjub.PrivateKey, msg *big.Int) (*SecNonce, *PubNonce, error) {
+	if msg != nil && !utils.CheckBigIntInField(msg) {
+		return nil, nil, fmt.Errorf("msg must be inside the finite field")
+	}
+	random := make([]byte, 32) //nolint:gomnd
+	if _, err := rand.Read(random); err != nil {
+		return nil, nil, err
+	}
+	r1 := nonceHash(random, sk, msg, 1)
+	r2 := nonceHash(random, sk, msg, 2) //nolint:gomnd
+	if r1.Sign() == 0 || r2.Sign() == 0 {
+		return nil, nil, fmt.Errorf("zero nonce")
+	}
+	sec := &SecNonce{r1: r1, r2: r2, pk: sk.Public().Point()}
+	pub := &PubNonce{
+		R1: babyjub.NewPoint().Mul(r1, babyjub.B8),
+		R2: babyjub.NewPoint().Mul(r2, babyjub.B8),
+	}
+	return sec, pub, nil
+}
+
+// AggregateNonces returns the aggregate of the public nonces of all the
+// signers.
+func AggregateNonces(pubs []*PubNonce) (*PubNonce, error) {
+	if len(pubs) == 0 {
+		return nil, fmt.Errorf("no public nonces")
+	}
+	r1 := babyjub.NewPointProjective()
+	r2 := babyjub.NewPointProjective()
+	for i, pub := range pubs {
+		if !checkNoncePoint(pub.R1) || !checkNoncePoint(pub.R2) {
+			return nil, fmt.Errorf("invalid public nonce %d", i)
+		}
+		r1 = babyjub.NewPointProjective().Add(r1, pub.R1.Projective())
+		r2 = babyjub.NewPointProjective().Add(r2, pub.R2.Projective())
+	}
+	return &PubNonce{R1: r1.Affine(), R2: r2.Affine()}, nil
+}
+
+func checkNoncePoint(p *babyjub.Point) bool {
+	return p != nil && p.X != nil && p.Y != nil && p.InSubGroup()
+}
+
+// Session is a signing session of a message with an aggregate nonce.
+type Session struct {
+	ctx *KeyAggContext
+	msg *big.Int
+	b   *big.Int
+	r   *babyjub.Point
+	c   *big.Int
+}
+
+// NewSession returns the signing session of msg for the signers of the key
+// aggregation context with the aggregate nonce.  The nonce coefficient b is
+// squeezed from a transcript of the aggregate nonce, the aggregate key and
+// msg, the final nonce is R = R1 + b * R2, and the challenge is
+// c = Poseidon(R.x, R.y, X.x, X.y, msg).  Unlike in NonceGen, msg can't be
+// nil, as the signatures are always of a message.
+func NewSession(ctx *KeyAggContext, aggNonce *PubNonce, msg *big.Int) (*Session, error) {
+	if msg == nil || !utils.CheckBigIntInField(msg) {
+		return nil, fmt.Errorf("msg must be inside the finite field")
+	}
+	if !checkNoncePoint(aggNonce.R1) || !checkNoncePoint(aggNonce.R2) {
+		return nil, fmt.Errorf("invalid aggregate nonce")
+	}
+	x := ctx.key.Point()
+	t := poseidon.NewTranscript(DomainNonceCoef)
+	t.AppendPoint("R1", aggNonce.R1)
+	t.AppendPoint("R2", aggNonce.R2)
+	t.AppendPoint("X", x)
+	t.AppendScalar("msg", msg)
+	b := t.ChallengeScalar("b", babyjub.SubOrder)
+	r := babyjub.NewPoint().Add(aggNonce.R1, babyjub.NewPoint().Mul(b, aggNonce.R2))
+
+	hm, err := poseidon.Hash([]*big.Int{r.X, r.Y, x.X, x.Y, msg})
+	if err != nil {
+		return nil, err
+	}
+	return &Session{ctx: ctx, msg: msg, b: b, r: r, c: hm.Mod(hm, babyjub.SubOrder)}, nil
+}
+
+// Sign returns the partial signature s = r1 + b * r2 + c * a * x of the
+// signer with private key sk and secret nonce sec.  The secret nonce is
+// erased, so a second call with it returns error.
+func (s *Session) Sign(sk *babyjub.PrivateKey, sec *SecNonce) (*big.Int, error) {
+	if sec.r1 == nil || sec.r2 == nil {
+		return nil, fmt.Errorf("secret nonce already used")
+	}
+	pk := sk.Public().Point()
+	if !pk.Equal(sec.pk) {
+		return nil, fmt.Errorf("secret nonce of another signer")
+	}
+	i := s.ctx.index(pk)
+	if i < 0 {
+		return nil, fmt.Errorf("signer not in the key aggregation context")
+	}
+	x := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	z := new(big.Int).Mul(s.c, s.ctx.coefs[i])
+	z.Mul(z, x)
+	z.Add(z, sec.r1)
+	z.Add(z, new(big.Int).Mul(s.b, sec.r2))
+	z.Mod(z, babyjub.SubOrder)
+	sec.r1, sec.r2 = nil, nil
+	return z, nil
+}
+
+// VerifyPartial verifies the partial signature of the signer i, in the order
+// of the key aggregation, with its public nonce:
+// s * B8 == R1 + b * R2 + c * a * X.
+func (s *Session) VerifyPartial(i int, pub *PubNonce, partial *big.Int) error {
+	if i < 0 || i >= len(s.ctx.keys) {
+		return fmt.Errorf("invalid signer index %d", i)
+	}
+	if partial == nil || partial.Sign() < 0 || partial.Cmp(babyjub.SubOrder) >= 0 {
+		return fmt.Errorf("partial signature not in [0, SubOrder)")
+	}
+	if !checkNoncePoint(pub.R1) || !checkNoncePoint(pub.R2) {
+		return fmt.Errorf("invalid public nonce")
+	}
+	ca := new(big.Int).Mul(s.c, s.ctx.coefs[i])
+	ca.Mod(ca, babyjub.SubOrder)
+	right := babyjub.NewPoint().Add(pub.R1, babyjub.NewPoint().Mul(s.b, pub.R2))
+	right.Add(right, babyjub.NewPoint().Mul(ca, s.ctx.keys[i]))
+	if !babyjub.NewPoint().Mul(partial, babyjub.B8).Equal(right) {
+		return fmt.Errorf("invalid partial signature of signer %d", i)
+	}
+	return nil
+}
+
+// Aggregate combines the partial signatures of all the signers into a
+// signature that verifies with VerifyPoseidon under the aggregate key.
+func (s *Session) Aggregate(partials []*big.Int) (*babyjub.Signature, error) {
+	if len(partials) != len(s.ctx.keys) {
+		return nil, fmt.Errorf("%d partial signatures for %d signers", len(partials),
+			len(s.ctx.keys))
+	}
+	z := big.NewInt(0)
+	for _, p := range partials {
+		z.Add(z, p)
+	}
+	z.Mod(z, babyjub.SubOrder)
+	sig := &babyjub.Signature{R8: babyjub.NewPoint().Set(s.r), S: z}
+	if !s.ctx.key.VerifyPoseidon(s.msg, sig) {
+		return nil, fmt.Errorf("aggregated signature does not verify")
+	}
+	return sig, nil
+}
+
+// PubNonceComp represents a compressed public nonce, the compressed points R1
+// and R2.
+type PubNonceComp [64]byte
+
+// MarshalText implements the marshaler for the PubNonceComp
+func (nComp PubNonceComp) MarshalText() ([]byte, error) {
+	return utils.Hex(nComp[:]).MarshalText()
+}
+
+// String returns the string representation of the PubNonceComp
+func (nComp PubNonceComp) String() string { return utils.Hex(nComp[:]).String() }
+
+// UnmarshalText implements the unmarshaler for the PubNonceComp
+func (nComp *PubNonceComp) UnmarshalText(h []byte) error {
+	return utils.HexDecodeInto(nComp[:], h)
+}
+
+// Compress returns the PubNonceComp for the given PubNonce
+func (n *PubNonce) Compress() PubNonceComp {
+	var buf PubNonceComp
+	r1 := n.R1.Compress()
+	r2 := n.R2.Compress()
+	copy(buf[:32], r1[:])
+	copy(buf[32:], r2[:])
+	return buf
+}
+
+// Decompress returns the PubNonce for the given PubNonceComp.  Returns error
+// if the point decompression fails or if the points are not in the subgroup.
+func (nComp *PubNonceComp) Decompress() (*PubNonce, error) {
+	var n PubNonce
+	for i, dst := range []**babyjub.Point{&n.R1, &n.R2} {
+		var buf [32]byte
+		copy(buf[:], nComp[32*i:32*(i+1)])
+		p, err := babyjub.NewPoint().Decompress(buf)
+		if err != nil {
+			return nil, err
+		}
+		if !p.InSubGroup() {
+			return nil, fmt.Errorf("nonce point not in the subgroup")
+		}
+		*dst = p
+	}
+	return &n, nil
+}
+
+// MarshalText implements the marshaler for the PubNonce
+func (n PubNonce) MarshalText() ([]byte, error) {
+	return n.Compress().MarshalText()
+}
+
+// UnmarshalText implements the unmarshaler for the PubNonce
+func (n *PubNonce) UnmarshalText(h []byte) error {
+	var nComp PubNonceComp
+	if err := nComp.UnmarshalText(h); err != nil {
+		return err
+	}
+	n2, err := nComp.Decompress()
+	if err != nil {
+		return err
+	}
+	*n = *n2
+	return nil
+}
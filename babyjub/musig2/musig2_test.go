@@ -0,0 +1,142 @@
+package musig2
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signers(n int) ([]babyjub.PrivateKey, []*babyjub.PublicKey) {
+	sks := make([]babyjub.PrivateKey, n)
+	pks := make([]*babyjub.PublicKey, n)
+	for i := range sks {
+		sks[i] = babyjub.NewRandPrivKey()
+		pks[i] = sks[i].Public()
+	}
+	return sks, pks
+}
+
+func TestMuSig2(t *testing.T) {
+	sks, pks := signers(3)
+	ctx, err := AggregateKeys(pks)
+	require.Nil(t, err)
+	msg := big.NewInt(987654321)
+
+	secs := make([]*SecNonce, len(sks))
+	pubs := make([]*PubNonce, len(sks))
+	for i := range sks {
+		secs[i], pubs[i], err = NonceGen(&sks[i], msg)
+		require.Nil(t, err)
+	}
+
+	// the public nonces are exchanged serialized
+	for i := range pubs {
+		b, err := json.Marshal(pubs[i])
+		require.Nil(t, err)
+		var pub PubNonce
+		require.Nil(t, json.Unmarshal(b, &pub))
+		assert.Equal(t, pubs[i].Compress(), pub.Compress())
+		pubs[i] = &pub
+	}
+
+	aggNonce, err := AggregateNonces(pubs)
+	require.Nil(t, err)
+	session, err := NewSession(ctx, aggNonce, msg)
+	require.Nil(t, err)
+	partials := make([]*big.Int, len(sks))
+	for i := range sks {
+		partials[i], err = session.Sign(&sks[i], secs[i])
+		require.Nil(t, err)
+		assert.Nil(t, session.VerifyPartial(i, pubs[i], partials[i]))
+	}
+
+	sig, err := session.Aggregate(partials)
+	require.Nil(t, err)
+	assert.True(t, ctx.PublicKey().VerifyPoseidon(msg, sig))
+	assert.False(t, ctx.PublicKey().VerifyPoseidon(big.NewInt(1), sig))
+	for _, pk := range pks {
+		assert.False(t, pk.VerifyPoseidon(msg, sig))
+	}
+
+	// nonce reuse is rejected
+	_, err = session.Sign(&sks[0], secs[0])
+	assert.NotNil(t, err)
+
+	// invalid partial signature
+	bad := new(big.Int).Add(partials[1], big.NewInt(1))
+	assert.NotNil(t, session.VerifyPartial(1, pubs[1], bad))
+	assert.NotNil(t, session.VerifyPartial(0, pubs[1], partials[1]))
+	_, err = session.Aggregate([]*big.Int{partials[0], bad, partials[2]})
+	assert.NotNil(t, err)
+	_, err = session.Aggregate(partials[:2])
+	assert.NotNil(t, err)
+}
+
+func TestAggregateKeys(t *testing.T) {
+	_, pks := signers(3)
+	ctx, err := AggregateKeys(pks)
+	require.Nil(t, err)
+	// the second key has coefficient 1
+	assert.Equal(t, big.NewInt(1), ctx.coefs[1])
+
+	// the order of the keys matters
+	ctx2, err := AggregateKeys([]*babyjub.PublicKey{pks[1], pks[0], pks[2]})
+	require.Nil(t, err)
+	assert.False(t, ctx.PublicKey().Point().Equal(ctx2.PublicKey().Point()))
+
+	// a single signer, and repeated keys
+	_, err = AggregateKeys(pks[:1])
+	assert.Nil(t, err)
+	_, err = AggregateKeys([]*babyjub.PublicKey{pks[0], pks[0], pks[1]})
+	assert.Nil(t, err)
+
+	_, err = AggregateKeys(nil)
+	assert.NotNil(t, err)
+	identity := babyjub.PublicKey(*babyjub.NewPoint())
+	_, err = AggregateKeys([]*babyjub.PublicKey{pks[0], &identity})
+	assert.NotNil(t, err)
+}
+
+func TestSignErrors(t *testing.T) {
+	sks, pks := signers(2)
+	ctx, err := AggregateKeys(pks)
+	require.Nil(t, err)
+	msg := big.NewInt(1)
+	sec0, pub0, err := NonceGen(&sks[0], nil)
+	require.Nil(t, err)
+	_, pub1, err := NonceGen(&sks[1], nil)
+	require.Nil(t, err)
+	aggNonce, err := AggregateNonces([]*PubNonce{pub0, pub1})
+	require.Nil(t, err)
+	session, err := NewSession(ctx, aggNonce, msg)
+	require.Nil(t, err)
+
+	// the session needs a message in the field, while the nonces may be
+	// unbound
+	_, err = NewSession(ctx, aggNonce, nil)
+	assert.NotNil(t, err)
+	_, err = NewSession(ctx, aggNonce, constants.Q)
+	assert.NotNil(t, err)
+	_, _, err = NonceGen(&sks[0], constants.Q)
+	assert.NotNil(t, err)
+
+	// secret nonce of another signer
+	_, err = session.Sign(&sks[1], sec0)
+	assert.NotNil(t, err)
+	// signer not in the context
+	other := babyjub.NewRandPrivKey()
+	secOther, _, err := NonceGen(&other, nil)
+	require.Nil(t, err)
+	_, err = session.Sign(&other, secOther)
+	assert.NotNil(t, err)
+
+	// invalid nonce point
+	lowOrder := &babyjub.Point{X: big.NewInt(0), Y: new(big.Int).Sub(constants.Q, big.NewInt(1))}
+	_, err = AggregateNonces([]*PubNonce{pub0, {R1: lowOrder, R2: pub1.R2}})
+	assert.NotNil(t, err)
+}
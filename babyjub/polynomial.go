@@ -0,0 +1,51 @@
+package babyjub
+
+import (
+	"math/big"
+)
+
+// EvalPolynomial evaluates modulo SubOrder at x the polynomial with the
+// coefficients, from the constant term up, as when computing the Shamir
+// shares of a scalar.
+func EvalPolynomial(coefs []*big.Int, x int) *big.Int {
+	xb := big.NewInt(int64(x))
+	r := big.NewInt(0)
+	for i := len(coefs) - 1; i >= 0; i-- {
+		r.Mul(r, xb)
+		r.Add(r, coefs[i])
+		r.Mod(r, SubOrder)
+	}
+	return r
+}
+
+// LagrangeCoefficient returns modulo SubOrder the Lagrange coefficient at x of
+// the point with abscissa xi among the points with abscissas xs, which must be
+// distinct and contain xi.  With x = 0 it is the coefficient of the share of
+// index xi to recover the secret from the shares of indexes xs.
+func LagrangeCoefficient(xi int, xs []int, x int) *big.Int {
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	for _, xj := range xs {
+		if xj == xi {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(x-xj)))
+		num.Mod(num, SubOrder)
+		den.Mul(den, big.NewInt(int64(xi-xj)))
+		den.Mod(den, SubOrder)
+	}
+	den.ModInverse(den, SubOrder)
+	return num.Mul(num, den).Mod(num, SubOrder)
+}
+
+// Interpolate evaluates modulo SubOrder at x the polynomial of degree
+// len(xs) - 1 that passes through the points (xs[i], ys[i]).  The xs must be
+// distinct.
+func Interpolate(xs []int, ys []*big.Int, x int) *big.Int {
+	r := big.NewInt(0)
+	for i, xi := range xs {
+		l := LagrangeCoefficient(xi, xs, x)
+		r.Add(r, l.Mul(l, ys[i]))
+	}
+	return r.Mod(r, SubOrder)
+}
@@ -0,0 +1,36 @@
+package babyjub
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolynomial(t *testing.T) {
+	// 3 + 2x + x^2
+	coefs := []*big.Int{big.NewInt(3), big.NewInt(2), big.NewInt(1)}
+	assert.Equal(t, "3", EvalPolynomial(coefs, 0).String())
+	assert.Equal(t, "18", EvalPolynomial(coefs, 3).String())
+	// -1 + x modulo SubOrder
+	neg := []*big.Int{new(big.Int).Sub(SubOrder, big.NewInt(1)), big.NewInt(1)}
+	assert.Equal(t, "0", EvalPolynomial(neg, 1).String())
+
+	xs := []int{1, 4, 6}
+	ys := make([]*big.Int, len(xs))
+	for i, x := range xs {
+		ys[i] = EvalPolynomial(coefs, x)
+	}
+	for _, x := range []int{0, 2, 7} {
+		assert.Equal(t, EvalPolynomial(coefs, x), Interpolate(xs, ys, x))
+	}
+	// the coefficients at the abscissas are 1 for its point and 0 for the
+	// others, and sum to 1 at any x
+	assert.Equal(t, "1", LagrangeCoefficient(4, xs, 4).String())
+	assert.Equal(t, "0", LagrangeCoefficient(4, xs, 6).String())
+	sum := big.NewInt(0)
+	for _, xi := range xs {
+		sum.Add(sum, LagrangeCoefficient(xi, xs, 0))
+	}
+	assert.Equal(t, "1", sum.Mod(sum, SubOrder).String())
+}
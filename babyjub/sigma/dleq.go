@@ -0,0 +1,76 @@
+package sigma
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// DLEQProof is a Chaum-Pedersen proof that log_G(A) == log_H(B):
+// R1 = r * G, R2 = r * H and Z = r + c * x, where c is the challenge.
+type DLEQProof struct {
+	R1 *babyjub.Point
+	R2 *babyjub.Point
+	Z  *big.Int
+}
+
+// dleqChallenge returns the challenge of the transcript DomainDLEQ with G,
+// H, A, B, R1, R2 and msg.
+func dleqChallenge(g, h, a, b, r1, r2 *babyjub.Point, msg *big.Int) *big.Int {
+	t := poseidon.NewTranscript(DomainDLEQ)
+	t.AppendPoint("G", g)
+	t.AppendPoint("H", h)
+	t.AppendPoint("A", a)
+	t.AppendPoint("B", b)
+	t.AppendPoint("R1", r1)
+	t.AppendPoint("R2", r2)
+	appendMsg(t, msg)
+	return challenge(t)
+}
+
+// ProveDLEQ proves that A = x * G and B = x * H have the same discrete
+// logarithm x, binding the proof to msg (which can be nil).  It returns the
+// points A and B together with the proof.
+func ProveDLEQ(x *big.Int, g, h *babyjub.Point, msg *big.Int) (*babyjub.Point,
+	*babyjub.Point, *DLEQProof, error) {
+	if !checkPoint(g) || !checkPoint(h) {
+		return nil, nil, nil, fmt.Errorf("point not in the subgroup")
+	}
+	xr := new(big.Int).Mod(x, babyjub.SubOrder)
+	a := babyjub.NewPoint().Mul(xr, g)
+	b := babyjub.NewPoint().Mul(xr, h)
+	r, err := babyjub.NewRandScalar()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	r1 := babyjub.NewPoint().Mul(r, g)
+	r2 := babyjub.NewPoint().Mul(r, h)
+	c := dleqChallenge(g, h, a, b, r1, r2, msg)
+	z := new(big.Int).Mul(c, xr)
+	z.Add(z, r)
+	z.Mod(z, babyjub.SubOrder)
+	return a, b, &DLEQProof{R1: r1, R2: r2, Z: z}, nil
+}
+
+// VerifyDLEQ verifies the proof that log_G(A) == log_H(B), bound to msg:
+// Z * G == R1 + c * A and Z * H == R2 + c * B.
+func VerifyDLEQ(g, h, a, b *babyjub.Point, msg *big.Int, proof *DLEQProof) error {
+	for _, p := range []*babyjub.Point{g, h, a, b, proof.R1, proof.R2} {
+		if !checkPoint(p) {
+			return fmt.Errorf("point not in the subgroup")
+		}
+	}
+	if !checkScalar(proof.Z) {
+		return fmt.Errorf("response not in [0, SubOrder)")
+	}
+	c := dleqChallenge(g, h, a, b, proof.R1, proof.R2, msg)
+	if !babyjub.NewPoint().Mul(proof.Z, g).Equal(
+		babyjub.NewPoint().Add(proof.R1, babyjub.NewPoint().Mul(c, a))) ||
+		!babyjub.NewPoint().Mul(proof.Z, h).Equal(
+			babyjub.NewPoint().Add(proof.R2, babyjub.NewPoint().Mul(c, b))) {
+		return fmt.Errorf("invalid dleq proof")
+	}
+	return nil
+}
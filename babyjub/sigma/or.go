@@ -0,0 +1,122 @@
+package sigma
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// OrProof is an OR-composition of Schnorr proofs (Cramer-Damgård-
+// Schoenmakers), proving knowledge of the private key of one of several
+// public keys without revealing which one.  For each public key PK_i, the
+// proof holds the challenge C_i and the response Z_i; the commitments are
+// recomputed as R_i = Z_i * B8 - C_i * PK_i, and the challenges must add up
+// to the Fiat-Shamir challenge of all the public keys and commitments.
+type OrProof struct {
+	C []*big.Int
+	Z []*big.Int
+}
+
+// orChallenge returns the challenge of the transcript DomainOr with the
+// number of public keys n, B8, PK_0..PK_n-1, R_0..R_n-1 and msg.
+func orChallenge(pks, rs []*babyjub.Point, msg *big.Int) *big.Int {
+	t := poseidon.NewTranscript(DomainOr)
+	t.AppendScalar("n", big.NewInt(int64(len(pks))))
+	t.AppendPoint("B8", babyjub.B8)
+	for _, pk := range pks {
+		t.AppendPoint("pk", pk)
+	}
+	for _, r := range rs {
+		t.AppendPoint("R", r)
+	}
+	appendMsg(t, msg)
+	return challenge(t)
+}
+
+// ProveOr proves knowledge of the private key sk of pks[index], binding the
+// proof to msg (which can be nil).
+func ProveOr(pks []*babyjub.PublicKey, index int, sk *babyjub.PrivateKey,
+	msg *big.Int) (*OrProof, error) {
+	if index < 0 || index >= len(pks) {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+	s := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	if !babyjub.NewPoint().Mul(s, babyjub.B8).Equal(pks[index].Point()) {
+		return nil, fmt.Errorf("private key does not match public key %d", index)
+	}
+	n := len(pks)
+	ps := make([]*babyjub.Point, n)
+	rs := make([]*babyjub.Point, n)
+	cs := make([]*big.Int, n)
+	zs := make([]*big.Int, n)
+	for i := range pks {
+		ps[i] = pks[i].Point()
+		if !checkPoint(ps[i]) {
+			return nil, fmt.Errorf("public key %d not in the subgroup", i)
+		}
+	}
+	// simulate the proofs of the other public keys
+	var err error
+	for i := range pks {
+		if i == index {
+			continue
+		}
+		if cs[i], err = babyjub.NewRandScalar(); err != nil {
+			return nil, err
+		}
+		if zs[i], err = babyjub.NewRandScalar(); err != nil {
+			return nil, err
+		}
+		negC := new(big.Int).Sub(babyjub.SubOrder, cs[i])
+		rs[i] = mulAdd(zs[i], babyjub.B8, negC, ps[i])
+	}
+	r, err := babyjub.NewRandScalar()
+	if err != nil {
+		return nil, err
+	}
+	rs[index] = babyjub.NewPoint().Mul(r, babyjub.B8)
+
+	// the real challenge is the remainder of the Fiat-Shamir challenge
+	c := orChallenge(ps, rs, msg)
+	for i := range pks {
+		if i != index {
+			c.Sub(c, cs[i])
+		}
+	}
+	cs[index] = c.Mod(c, babyjub.SubOrder)
+	z := new(big.Int).Mul(cs[index], s)
+	z.Add(z, r)
+	zs[index] = z.Mod(z, babyjub.SubOrder)
+	return &OrProof{C: cs, Z: zs}, nil
+}
+
+// VerifyOr verifies the proof of knowledge of the private key of one of the
+// public keys, bound to msg.
+func VerifyOr(pks []*babyjub.PublicKey, msg *big.Int, proof *OrProof) error {
+	n := len(pks)
+	if n == 0 || len(proof.C) != n || len(proof.Z) != n {
+		return fmt.Errorf("invalid number of proof elements")
+	}
+	ps := make([]*babyjub.Point, n)
+	rs := make([]*babyjub.Point, n)
+	sum := big.NewInt(0)
+	for i := range pks {
+		ps[i] = pks[i].Point()
+		if !checkPoint(ps[i]) {
+			return fmt.Errorf("public key %d not in the subgroup", i)
+		}
+		if !checkScalar(proof.C[i]) || !checkScalar(proof.Z[i]) {
+			return fmt.Errorf("proof element %d not in [0, SubOrder)", i)
+		}
+		negC := new(big.Int).Sub(babyjub.SubOrder, proof.C[i])
+		rs[i] = mulAdd(proof.Z[i], babyjub.B8, negC, ps[i])
+		sum.Add(sum, proof.C[i])
+	}
+	sum.Mod(sum, babyjub.SubOrder)
+	if sum.Cmp(orChallenge(ps, rs, msg)) != 0 {
+		return fmt.Errorf("invalid or proof")
+	}
+	return nil
+}
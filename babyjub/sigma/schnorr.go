@@ -0,0 +1,62 @@
+package sigma
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// SchnorrProof is a proof of knowledge of the private key of a PublicKey:
+// R = r * B8 and Z = r + c * s, where c is the challenge.
+type SchnorrProof struct {
+	R *babyjub.Point
+	Z *big.Int
+}
+
+// schnorrChallenge returns the challenge of the transcript DomainSchnorr
+// with B8, pk, R and msg.
+func schnorrChallenge(pk, r *babyjub.Point, msg *big.Int) *big.Int {
+	t := poseidon.NewTranscript(DomainSchnorr)
+	t.AppendPoint("B8", babyjub.B8)
+	t.AppendPoint("pk", pk)
+	t.AppendPoint("R", r)
+	appendMsg(t, msg)
+	return challenge(t)
+}
+
+// ProveSchnorr proves knowledge of the private key sk of its public key,
+// binding the proof to msg (which can be nil).
+func ProveSchnorr(sk *babyjub.PrivateKey, msg *big.Int) (*SchnorrProof, error) {
+	s := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	pk := babyjub.NewPoint().Mul(s, babyjub.B8)
+	r, err := babyjub.NewRandScalar()
+	if err != nil {
+		return nil, err
+	}
+	rp := babyjub.NewPoint().Mul(r, babyjub.B8)
+	c := schnorrChallenge(pk, rp, msg)
+	z := new(big.Int).Mul(c, s)
+	z.Add(z, r)
+	z.Mod(z, babyjub.SubOrder)
+	return &SchnorrProof{R: rp, Z: z}, nil
+}
+
+// VerifySchnorr verifies the proof of knowledge of the private key of pk,
+// bound to msg: Z * B8 == R + c * pk.
+func VerifySchnorr(pk *babyjub.PublicKey, msg *big.Int, proof *SchnorrProof) error {
+	if !checkPoint(pk.Point()) || !checkPoint(proof.R) {
+		return fmt.Errorf("point not in the subgroup")
+	}
+	if !checkScalar(proof.Z) {
+		return fmt.Errorf("response not in [0, SubOrder)")
+	}
+	c := schnorrChallenge(pk.Point(), proof.R, msg)
+	left := babyjub.NewPoint().Mul(proof.Z, babyjub.B8)
+	right := babyjub.NewPoint().Add(proof.R, babyjub.NewPoint().Mul(c, pk.Point()))
+	if !left.Equal(right) {
+		return fmt.Errorf("invalid schnorr proof")
+	}
+	return nil
+}
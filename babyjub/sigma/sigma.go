@@ -0,0 +1,54 @@
+// Package sigma implements non-interactive sigma protocols over the
+// BabyJubJub subgroup generated by babyjub.B8: Schnorr proofs of knowledge of
+// a private key, Chaum-Pedersen proofs of discrete logarithm equality (DLEQ)
+// and OR-compositions of Schnorr proofs.
+//
+// The protocols are made non-interactive with the Fiat-Shamir transform using
+// a poseidon.Transcript, so that the challenges can be recomputed cheaply
+// inside a circuit.  The transcript is labeled with the domain of the
+// protocol, and absorbs the statement, the prover commitments and the
+// message before squeezing the challenge, reduced modulo SubOrder.
+package sigma
+
+import (
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// Domain labels of the Fiat-Shamir transcripts of each protocol.
+const (
+	DomainSchnorr = "iden3_sigma_schnorr"
+	DomainDLEQ    = "iden3_sigma_dleq"
+	DomainOr      = "iden3_sigma_or"
+)
+
+// appendMsg appends the message to the transcript, 0 if it is nil.
+func appendMsg(t *poseidon.Transcript, msg *big.Int) {
+	if msg == nil {
+		msg = big.NewInt(0)
+	}
+	t.AppendScalar("msg", msg)
+}
+
+// challenge squeezes the challenge of the transcript, reduced modulo
+// SubOrder.
+func challenge(t *poseidon.Transcript) *big.Int {
+	return t.ChallengeScalar("c", babyjub.SubOrder)
+}
+
+// checkPoint returns true if the point is in the subgroup.
+func checkPoint(p *babyjub.Point) bool {
+	return p != nil && p.X != nil && p.Y != nil && p.InSubGroup()
+}
+
+// checkScalar returns true if the scalar is in [0, SubOrder).
+func checkScalar(s *big.Int) bool {
+	return s != nil && s.Sign() >= 0 && s.Cmp(babyjub.SubOrder) < 0
+}
+
+// mulAdd returns a * B + c * P.
+func mulAdd(a *big.Int, b *babyjub.Point, c *big.Int, p *babyjub.Point) *babyjub.Point {
+	return babyjub.NewPoint().Add(babyjub.NewPoint().Mul(a, b), babyjub.NewPoint().Mul(c, p))
+}
@@ -0,0 +1,106 @@
+package sigma
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallenge(t *testing.T) {
+	tr := poseidon.NewTranscript(DomainSchnorr)
+	tr.AppendPoint("R", babyjub.B8)
+	expected := tr.Clone().ChallengeScalar("c", babyjub.SubOrder)
+	assert.Equal(t, expected, challenge(tr))
+	assert.True(t, expected.Cmp(babyjub.SubOrder) < 0)
+}
+
+func TestSchnorr(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+	msg := big.NewInt(1234)
+
+	proof, err := ProveSchnorr(&sk, msg)
+	require.Nil(t, err)
+	assert.Nil(t, VerifySchnorr(pk, msg, proof))
+
+	// wrong message or public key
+	assert.NotNil(t, VerifySchnorr(pk, big.NewInt(1235), proof))
+	other := babyjub.NewRandPrivKey()
+	assert.NotNil(t, VerifySchnorr(other.Public(), msg, proof))
+
+	// tampered response
+	bad := *proof
+	bad.Z = new(big.Int).Add(proof.Z, big.NewInt(1))
+	bad.Z.Mod(bad.Z, babyjub.SubOrder)
+	assert.Equal(t, "invalid schnorr proof", VerifySchnorr(pk, msg, &bad).Error())
+	bad.Z = new(big.Int).Add(proof.Z, babyjub.SubOrder)
+	assert.Equal(t, "response not in [0, SubOrder)", VerifySchnorr(pk, msg, &bad).Error())
+
+	// nil message
+	proof, err = ProveSchnorr(&sk, nil)
+	require.Nil(t, err)
+	assert.Nil(t, VerifySchnorr(pk, nil, proof))
+	assert.Nil(t, VerifySchnorr(pk, big.NewInt(0), proof))
+}
+
+func TestDLEQ(t *testing.T) {
+	g := babyjub.B8
+	h := babyjub.HashToCurve([]byte("H"), []byte("sigma-test"))
+	x := big.NewInt(987654321)
+	msg := big.NewInt(1)
+
+	a, b, proof, err := ProveDLEQ(x, g, h, msg)
+	require.Nil(t, err)
+	assert.True(t, a.Equal(babyjub.NewPoint().Mul(x, g)))
+	assert.True(t, b.Equal(babyjub.NewPoint().Mul(x, h)))
+	assert.Nil(t, VerifyDLEQ(g, h, a, b, msg, proof))
+
+	// B with a different discrete logarithm
+	b2 := babyjub.NewPoint().Mul(big.NewInt(987654322), h)
+	assert.Equal(t, "invalid dleq proof", VerifyDLEQ(g, h, a, b2, msg, proof).Error())
+	assert.NotNil(t, VerifyDLEQ(g, h, a, b, big.NewInt(2), proof))
+
+	// points out of the subgroup are rejected
+	low := babyjub.Point{X: big.NewInt(0), Y: new(big.Int).Sub(constants.Q, big.NewInt(1))}
+	assert.NotNil(t, VerifyDLEQ(g, &low, a, b, msg, proof))
+}
+
+func TestOr(t *testing.T) {
+	sks := make([]babyjub.PrivateKey, 4)
+	pks := make([]*babyjub.PublicKey, 4)
+	for i := range sks {
+		sks[i] = babyjub.NewRandPrivKey()
+		pks[i] = sks[i].Public()
+	}
+	msg := big.NewInt(42)
+
+	for i := range sks {
+		proof, err := ProveOr(pks, i, &sks[i], msg)
+		require.Nil(t, err)
+		assert.Nil(t, VerifyOr(pks, msg, proof))
+		assert.NotNil(t, VerifyOr(pks, big.NewInt(43), proof))
+		assert.NotNil(t, VerifyOr(pks[:3], msg, proof))
+	}
+
+	// single public key
+	proof, err := ProveOr(pks[:1], 0, &sks[0], msg)
+	require.Nil(t, err)
+	assert.Nil(t, VerifyOr(pks[:1], msg, proof))
+
+	// the private key must match the public key of the index
+	_, err = ProveOr(pks, 1, &sks[0], msg)
+	assert.Equal(t, "private key does not match public key 1", err.Error())
+
+	// replacing the public keys invalidates the proof
+	proof, err = ProveOr(pks, 2, &sks[2], msg)
+	require.Nil(t, err)
+	other := babyjub.NewRandPrivKey()
+	pks2 := append([]*babyjub.PublicKey{}, pks...)
+	pks2[0] = other.Public()
+	assert.Equal(t, "invalid or proof", VerifyOr(pks2, msg, proof).Error())
+}
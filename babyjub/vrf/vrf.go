@@ -0,0 +1,201 @@
+// Package vrf implements a verifiable random function over the BabyJubJub
+// subgroup generated by babyjub.B8, following the structure of ECVRF
+// (RFC 9381) with circuit friendly choices for the challenge and the output.
+//
+// The input alpha is hashed to the point H = HashToCurve(pk || alpha) with
+// the hash to curve of babyjub, the proof holds Gamma = sk * H and a DLEQ
+// proof (C, S) that log_B8(pk) == log_H(Gamma), whose challenge is computed
+// with a poseidon.Transcript, and the output beta is squeezed from a
+// poseidon.Transcript that absorbs Gamma.  The proof is deterministic, so
+// each key and input have a single valid output.
+package vrf
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+const (
+	// DST is the domain separation tag of the hash to curve of the input.
+	DST = "IDEN3_BABYJUB_VRF_XMD:SHA-256_ELL2_RO_"
+	// DomainChallenge is the label of the transcript of the DLEQ challenge.
+	DomainChallenge = "iden3_vrf_challenge"
+	// DomainOutput is the label of the transcript of the output.
+	DomainOutput = "iden3_vrf_output"
+	// DomainNonce is the prefix of the blake-512 input of the nonce.
+	DomainNonce = "vrf"
+)
+
+// Proof is a VRF proof: the point Gamma = sk * H and the DLEQ proof (C, S)
+// with S = k + C * sk.
+type Proof struct {
+	Gamma *babyjub.Point
+	C     *big.Int
+	S     *big.Int
+}
+
+// hashToCurve returns the point H of the input alpha for the public key.
+func hashToCurve(pk *babyjub.Point, alpha []byte) *babyjub.Point {
+	pkComp := pk.Compress()
+	return babyjub.HashToCurve(append(pkComp[:], alpha...), []byte(DST))
+}
+
+// challenge returns the DLEQ challenge of B8, pk, H, Gamma, U and V.
+func challenge(pk, h, gamma, u, v *babyjub.Point) *big.Int {
+	t := poseidon.NewTranscript(DomainChallenge)
+	t.AppendPoint("B8", babyjub.B8)
+	t.AppendPoint("pk", pk)
+	t.AppendPoint("H", h)
+	t.AppendPoint("Gamma", gamma)
+	t.AppendPoint("U", u)
+	t.AppendPoint("V", v)
+	return t.ChallengeScalar("c", babyjub.SubOrder)
+}
+
+// nonce returns the deterministic nonce k = H(DomainNonce, H_{32..63}(sk), H)
+// mod SubOrder, using blake-512 as SignPoseidon does.  The prefix separates
+// it from the nonces of SignPoseidon, which would otherwise be the same for a
+// message whose bytes are the compressed H, and leak sk.
+func nonce(sk *babyjub.PrivateKey, h *babyjub.Point) *big.Int {
+	h1 := babyjub.Blake512(sk[:])
+	hComp := h.Compress()
+	kInput := append([]byte(DomainNonce), h1[32:]...)
+	kBuf := babyjub.Blake512(append(kInput, hComp[:]...))
+	k := utils.SetBigIntFromLEBytes(new(big.Int), kBuf)
+	return k.Mod(k, babyjub.SubOrder)
+}
+
+// Prove computes the VRF proof of the input alpha with the private key sk.
+// The output is obtained from the proof with Proof.Hash.
+func Prove(sk *babyjub.PrivateKey, alpha []byte) *Proof {
+	s := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	pk := babyjub.NewPoint().Mul(s, babyjub.B8)
+	h := hashToCurve(pk, alpha)
+	gamma := babyjub.NewPoint().Mul(s, h)
+	k := nonce(sk, h)
+	u := babyjub.NewPoint().Mul(k, babyjub.B8)
+	v := babyjub.NewPoint().Mul(k, h)
+	c := challenge(pk, h, gamma, u, v)
+	z := new(big.Int).Mul(c, s)
+	z.Add(z, k)
+	z.Mod(z, babyjub.SubOrder)
+	return &Proof{Gamma: gamma, C: c, S: z}
+}
+
+// Hash returns the VRF output beta of the proof, a field element.  It doesn't
+// verify the proof, use Verify to obtain the output of untrusted proofs.
+func (p *Proof) Hash() *big.Int {
+	t := poseidon.NewTranscript(DomainOutput)
+	t.AppendPoint("Gamma", p.Gamma)
+	return t.Challenge("beta")
+}
+
+func checkPoint(p *babyjub.Point) bool {
+	return p != nil && p.X != nil && p.Y != nil && p.InSubGroup()
+}
+
+func checkScalar(s *big.Int) bool {
+	return s != nil && s.Sign() >= 0 && s.Cmp(babyjub.SubOrder) < 0
+}
+
+// Verify verifies the VRF proof of the input alpha for the public key pk, and
+// returns the VRF output beta.
+func Verify(pk *babyjub.PublicKey, alpha []byte, proof *Proof) (*big.Int, error) {
+	y := pk.Point()
+	if !checkPoint(y) || y.Equal(babyjub.NewPoint()) {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	if !checkPoint(proof.Gamma) {
+		return nil, fmt.Errorf("gamma not in the subgroup")
+	}
+	if !checkScalar(proof.C) || !checkScalar(proof.S) {
+		return nil, fmt.Errorf("proof scalars not in [0, SubOrder)")
+	}
+	h := hashToCurve(y, alpha)
+	negC := new(big.Int).Sub(babyjub.SubOrder, proof.C)
+	// U = S * B8 - C * pk, V = S * H - C * Gamma
+	u := babyjub.NewPoint().Add(babyjub.NewPoint().Mul(proof.S, babyjub.B8),
+		babyjub.NewPoint().Mul(negC, y))
+	v := babyjub.NewPoint().Add(babyjub.NewPoint().Mul(proof.S, h),
+		babyjub.NewPoint().Mul(negC, proof.Gamma))
+	if challenge(y, h, proof.Gamma, u, v).Cmp(proof.C) != 0 {
+		return nil, fmt.Errorf("invalid vrf proof")
+	}
+	return proof.Hash(), nil
+}
+
+// ProofComp represents a compressed VRF proof: the compressed point Gamma
+// followed by the Little-Endian encodings of C and S.
+type ProofComp [96]byte
+
+// MarshalText implements the marshaler for the ProofComp
+func (pComp ProofComp) MarshalText() ([]byte, error) {
+	return utils.Hex(pComp[:]).MarshalText()
+}
+
+// String returns the string representation of the ProofComp
+func (pComp ProofComp) String() string { return utils.Hex(pComp[:]).String() }
+
+// UnmarshalText implements the unmarshaler for the ProofComp
+func (pComp *ProofComp) UnmarshalText(h []byte) error {
+	return utils.HexDecodeInto(pComp[:], h)
+}
+
+// Compress returns the ProofComp for the given Proof
+func (p *Proof) Compress() ProofComp {
+	var buf ProofComp
+	gamma := p.Gamma.Compress()
+	c := utils.BigIntLEBytes(p.C)
+	s := utils.BigIntLEBytes(p.S)
+	copy(buf[:32], gamma[:])
+	copy(buf[32:64], c[:])
+	copy(buf[64:], s[:])
+	return buf
+}
+
+// Decompress returns the Proof for the given ProofComp.  Returns error if the
+// point decompression fails, if Gamma is not in the subgroup or if the
+// scalars are not in [0, SubOrder).
+func (pComp *ProofComp) Decompress() (*Proof, error) {
+	var gammaComp [32]byte
+	copy(gammaComp[:], pComp[:32])
+	gamma, err := babyjub.NewPoint().Decompress(gammaComp)
+	if err != nil {
+		return nil, err
+	}
+	if !gamma.InSubGroup() {
+		return nil, fmt.Errorf("gamma not in the subgroup")
+	}
+	p := &Proof{
+		Gamma: gamma,
+		C:     utils.SetBigIntFromLEBytes(new(big.Int), pComp[32:64]),
+		S:     utils.SetBigIntFromLEBytes(new(big.Int), pComp[64:]),
+	}
+	if !checkScalar(p.C) || !checkScalar(p.S) {
+		return nil, fmt.Errorf("proof scalars not in [0, SubOrder)")
+	}
+	return p, nil
+}
+
+// MarshalText implements the marshaler for the Proof
+func (p Proof) MarshalText() ([]byte, error) {
+	return p.Compress().MarshalText()
+}
+
+// UnmarshalText implements the unmarshaler for the Proof
+func (p *Proof) UnmarshalText(h []byte) error {
+	var pComp ProofComp
+	if err := pComp.UnmarshalText(h); err != nil {
+		return err
+	}
+	p2, err := pComp.Decompress()
+	if err != nil {
+		return err
+	}
+	*p = *p2
+	return nil
+}
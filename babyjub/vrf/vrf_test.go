@@ -0,0 +1,142 @@
+package vrf
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T) babyjub.PrivateKey {
+	var sk babyjub.PrivateKey
+	b, err := hex.DecodeString("0001020304050607080900010203040506070809000102030405060708090001")
+	require.Nil(t, err)
+	copy(sk[:], b)
+	return sk
+}
+
+func TestVectors(t *testing.T) {
+	sk := testKey(t)
+	pk := sk.Public()
+	assert.Equal(t, "f30eaa3a55c66695245455c11d35004962ba29a7aa7c1bbfef8da4cc5f644a87",
+		pk.Compress().String())
+
+	vectors := []struct {
+		alpha string
+		proof string
+		beta  string
+	}{
+		{
+			"",
+			"a6d458f2616097a437e5de2775310761d57076c5377d885fd06e43ebeab0918d" +
+				"03a357ad8ec5e8f75f061b77a1c1a3790f191111a5493f269e1396f3a182c501" +
+				"b61faeee751c8acd43d134e4e557362d76e4886394e29d4bb2dc43d0db89cf05",
+			"14537096443180638308431254688541087562828495972074131881782015417368401916112",
+		},
+		{
+			"sample",
+			"3fc539c0163126a983a4ac49306247387a670be2594f1ee5ac07d396e126f2ae" +
+				"de11e4289fb1eba7c25ddb6ad2e591f2c52c2a82419dd00067d8cd0941d7aa00" +
+				"6d32c4bae886c2b393cd6b782a463c72e46f3055ea6aeb11be9127d2e0904001",
+			"12300794940412696696151984489556923326798668973789822874700230404885765094146",
+		},
+		{
+			"leader election round 1",
+			"0313b9e5db4b787ea899f60772ae3426d89a7b7f956cf67f70d9c3338df1da19" +
+				"a604986aa7f7e1280d05bf67e196dc484b30b9d35e246fe8ef298d997ff3dc02" +
+				"1c2cc057fd554a03f34ffe772d818383739eaaa9e2d027f83d8a374dc4936d05",
+			"17226152838970523307453729268441182904811090542601362521378591184721940510274",
+		},
+	}
+	for _, v := range vectors {
+		proof := Prove(&sk, []byte(v.alpha))
+		assert.Equal(t, v.proof, proof.Compress().String())
+		assert.Equal(t, v.beta, proof.Hash().String())
+
+		var pComp ProofComp
+		require.Nil(t, pComp.UnmarshalText([]byte(v.proof)))
+		decoded, err := pComp.Decompress()
+		require.Nil(t, err)
+		beta, err := Verify(pk, []byte(v.alpha), decoded)
+		require.Nil(t, err)
+		assert.Equal(t, v.beta, beta.String())
+	}
+}
+
+func TestProveVerify(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	pk := sk.Public()
+	alpha := []byte("alpha")
+
+	proof := Prove(&sk, alpha)
+	beta, err := Verify(pk, alpha, proof)
+	require.Nil(t, err)
+	assert.Equal(t, proof.Hash(), beta)
+
+	// deterministic
+	assert.Equal(t, proof.Compress(), Prove(&sk, alpha).Compress())
+	// different inputs give different outputs
+	assert.NotEqual(t, beta, Prove(&sk, []byte("alpha2")).Hash())
+
+	// wrong input or public key
+	_, err = Verify(pk, []byte("alpha2"), proof)
+	assert.NotNil(t, err)
+	other := babyjub.NewRandPrivKey()
+	_, err = Verify(other.Public(), alpha, proof)
+	assert.NotNil(t, err)
+
+	// tampered proofs
+	bad := *proof
+	bad.S = new(big.Int).Add(proof.S, big.NewInt(1))
+	_, err = Verify(pk, alpha, &bad)
+	assert.NotNil(t, err)
+	bad = *proof
+	bad.Gamma = babyjub.NewPoint().Add(proof.Gamma, babyjub.B8)
+	_, err = Verify(pk, alpha, &bad)
+	assert.NotNil(t, err)
+	bad = *proof
+	bad.C = new(big.Int).Add(proof.C, babyjub.SubOrder)
+	_, err = Verify(pk, alpha, &bad)
+	assert.NotNil(t, err)
+}
+
+func TestNonceSeparation(t *testing.T) {
+	sk := testKey(t)
+	pk := sk.Public().Point()
+	// an input whose H, compressed, is a field element that can be signed
+	for i := 0; ; i++ {
+		h := hashToCurve(pk, []byte{byte(i)})
+		hComp := h.Compress()
+		msg := utils.SetBigIntFromLEBytes(new(big.Int), hComp[:])
+		if !utils.CheckBigIntInField(msg) {
+			continue
+		}
+		sig := sk.SignPoseidon(msg)
+		u := babyjub.NewPoint().Mul(nonce(&sk, h), babyjub.B8)
+		assert.False(t, u.Equal(sig.R8))
+		break
+	}
+}
+
+func TestProofText(t *testing.T) {
+	sk := testKey(t)
+	proof := Prove(&sk, []byte("sample"))
+	text, err := proof.MarshalText()
+	require.Nil(t, err)
+	var proof2 Proof
+	require.Nil(t, proof2.UnmarshalText(text))
+	assert.Equal(t, proof.Compress(), proof2.Compress())
+
+	// Gamma of order 2, outside of the subgroup
+	pComp := proof.Compress()
+	lowOrder := (&babyjub.Point{X: big.NewInt(0),
+		Y: new(big.Int).Sub(constants.Q, big.NewInt(1))}).Compress()
+	copy(pComp[:32], lowOrder[:])
+	_, err = pComp.Decompress()
+	assert.NotNil(t, err)
+}
@@ -7,5 +7,5 @@ require (
 	github.com/ethereum/go-ethereum v1.9.12
 	github.com/stretchr/testify v1.4.0
 	golang.org/x/crypto v0.0.0-20200311171314-f7b00557c8c4
-	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
+	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd
 )
@@ -0,0 +1,181 @@
+// Package identity implements Semaphore-style identities: an identity is a
+// pair of secrets, the trapdoor and the nullifier, whose public identity
+// commitment is Poseidon(Poseidon(nullifier, trapdoor)), and which signals
+// once per external nullifier with the nullifier hash
+// Poseidon(externalNullifier, nullifier).
+//
+// The hashes use poseidon.Hash of this module, so the circuits that verify
+// the proofs of membership and the nullifier hashes must use the same Poseidon
+// parameters.
+package identity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// Identity is a Semaphore identity, made of two secret field elements.
+type Identity struct {
+	Trapdoor  *big.Int
+	Nullifier *big.Int
+}
+
+// New returns a new random identity.
+func New() (*Identity, error) {
+	trapdoor, err := rand.Int(rand.Reader, constants.Q)
+	if err != nil {
+		return nil, err
+	}
+	nullifier, err := rand.Int(rand.Reader, constants.Q)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{Trapdoor: trapdoor, Nullifier: nullifier}, nil
+}
+
+// FromMessage deterministically derives an identity from a secret message,
+// such as a signature of a wallet, hashing it with SHA-256 and a label for
+// each secret: SHA-256(SHA-256(msg) || "identity_trapdoor") mod Q and
+// SHA-256(SHA-256(msg) || "identity_nullifier") mod Q.
+func FromMessage(msg []byte) *Identity {
+	h := sha256.Sum256(msg)
+	derive := func(label string) *big.Int {
+		d := sha256.Sum256(append(h[:], label...))
+		v := new(big.Int).SetBytes(d[:])
+		return v.Mod(v, constants.Q)
+	}
+	return &Identity{
+		Trapdoor:  derive("identity_trapdoor"),
+		Nullifier: derive("identity_nullifier"),
+	}
+}
+
+func hash(inputs ...*big.Int) *big.Int {
+	h, err := poseidon.Hash(inputs)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// Secret returns the identity secret Poseidon(nullifier, trapdoor).
+func (id *Identity) Secret() *big.Int {
+	return hash(id.Nullifier, id.Trapdoor)
+}
+
+// Commitment returns the public identity commitment Poseidon(secret), which
+// is the leaf of the identity in the Merkle tree of the group.
+func (id *Identity) Commitment() *big.Int {
+	return hash(id.Secret())
+}
+
+// NullifierHash returns the nullifier hash of the identity for the external
+// nullifier, Poseidon(externalNullifier, nullifier), which is the same for
+// all the signals of the identity with the external nullifier.
+func (id *Identity) NullifierHash(externalNullifier *big.Int) *big.Int {
+	return hash(externalNullifier, id.Nullifier)
+}
+
+// SignalHash returns the hash of a signal as expected by the Semaphore
+// circuit: keccak256(signal) shifted right by 8 bits, so that it fits in the
+// field.
+func SignalHash(signal []byte) *big.Int {
+	h := new(big.Int).SetBytes(crypto.Keccak256(signal))
+	return h.Rsh(h, 8) //nolint:gomnd
+}
+
+// MarshalJSON implements the json marshaler for the Identity, as the
+// Semaphore serialization: a list with the trapdoor and the nullifier as
+// 0x-prefixed hex strings.
+func (id Identity) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{
+		fmt.Sprintf("0x%x", id.Trapdoor),
+		fmt.Sprintf("0x%x", id.Nullifier),
+	})
+}
+
+// UnmarshalJSON implements the json unmarshaler for the Identity, checking
+// that the secrets are in the field.
+func (id *Identity) UnmarshalJSON(b []byte) error {
+	var secrets []string
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return err
+	}
+	if len(secrets) != 2 { //nolint:gomnd
+		return fmt.Errorf("identity with %d secrets, want 2", len(secrets))
+	}
+	values := make([]*big.Int, len(secrets))
+	for i, s := range secrets {
+		v, ok := new(big.Int).SetString(s, 0)
+		if !ok || v.Sign() < 0 || v.Cmp(constants.Q) >= 0 {
+			return fmt.Errorf("invalid identity secret %q", s)
+		}
+		values[i] = v
+	}
+	id.Trapdoor, id.Nullifier = values[0], values[1]
+	return nil
+}
+
+// String returns the serialization of the Identity, see MarshalJSON.
+func (id Identity) String() string {
+	b, err := id.MarshalJSON()
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// Parse restores an identity from its serialization.
+func Parse(s string) (*Identity, error) {
+	var id Identity
+	if err := id.UnmarshalJSON([]byte(s)); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// WitnessInput is the input of the Semaphore circuit, which serializes to the
+// JSON expected by the witness generator, with the numbers as decimal
+// strings.
+type WitnessInput struct {
+	IdentityNullifier string   `json:"identityNullifier"`
+	IdentityTrapdoor  string   `json:"identityTrapdoor"`
+	TreePathIndices   []int    `json:"treePathIndices"`
+	TreeSiblings      []string `json:"treeSiblings"`
+	ExternalNullifier string   `json:"externalNullifier"`
+	SignalHash        string   `json:"signalHash"`
+}
+
+// WitnessInput returns the input of the Semaphore circuit to signal with the
+// identity, given the Merkle proof of its commitment in the group tree (the
+// path indices, 0 for left and 1 for right, and the siblings from the leaf
+// to the root), the external nullifier and the signal.
+func (id *Identity) WitnessInput(pathIndices []int, siblings []*big.Int,
+	externalNullifier *big.Int, signal []byte) (*WitnessInput, error) {
+	if len(pathIndices) != len(siblings) {
+		return nil, fmt.Errorf("%d path indices for %d siblings", len(pathIndices), len(siblings))
+	}
+	w := &WitnessInput{
+		IdentityNullifier: id.Nullifier.String(),
+		IdentityTrapdoor:  id.Trapdoor.String(),
+		TreePathIndices:   make([]int, len(pathIndices)),
+		TreeSiblings:      make([]string, len(siblings)),
+		ExternalNullifier: externalNullifier.String(),
+		SignalHash:        SignalHash(signal).String(),
+	}
+	for i, idx := range pathIndices {
+		if idx != 0 && idx != 1 {
+			return nil, fmt.Errorf("invalid path index %d", idx)
+		}
+		w.TreePathIndices[i] = idx
+		w.TreeSiblings[i] = siblings[i].String()
+	}
+	return w, nil
+}
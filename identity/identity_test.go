@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentity(t *testing.T) {
+	id := &Identity{Trapdoor: big.NewInt(1), Nullifier: big.NewInt(2)}
+	assert.Equal(t,
+		"279124977313469174950355823022037645903743288271546722311449586398308162460",
+		id.Secret().String())
+	assert.Equal(t,
+		"9956081413561753219368561852051996578835975977363854970484227170604260679453",
+		id.Commitment().String())
+	assert.Equal(t,
+		"21063177150442916958534146815533752066392848035456997818004801131195835436191",
+		id.NullifierHash(big.NewInt(42)).String())
+	assert.NotEqual(t, id.NullifierHash(big.NewInt(42)), id.NullifierHash(big.NewInt(43)))
+
+	id, err := New()
+	require.Nil(t, err)
+	assert.True(t, id.Trapdoor.Cmp(constants.Q) < 0)
+	assert.True(t, id.Nullifier.Cmp(constants.Q) < 0)
+	id2, err := New()
+	require.Nil(t, err)
+	assert.NotEqual(t, id.Commitment(), id2.Commitment())
+}
+
+func TestFromMessage(t *testing.T) {
+	id := FromMessage([]byte("signed message"))
+	assert.Equal(t,
+		"7480116977747803414402784009499268523058132530075168675797946477801190821853",
+		id.Trapdoor.String())
+	assert.Equal(t,
+		"18197497142365427170870661495599299785082630080684289592903896875421727371998",
+		id.Nullifier.String())
+	assert.Equal(t,
+		"14044470878450401827538743770691769051986180011512268975864894471785949001259",
+		id.Commitment().String())
+	assert.Equal(t,
+		"142850789488466699239825852517839999338632080137620958713624217415722229860",
+		id.NullifierHash(big.NewInt(42)).String())
+
+	assert.Equal(t, id, FromMessage([]byte("signed message")))
+	assert.NotEqual(t, id.Commitment(), FromMessage([]byte("other message")).Commitment())
+}
+
+func TestSignalHash(t *testing.T) {
+	// keccak256("") >> 8
+	assert.Equal(t,
+		"0x00c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a4",
+		fmt.Sprintf("0x%064x", SignalHash([]byte{})))
+	assert.True(t, SignalHash([]byte("hello")).BitLen() <= 248)
+}
+
+func TestSerialization(t *testing.T) {
+	id := &Identity{Trapdoor: big.NewInt(255), Nullifier: big.NewInt(4096)}
+	assert.Equal(t, `["0xff","0x1000"]`, id.String())
+
+	id2, err := Parse(id.String())
+	require.Nil(t, err)
+	assert.Equal(t, id, id2)
+
+	id, err = New()
+	require.Nil(t, err)
+	b, err := json.Marshal(id)
+	require.Nil(t, err)
+	var id3 Identity
+	require.Nil(t, json.Unmarshal(b, &id3))
+	assert.Equal(t, id.Commitment(), id3.Commitment())
+
+	_, err = Parse(`["0x1"]`)
+	assert.NotNil(t, err)
+	_, err = Parse(`["0x1","zz"]`)
+	assert.NotNil(t, err)
+	_, err = Parse(`["0x1","0x` + constants.Q.Text(16) + `"]`)
+	assert.NotNil(t, err)
+	_, err = Parse(`{}`)
+	assert.NotNil(t, err)
+}
+
+func TestWitnessInput(t *testing.T) {
+	id := &Identity{Trapdoor: big.NewInt(1), Nullifier: big.NewInt(2)}
+	siblings := []*big.Int{big.NewInt(10), big.NewInt(20)}
+	w, err := id.WitnessInput([]int{0, 1}, siblings, big.NewInt(7), []byte{})
+	require.Nil(t, err)
+	b, err := json.Marshal(w)
+	require.Nil(t, err)
+	assert.Equal(t, `{"identityNullifier":"2","identityTrapdoor":"1",`+
+		`"treePathIndices":[0,1],"treeSiblings":["10","20"],"externalNullifier":"7",`+
+		`"signalHash":"`+SignalHash([]byte{}).String()+`"}`, string(b))
+
+	_, err = id.WitnessInput([]int{0}, siblings, big.NewInt(7), nil)
+	assert.NotNil(t, err)
+	_, err = id.WitnessInput([]int{0, 2}, siblings, big.NewInt(7), nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,499 @@
+// Package incremental implements the append-only incremental Merkle tree of
+// deposit contracts and mixers, such as Tornado Cash's MerkleTreeWithHistory
+// and Semaphore's groups: a binary tree of fixed depth whose leaves are
+// inserted from left to right, whose empty leaves have a zero value, and that
+// keeps a ring buffer with the last roots, so that proofs against a recent
+// root are still accepted after new insertions.
+//
+// As the contracts, Tree only keeps the frontier of the tree, the last left
+// node of each level, with the zero hashes and the root history, so that an
+// insertion and a restore take O(levels).  The nodes of the paths of the
+// insertions are written by hash to a storage.Storage, from which the Merkle
+// path of any leaf is generated.  With a file storage, the tree is persisted
+// at each insertion and can be reopened, and any of its previous roots can
+// be opened as a snapshot.
+package incremental
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/merkle/storage"
+	"github.com/iden3/go-iden3-crypto/mimc7"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// MaxLevels is the maximum number of levels of a tree, so that the indexes
+// of the leaves fit in an uint64.
+const MaxLevels = 32
+
+// elemBytesLen is the length of the encoding of a field element in the
+// storage.
+const elemBytesLen = 32
+
+// DefaultRootHistorySize is the number of roots kept by Tornado Cash's
+// MerkleTreeWithHistory.
+const DefaultRootHistorySize = 30
+
+// Hasher returns the hash of a node with children l and r.
+type Hasher func(l, r *big.Int) (*big.Int, error)
+
+// PoseidonHasher hashes the nodes as Poseidon(l, r).
+func PoseidonHasher(l, r *big.Int) (*big.Int, error) {
+	return poseidon.Hash([]*big.Int{l, r})
+}
+
+// MiMC7Hasher hashes the nodes as the MiMC7 of [l, r] with key 0.
+func MiMC7Hasher(l, r *big.Int) (*big.Int, error) {
+	return mimc7.Hash([]*big.Int{l, r}, nil)
+}
+
+// ZeroHashes returns the roots of the empty subtrees of height 0 to levels,
+// whose leaves are zeroLeaf.
+func ZeroHashes(hasher Hasher, levels int, zeroLeaf *big.Int) ([]*big.Int, error) {
+	zeros := make([]*big.Int, levels+1)
+	zeros[0] = new(big.Int).Set(zeroLeaf)
+	for i := 1; i <= levels; i++ {
+		z, err := hasher(zeros[i-1], zeros[i-1])
+		if err != nil {
+			return nil, err
+		}
+		zeros[i] = z
+	}
+	return zeros, nil
+}
+
+// ErrSnapshot is returned when inserting in a snapshot.
+var ErrSnapshot = errors.New("snapshots are read-only")
+
+// stateKey is the key of the state of the tree in the storage, which can't
+// clash with the 32 bytes keys of the nodes.
+var stateKey = []byte("state")
+
+// sizeKey returns the key of the number of leaves of the tree when it had
+// the root, which can't clash with the 32 bytes keys of the nodes.
+func sizeKey(root *big.Int) []byte {
+	return append([]byte("size"), encodeElem(root)...)
+}
+
+// Tree is an incremental Merkle tree with root history.
+type Tree struct {
+	hasher    Hasher
+	levels    int
+	zeros     []*big.Int
+	db        storage.Storage
+	numLeaves uint64
+	// frontier are the last left nodes of each level from the leaves up,
+	// the filledSubtrees of MerkleTreeWithHistory
+	frontier []*big.Int
+	// roots is the ring buffer of the last roots, with the current one at
+	// currentRootIndex
+	roots            []*big.Int
+	currentRootIndex int
+	snapshot         bool
+}
+
+// New returns an empty tree with the given number of levels, at most
+// MaxLevels, whose empty leaves are zeroLeaf, and that keeps the last
+// historySize roots.  The nodes are kept in memory.  A nil hasher defaults
+// to PoseidonHasher.
+func New(hasher Hasher, levels int, zeroLeaf *big.Int, historySize int) (*Tree, error) {
+	return NewWithStorage(storage.NewMemory(), hasher, levels, zeroLeaf, historySize)
+}
+
+// NewWithStorage returns the tree in the storage, which is empty if the
+// storage is new, with the given number of levels, zero leaf and root
+// history size, which must be the ones of the tree in the storage.  A nil
+// hasher defaults to PoseidonHasher.
+func NewWithStorage(db storage.Storage, hasher Hasher, levels int, zeroLeaf *big.Int,
+	historySize int) (*Tree, error) {
+	t, err := newTree(db, hasher, levels, zeroLeaf, historySize)
+	if err != nil {
+		return nil, err
+	}
+	b, err := db.Get(stateKey)
+	if err == storage.ErrNotFound {
+		return t, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s state
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	if s.Levels != levels || s.ZeroLeaf == nil || s.ZeroLeaf.Cmp(zeroLeaf) != 0 ||
+		len(s.Roots) != historySize {
+		return nil, fmt.Errorf("the tree in the storage has other parameters")
+	}
+	if err := t.load(&s); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// newTree returns an empty tree in the storage.
+func newTree(db storage.Storage, hasher Hasher, levels int, zeroLeaf *big.Int,
+	historySize int) (*Tree, error) {
+	if hasher == nil {
+		hasher = PoseidonHasher
+	}
+	if levels < 1 || levels > MaxLevels {
+		return nil, fmt.Errorf("levels must be between 1 and %d", MaxLevels)
+	}
+	if historySize < 1 {
+		return nil, fmt.Errorf("root history size must be positive")
+	}
+	if !utils.CheckBigIntInField(zeroLeaf) {
+		return nil, fmt.Errorf("zero leaf must be inside the finite field")
+	}
+	zeros, err := ZeroHashes(hasher, levels, zeroLeaf)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{
+		hasher:   hasher,
+		levels:   levels,
+		zeros:    zeros,
+		db:       db,
+		frontier: make([]*big.Int, levels),
+		roots:    make([]*big.Int, historySize),
+	}
+	copy(t.frontier, zeros)
+	t.roots[0] = zeros[levels]
+	return t, nil
+}
+
+// Levels returns the number of levels of the tree.
+func (t *Tree) Levels() int { return t.levels }
+
+// NumLeaves returns the number of inserted leaves, which is the index of the
+// next leaf.
+func (t *Tree) NumLeaves() uint64 { return t.numLeaves }
+
+// Root returns the current root of the tree.
+func (t *Tree) Root() *big.Int { return new(big.Int).Set(t.roots[t.currentRootIndex]) }
+
+func encodeElem(e *big.Int) []byte {
+	b := utils.BigIntLEBytes(e)
+	return b[:]
+}
+
+func decodeElem(b []byte) *big.Int {
+	return utils.SetBigIntFromLEBytes(new(big.Int), b)
+}
+
+// children returns the children of the node with hash h of the level.
+func (t *Tree) children(lvl int, h *big.Int) (*big.Int, *big.Int, error) {
+	if h.Cmp(t.zeros[lvl]) == 0 {
+		return t.zeros[lvl-1], t.zeros[lvl-1], nil
+	}
+	b, err := t.db.Get(encodeElem(h))
+	if err == storage.ErrNotFound {
+		return nil, nil, fmt.Errorf("node %s not found", h)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if len(b) != 2*elemBytesLen {
+		return nil, nil, fmt.Errorf("invalid node length %d", len(b))
+	}
+	return decodeElem(b[:elemBytesLen]), decodeElem(b[elemBytesLen:]), nil
+}
+
+// Insert inserts the leaf at the next index, which it returns, and adds the
+// new root to the history.
+func (t *Tree) Insert(leaf *big.Int) (uint64, error) {
+	index := t.numLeaves
+	if index == 1<<uint(t.levels) {
+		return 0, fmt.Errorf("merkle tree is full")
+	}
+	if !utils.CheckBigIntInField(leaf) {
+		return 0, fmt.Errorf("leaf must be inside the finite field")
+	}
+	if t.snapshot {
+		return 0, ErrSnapshot
+	}
+	next := *t
+	next.frontier = make([]*big.Int, t.levels)
+	copy(next.frontier, t.frontier)
+	b := t.db.Batch()
+	h := new(big.Int).Set(leaf)
+	i := index
+	for lvl := 0; lvl < t.levels; lvl++ {
+		var l, r *big.Int
+		if i%2 == 0 {
+			l, r = h, t.zeros[lvl]
+			next.frontier[lvl] = h
+		} else {
+			l, r = next.frontier[lvl], h
+		}
+		var err error
+		if h, err = t.hasher(l, r); err != nil {
+			b.Rollback()
+			return 0, err
+		}
+		b.Put(encodeElem(h), append(encodeElem(l), encodeElem(r)...))
+		i /= 2
+	}
+	next.numLeaves++
+	next.roots = make([]*big.Int, len(t.roots))
+	copy(next.roots, t.roots)
+	next.currentRootIndex = (t.currentRootIndex + 1) % len(t.roots)
+	next.roots[next.currentRootIndex] = h
+
+	sb, err := json.Marshal(next.state())
+	if err != nil {
+		b.Rollback()
+		return 0, err
+	}
+	b.Put(stateKey, sb)
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], next.numLeaves)
+	b.Put(sizeKey(h), size[:])
+	if err := b.Commit(); err != nil {
+		return 0, err
+	}
+	*t = next
+	return index, nil
+}
+
+// Snapshot returns a read-only view of the tree at a root it had before,
+// which is not affected by the later insertions, with the paths of its
+// leaves for that root.
+func (t *Tree) Snapshot(root *big.Int) (*Tree, error) {
+	var numLeaves uint64
+	b, err := t.db.Get(sizeKey(root))
+	switch {
+	case err == storage.ErrNotFound && root.Cmp(t.zeros[t.levels]) == 0:
+	case err == storage.ErrNotFound:
+		return nil, fmt.Errorf("unknown root %s", root)
+	case err != nil:
+		return nil, err
+	case len(b) != 8: //nolint:gomnd
+		return nil, fmt.Errorf("invalid size length %d", len(b))
+	default:
+		numLeaves = binary.LittleEndian.Uint64(b)
+	}
+	return &Tree{
+		hasher:    t.hasher,
+		levels:    t.levels,
+		zeros:     t.zeros,
+		db:        t.db,
+		numLeaves: numLeaves,
+		roots:     []*big.Int{new(big.Int).Set(root)},
+		snapshot:  true,
+	}, nil
+}
+
+// IsKnownRoot returns whether the root is one of the roots in the history.
+// The zero root is never known.
+func (t *Tree) IsKnownRoot(root *big.Int) bool {
+	if root.Sign() == 0 {
+		return false
+	}
+	for _, r := range t.roots {
+		if r != nil && r.Cmp(root) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Path is the Merkle path of a leaf, from the leaf up: the siblings and the
+// position of the node in each level, 0 for left and 1 for right.  These
+// are the treeSiblings and treePathIndices of the Semaphore circuit.
+type Path struct {
+	Index       uint64     `json:"index"`
+	Siblings    []*big.Int `json:"siblings"`
+	PathIndices []int      `json:"pathIndices"`
+}
+
+// Path returns the Merkle path of the inserted leaf with the index, for the
+// current root.  It fails if the nodes of the path are not in the storage,
+// for the leaves inserted before the tree was restored with Restore.
+func (t *Tree) Path(index uint64) (*Path, error) {
+	if index >= t.NumLeaves() {
+		return nil, fmt.Errorf("leaf %d not inserted", index)
+	}
+	p := &Path{
+		Index:       index,
+		Siblings:    make([]*big.Int, t.levels),
+		PathIndices: make([]int, t.levels),
+	}
+	// walk down from the root
+	h := t.Root()
+	for lvl := t.levels; lvl >= 1; lvl-- {
+		l, r, err := t.children(lvl, h)
+		if err != nil {
+			return nil, err
+		}
+		bit := int(index >> uint(lvl-1) & 1)
+		p.PathIndices[lvl-1] = bit
+		if bit == 0 {
+			h, p.Siblings[lvl-1] = l, new(big.Int).Set(r)
+		} else {
+			h, p.Siblings[lvl-1] = r, new(big.Int).Set(l)
+		}
+	}
+	return p, nil
+}
+
+// RootFromPath returns the root that the path gives for the leaf.  A nil
+// hasher defaults to PoseidonHasher.
+func RootFromPath(hasher Hasher, p *Path, leaf *big.Int) (*big.Int, error) {
+	if hasher == nil {
+		hasher = PoseidonHasher
+	}
+	if len(p.Siblings) != len(p.PathIndices) {
+		return nil, fmt.Errorf("%d path indices for %d siblings", len(p.PathIndices), len(p.Siblings))
+	}
+	// a value outside the field would give the root of its reduction
+	if leaf.Sign() < 0 || !utils.CheckBigIntInField(leaf) {
+		return nil, fmt.Errorf("leaf must be inside the finite field")
+	}
+	h := leaf
+	for lvl, s := range p.Siblings {
+		if s == nil || s.Sign() < 0 || !utils.CheckBigIntInField(s) {
+			return nil, fmt.Errorf("sibling %d must be inside the finite field", lvl)
+		}
+		if p.PathIndices[lvl] != int(p.Index>>uint(lvl)&1) {
+			return nil, fmt.Errorf("path indices don't match the index %d", p.Index)
+		}
+		var err error
+		if p.PathIndices[lvl] == 0 {
+			h, err = hasher(h, s)
+		} else {
+			h, err = hasher(s, h)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// VerifyPath verifies the path of the leaf against the root.  A nil hasher
+// defaults to PoseidonHasher.
+func VerifyPath(hasher Hasher, root *big.Int, p *Path, leaf *big.Int) bool {
+	r, err := RootFromPath(hasher, p, leaf)
+	return err == nil && r.Cmp(root) == 0
+}
+
+// state is the serialization of a Tree.
+type state struct {
+	Levels           int        `json:"levels"`
+	ZeroLeaf         *big.Int   `json:"zeroLeaf"`
+	NumLeaves        uint64     `json:"numLeaves"`
+	Frontier         []*big.Int `json:"frontier"`
+	Roots            []*big.Int `json:"roots"`
+	CurrentRootIndex int        `json:"currentRootIndex"`
+}
+
+func (t *Tree) state() state {
+	return state{
+		Levels:           t.levels,
+		ZeroLeaf:         t.zeros[0],
+		NumLeaves:        t.numLeaves,
+		Frontier:         t.frontier,
+		Roots:            t.roots,
+		CurrentRootIndex: t.currentRootIndex,
+	}
+}
+
+// MarshalJSON implements the json marshaler for the Tree, with the frontier
+// and the root history, so that it can be restored with Restore.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	if t.snapshot {
+		return nil, ErrSnapshot
+	}
+	return json.Marshal(t.state())
+}
+
+// rootFromFrontier returns the root given by the frontier, or nil if the
+// tree is full and the frontier doesn't have the path of the last leaf.
+func (t *Tree) rootFromFrontier() (*big.Int, error) {
+	if t.numLeaves == 0 {
+		return t.zeros[t.levels], nil
+	}
+	// the frontier has the node of the path of the last leaf at the levels
+	// where it is a left child, and its sibling at the others
+	i := t.numLeaves - 1
+	var h *big.Int
+	for lvl := 0; lvl < t.levels; lvl++ {
+		var err error
+		switch {
+		case i%2 == 1 && h == nil:
+		case i%2 == 1:
+			h, err = t.hasher(t.frontier[lvl], h)
+		case h != nil && h.Cmp(t.frontier[lvl]) != 0:
+			return nil, fmt.Errorf("inconsistent frontier at level %d", lvl)
+		default:
+			h, err = t.hasher(t.frontier[lvl], t.zeros[lvl])
+		}
+		if err != nil {
+			return nil, err
+		}
+		i /= 2
+	}
+	return h, nil
+}
+
+// Restore returns the tree serialized with MarshalJSON, which must have been
+// built with the same hasher, in O(levels).  The nodes are not serialized,
+// so the restored tree only has the paths of the leaves inserted after it
+// was restored.  A nil hasher defaults to PoseidonHasher.
+func Restore(hasher Hasher, b []byte) (*Tree, error) {
+	var s state
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	if s.ZeroLeaf == nil {
+		return nil, fmt.Errorf("missing zero leaf")
+	}
+	t, err := New(hasher, s.Levels, s.ZeroLeaf, len(s.Roots))
+	if err != nil {
+		return nil, err
+	}
+	if err := t.load(&s); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// load sets the state of the empty tree t to s, after checking that it is
+// consistent.
+func (t *Tree) load(s *state) error {
+	if s.NumLeaves > 1<<uint(t.levels) {
+		return fmt.Errorf("%d leaves in a tree of %d levels", s.NumLeaves, t.levels)
+	}
+	if len(s.Frontier) != t.levels {
+		return fmt.Errorf("invalid frontier length %d", len(s.Frontier))
+	}
+	for _, f := range s.Frontier {
+		if !utils.CheckBigIntInField(f) {
+			return fmt.Errorf("frontier must be inside the finite field")
+		}
+	}
+	if s.CurrentRootIndex < 0 || s.CurrentRootIndex >= len(s.Roots) {
+		return fmt.Errorf("invalid current root index %d", s.CurrentRootIndex)
+	}
+	cur := s.Roots[s.CurrentRootIndex]
+	if cur == nil {
+		return fmt.Errorf("missing current root")
+	}
+	t.numLeaves = s.NumLeaves
+	t.frontier = s.Frontier
+	root, err := t.rootFromFrontier()
+	if err != nil {
+		return err
+	}
+	if root != nil && root.Cmp(cur) != 0 {
+		return fmt.Errorf("current root doesn't match the frontier")
+	}
+	copy(t.roots, s.Roots)
+	t.currentRootIndex = s.CurrentRootIndex
+	return nil
+}
@@ -0,0 +1,246 @@
+package incremental
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/merkle/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZeroHashes(t *testing.T) {
+	zeros, err := ZeroHashes(PoseidonHasher, 20, big.NewInt(0))
+	require.Nil(t, err)
+	assert.Equal(t,
+		"21171054475501428039276200107303724910988412040623520127996250430187280327495",
+		zeros[1].String())
+	assert.Equal(t,
+		"4348684721117925129604782273820228245330908989952845154665677189008407794562",
+		zeros[20].String())
+
+	tree, err := New(nil, 20, big.NewInt(0), DefaultRootHistorySize)
+	require.Nil(t, err)
+	assert.Equal(t, zeros[20], tree.Root())
+	assert.True(t, tree.IsKnownRoot(zeros[20]))
+}
+
+func TestInsert(t *testing.T) {
+	tree, err := New(PoseidonHasher, 3, big.NewInt(0), DefaultRootHistorySize)
+	require.Nil(t, err)
+	for i := int64(1); i <= 3; i++ {
+		index, err := tree.Insert(big.NewInt(i))
+		require.Nil(t, err)
+		assert.Equal(t, uint64(i-1), index)
+	}
+	assert.Equal(t,
+		"3663169894268211260471486858836760710628041227090450748142370388536199304749",
+		tree.Root().String())
+	assert.Equal(t, uint64(3), tree.NumLeaves())
+
+	for i := 3; i < 8; i++ {
+		_, err = tree.Insert(big.NewInt(0))
+		require.Nil(t, err)
+	}
+	_, err = tree.Insert(big.NewInt(1))
+	assert.NotNil(t, err)
+
+	_, err = New(nil, 0, big.NewInt(0), 1)
+	assert.NotNil(t, err)
+	_, err = New(nil, MaxLevels+1, big.NewInt(0), 1)
+	assert.NotNil(t, err)
+	_, err = New(nil, 10, big.NewInt(0), 0)
+	assert.NotNil(t, err)
+}
+
+func TestRootHistory(t *testing.T) {
+	tree, err := New(nil, 10, big.NewInt(0), 4)
+	require.Nil(t, err)
+	var roots []*big.Int
+	for i := int64(0); i < 6; i++ {
+		_, err := tree.Insert(big.NewInt(i + 100))
+		require.Nil(t, err)
+		roots = append(roots, tree.Root())
+	}
+	// only the last 4 roots are known
+	assert.False(t, tree.IsKnownRoot(roots[0]))
+	assert.False(t, tree.IsKnownRoot(roots[1]))
+	for _, r := range roots[2:] {
+		assert.True(t, tree.IsKnownRoot(r))
+	}
+	assert.False(t, tree.IsKnownRoot(big.NewInt(0)))
+	assert.False(t, tree.IsKnownRoot(big.NewInt(1)))
+}
+
+func TestPath(t *testing.T) {
+	for _, hasher := range []Hasher{PoseidonHasher, MiMC7Hasher} {
+		tree, err := New(hasher, 5, big.NewInt(7), DefaultRootHistorySize)
+		require.Nil(t, err)
+		for i := int64(0); i < 11; i++ {
+			_, err := tree.Insert(big.NewInt(i * 3))
+			require.Nil(t, err)
+		}
+		root := tree.Root()
+		for i := uint64(0); i < 11; i++ {
+			p, err := tree.Path(i)
+			require.Nil(t, err)
+			leaf := big.NewInt(int64(i) * 3)
+			assert.True(t, VerifyPath(hasher, root, p, leaf))
+			assert.False(t, VerifyPath(hasher, root, p, big.NewInt(1)))
+
+			// the leaf and the siblings must be inside the field, or
+			// they would alias their reduction
+			assert.False(t, VerifyPath(hasher, root, p, new(big.Int).Add(leaf, constants.Q)))
+			s := p.Siblings[2]
+			p.Siblings[2] = new(big.Int).Add(s, constants.Q)
+			assert.False(t, VerifyPath(hasher, root, p, leaf))
+			p.Siblings[2] = s
+
+			p.PathIndices[0] ^= 1
+			assert.False(t, VerifyPath(hasher, root, p, leaf))
+		}
+		_, err = tree.Path(11)
+		assert.NotNil(t, err)
+	}
+
+	// the hashers differ
+	a, err := New(PoseidonHasher, 5, big.NewInt(0), 1)
+	require.Nil(t, err)
+	b, err := New(MiMC7Hasher, 5, big.NewInt(0), 1)
+	require.Nil(t, err)
+	assert.NotEqual(t, a.Root(), b.Root())
+}
+
+func TestRestore(t *testing.T) {
+	tree, err := New(MiMC7Hasher, 8, big.NewInt(0), 3)
+	require.Nil(t, err)
+	var first *big.Int
+	for i := int64(1); i <= 5; i++ {
+		_, err := tree.Insert(big.NewInt(i))
+		require.Nil(t, err)
+		if i == 3 {
+			first = tree.Root()
+		}
+	}
+
+	b, err := json.Marshal(tree)
+	require.Nil(t, err)
+	tree2, err := Restore(MiMC7Hasher, b)
+	require.Nil(t, err)
+	assert.Equal(t, tree.Root(), tree2.Root())
+	assert.True(t, tree2.IsKnownRoot(first))
+
+	// both continue with the same roots
+	_, err = tree.Insert(big.NewInt(6))
+	require.Nil(t, err)
+	_, err = tree2.Insert(big.NewInt(6))
+	require.Nil(t, err)
+	assert.Equal(t, tree.Root(), tree2.Root())
+	assert.False(t, tree2.IsKnownRoot(first))
+
+	// only the frontier is serialized: the restored tree has the paths of
+	// the new leaves only
+	var s state
+	require.Nil(t, json.Unmarshal(b, &s))
+	assert.Len(t, s.Frontier, 8)
+	_, err = tree2.Path(0)
+	assert.NotNil(t, err)
+	p, err := tree2.Path(5)
+	require.Nil(t, err)
+	assert.True(t, VerifyPath(MiMC7Hasher, tree2.Root(), p, big.NewInt(6)))
+
+	// a tampered frontier fails
+	s.Frontier[1] = big.NewInt(1)
+	bad, err := json.Marshal(s)
+	require.Nil(t, err)
+	_, err = Restore(MiMC7Hasher, bad)
+	assert.NotNil(t, err)
+
+	// restoring with another hasher fails
+	_, err = Restore(PoseidonHasher, b)
+	assert.NotNil(t, err)
+	_, err = Restore(MiMC7Hasher, []byte(`{"levels":8,"zeroLeaf":0,"roots":[]}`))
+	assert.NotNil(t, err)
+}
+
+func TestRestoreFull(t *testing.T) {
+	tree, err := New(nil, 2, big.NewInt(0), 2)
+	require.Nil(t, err)
+	for i := int64(0); i < 4; i++ {
+		_, err := tree.Insert(big.NewInt(i + 1))
+		require.Nil(t, err)
+
+		b, err := json.Marshal(tree)
+		require.Nil(t, err)
+		tree2, err := Restore(nil, b)
+		require.Nil(t, err)
+		assert.Equal(t, tree.Root(), tree2.Root())
+		assert.Equal(t, tree.NumLeaves(), tree2.NumLeaves())
+	}
+}
+
+func TestTreeStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "incremental")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "tree")
+
+	zeroLeaf := big.NewInt(7)
+	db, err := storage.OpenFile(path)
+	require.Nil(t, err)
+	tree, err := NewWithStorage(db, nil, 10, zeroLeaf, 4)
+	require.Nil(t, err)
+	for i := int64(0); i < 9; i++ {
+		_, err := tree.Insert(big.NewInt(i + 100))
+		require.Nil(t, err)
+	}
+	snapshot := tree.Root()
+	for i := int64(9); i < 13; i++ {
+		_, err := tree.Insert(big.NewInt(i + 100))
+		require.Nil(t, err)
+	}
+	root := tree.Root()
+	require.Nil(t, db.Close())
+
+	db, err = storage.OpenFile(path)
+	require.Nil(t, err)
+	defer db.Close() //nolint:errcheck
+	_, err = NewWithStorage(db, nil, 10, big.NewInt(0), 4)
+	assert.NotNil(t, err)
+	_, err = NewWithStorage(db, nil, 10, zeroLeaf, 5)
+	assert.NotNil(t, err)
+	tree, err = NewWithStorage(db, nil, 10, zeroLeaf, 4)
+	require.Nil(t, err)
+	assert.Equal(t, root, tree.Root())
+	assert.Equal(t, uint64(13), tree.NumLeaves())
+	assert.False(t, tree.IsKnownRoot(snapshot))
+	for i := uint64(0); i < 13; i++ {
+		p, err := tree.Path(i)
+		require.Nil(t, err)
+		assert.True(t, VerifyPath(nil, root, p, big.NewInt(int64(i)+100)))
+	}
+
+	// a snapshot of the tree with 9 leaves
+	snap, err := tree.Snapshot(snapshot)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(9), snap.NumLeaves())
+	p, err := snap.Path(8)
+	require.Nil(t, err)
+	assert.True(t, VerifyPath(nil, snapshot, p, big.NewInt(108)))
+	_, err = snap.Path(9)
+	assert.NotNil(t, err)
+	_, err = snap.Insert(big.NewInt(1))
+	assert.Equal(t, ErrSnapshot, err)
+
+	// the tree continues after reopening
+	_, err = tree.Insert(big.NewInt(113))
+	require.Nil(t, err)
+	assert.True(t, tree.IsKnownRoot(root))
+	_, err = tree.Snapshot(big.NewInt(1))
+	assert.NotNil(t, err)
+}
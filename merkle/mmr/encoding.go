@@ -0,0 +1,137 @@
+package mmr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// encodeElems returns the elements as decimal strings.
+func encodeElems(elems ...[]*big.Int) []string {
+	var s []string
+	for _, es := range elems {
+		for _, e := range es {
+			s = append(s, e.String())
+		}
+	}
+	return s
+}
+
+// decodeElems parses the decimal strings, which must be field elements.
+func decodeElems(s []string) ([]*big.Int, error) {
+	elems := make([]*big.Int, len(s))
+	for i, e := range s {
+		v, ok := new(big.Int).SetString(e, 10)
+		if !ok || !inField(v) {
+			return nil, fmt.Errorf("invalid element %q", e)
+		}
+		elems[i] = v
+	}
+	return elems, nil
+}
+
+// decodeSize returns the element as a size or index.
+func decodeSize(e *big.Int) (uint64, error) {
+	if !e.IsUint64() {
+		return 0, fmt.Errorf("invalid size %s", e)
+	}
+	return e.Uint64(), nil
+}
+
+// MarshalJSON implements the json marshaler for the Proof, as the array of
+// the decimal field elements [index, size, siblings..., peaks...], the
+// input of circuits.
+func (p Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encodeElems(
+		[]*big.Int{new(big.Int).SetUint64(p.Index), new(big.Int).SetUint64(p.Size)},
+		p.Siblings, p.Peaks))
+}
+
+// UnmarshalJSON implements the json unmarshaler for the Proof
+func (p *Proof) UnmarshalJSON(b []byte) error {
+	var s []string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	elems, err := decodeElems(s)
+	if err != nil {
+		return err
+	}
+	if len(elems) < 2 { //nolint:gomnd
+		return fmt.Errorf("invalid proof length")
+	}
+	var p2 Proof
+	if p2.Index, err = decodeSize(elems[0]); err != nil {
+		return err
+	}
+	if p2.Size, err = decodeSize(elems[1]); err != nil {
+		return err
+	}
+	if p2.Index >= p2.Size {
+		return fmt.Errorf("element %d not in an MMR of %d elements", p2.Index, p2.Size)
+	}
+	ms := mountains(p2.Size)
+	height := int(ms[mountainOf(ms, p2.Index)].height)
+	if len(elems) != 2+height+len(ms) {
+		return fmt.Errorf("invalid proof length")
+	}
+	p2.Siblings = elems[2 : 2+height]
+	p2.Peaks = elems[2+height:]
+	*p = p2
+	return nil
+}
+
+// MarshalJSON implements the json marshaler for the ConsistencyProof, as
+// the array of the decimal field elements [old size, new size, old
+// peaks..., new peaks..., paths...].
+func (p ConsistencyProof) MarshalJSON() ([]byte, error) {
+	elems := [][]*big.Int{
+		{new(big.Int).SetUint64(p.OldSize), new(big.Int).SetUint64(p.NewSize)},
+		p.OldPeaks, p.NewPeaks,
+	}
+	return json.Marshal(encodeElems(append(elems, p.Paths...)...))
+}
+
+// UnmarshalJSON implements the json unmarshaler for the ConsistencyProof
+func (p *ConsistencyProof) UnmarshalJSON(b []byte) error {
+	var s []string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	elems, err := decodeElems(s)
+	if err != nil {
+		return err
+	}
+	if len(elems) < 2 { //nolint:gomnd
+		return fmt.Errorf("invalid proof length")
+	}
+	var p2 ConsistencyProof
+	if p2.OldSize, err = decodeSize(elems[0]); err != nil {
+		return err
+	}
+	if p2.NewSize, err = decodeSize(elems[1]); err != nil {
+		return err
+	}
+	if p2.OldSize > p2.NewSize {
+		return fmt.Errorf("old size %d larger than new size %d", p2.OldSize, p2.NewSize)
+	}
+	oldMs, newMs := mountains(p2.OldSize), mountains(p2.NewSize)
+	lengths := make([]int, len(oldMs))
+	n := 2 + len(oldMs) + len(newMs)
+	for k, mt := range oldMs {
+		lengths[k] = int(newMs[mountainOf(newMs, mt.offset)].height - mt.height)
+		n += lengths[k]
+	}
+	if len(elems) != n {
+		return fmt.Errorf("invalid proof length")
+	}
+	elems = elems[2:]
+	p2.OldPeaks, elems = elems[:len(oldMs)], elems[len(oldMs):]
+	p2.NewPeaks, elems = elems[:len(newMs)], elems[len(newMs):]
+	p2.Paths = make([][]*big.Int, len(oldMs))
+	for k, l := range lengths {
+		p2.Paths[k], elems = elems[:l], elems[l:]
+	}
+	*p = p2
+	return nil
+}
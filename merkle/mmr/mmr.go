@@ -0,0 +1,312 @@
+// Package mmr implements a Merkle mountain range, an append-only accumulator
+// with inclusion proofs of any of its elements and consistency proofs between
+// two of its sizes, hashed with Poseidon.
+//
+// The leaves are Poseidon(element) and the nodes Poseidon(left, right).  A
+// range of n leaves is the list of perfect binary trees, the mountains, given
+// by the bits of n from the most significant one, and their roots are the
+// peaks.  The root of the range is Poseidon(n, bag), where bag is the fold of
+// the peaks from the right, Poseidon(peak, bag), starting with the last one.
+// The root of the empty range is 0.
+package mmr
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+func hash(inputs ...*big.Int) (*big.Int, error) {
+	return poseidon.Hash(inputs)
+}
+
+// inField checks that the elements are inside the finite field, as the
+// hash would take any other value for its reduction.
+func inField(elems ...*big.Int) bool {
+	for _, e := range elems {
+		if e == nil || e.Sign() < 0 || !utils.CheckBigIntInField(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// mountain is a perfect binary tree of the range, with its leaves from
+// offset.
+type mountain struct {
+	height uint
+	offset uint64
+}
+
+// mountains returns the mountains of a range of n leaves, from the left.
+func mountains(n uint64) []mountain {
+	var ms []mountain
+	var offset uint64
+	for h := 63; h >= 0; h-- {
+		if n&(1<<uint(h)) != 0 {
+			ms = append(ms, mountain{height: uint(h), offset: offset})
+			offset += 1 << uint(h)
+		}
+	}
+	return ms
+}
+
+// mountainOf returns the position in ms of the mountain with the leaf i.
+func mountainOf(ms []mountain, i uint64) int {
+	for k, m := range ms {
+		if i < m.offset+1<<m.height {
+			return k
+		}
+	}
+	return -1
+}
+
+// bag returns the root of a range of n leaves with the peaks.
+func bag(n uint64, peaks []*big.Int) (*big.Int, error) {
+	if n == 0 {
+		return big.NewInt(0), nil
+	}
+	acc := peaks[len(peaks)-1]
+	for k := len(peaks) - 2; k >= 0; k-- {
+		var err error
+		if acc, err = hash(peaks[k], acc); err != nil {
+			return nil, err
+		}
+	}
+	return hash(new(big.Int).SetUint64(n), acc)
+}
+
+// climb returns the root of the subtree that has the node h with the index
+// in its level, given the siblings from the node up.
+func climb(h *big.Int, index uint64, siblings []*big.Int) (*big.Int, error) {
+	for _, s := range siblings {
+		var err error
+		if index&1 == 0 {
+			h, err = hash(h, s)
+		} else {
+			h, err = hash(s, h)
+		}
+		if err != nil {
+			return nil, err
+		}
+		index >>= 1
+	}
+	return h, nil
+}
+
+// MMR is a Merkle mountain range.
+type MMR struct {
+	// levels are the nodes of each height, from the leaves up: the node j
+	// of levels[h] is the root of the leaves from j*2^h to (j+1)*2^h
+	levels [][]*big.Int
+}
+
+// New returns an empty MMR.
+func New() *MMR {
+	return &MMR{}
+}
+
+// Size returns the number of elements of the MMR.
+func (m *MMR) Size() uint64 {
+	if len(m.levels) == 0 {
+		return 0
+	}
+	return uint64(len(m.levels[0]))
+}
+
+// Append appends the element to the MMR and returns its index.
+func (m *MMR) Append(element *big.Int) (uint64, error) {
+	if !inField(element) {
+		return 0, fmt.Errorf("element must be inside the finite field")
+	}
+	h, err := hash(element)
+	if err != nil {
+		return 0, err
+	}
+	index := m.Size()
+	for lvl := 0; ; lvl++ {
+		if lvl == len(m.levels) {
+			m.levels = append(m.levels, nil)
+		}
+		m.levels[lvl] = append(m.levels[lvl], h)
+		n := len(m.levels[lvl])
+		if n%2 == 1 {
+			break
+		}
+		if h, err = hash(m.levels[lvl][n-2], m.levels[lvl][n-1]); err != nil {
+			return 0, err
+		}
+	}
+	return index, nil
+}
+
+// peaks returns the peaks of the MMR when it had n elements.
+func (m *MMR) peaks(n uint64) []*big.Int {
+	ms := mountains(n)
+	peaks := make([]*big.Int, len(ms))
+	for k, mt := range ms {
+		peaks[k] = new(big.Int).Set(m.levels[mt.height][mt.offset>>mt.height])
+	}
+	return peaks
+}
+
+// siblings returns the siblings of the node with the index at level, up to
+// the given height.
+func (m *MMR) siblings(index uint64, level, height uint) []*big.Int {
+	siblings := make([]*big.Int, 0, height-level)
+	for ; level < height; level++ {
+		siblings = append(siblings, new(big.Int).Set(m.levels[level][index^1]))
+		index >>= 1
+	}
+	return siblings
+}
+
+// Root returns the root of the MMR.
+func (m *MMR) Root() (*big.Int, error) {
+	return m.RootAt(m.Size())
+}
+
+// RootAt returns the root that the MMR had with n elements.
+func (m *MMR) RootAt(n uint64) (*big.Int, error) {
+	if n > m.Size() {
+		return nil, fmt.Errorf("size %d larger than the MMR", n)
+	}
+	return bag(n, m.peaks(n))
+}
+
+// Proof is the inclusion proof of an element in an MMR of Size elements:
+// the siblings from its leaf up to the peak of its mountain, and the peaks.
+type Proof struct {
+	Index    uint64
+	Size     uint64
+	Siblings []*big.Int
+	Peaks    []*big.Int
+}
+
+// Prove returns the inclusion proof of the element with the index in the
+// MMR.
+func (m *MMR) Prove(index uint64) (*Proof, error) {
+	return m.ProveAt(index, m.Size())
+}
+
+// ProveAt returns the inclusion proof of the element with the index in the
+// MMR when it had n elements, to be verified against RootAt(n).
+func (m *MMR) ProveAt(index, n uint64) (*Proof, error) {
+	if n > m.Size() {
+		return nil, fmt.Errorf("size %d larger than the MMR", n)
+	}
+	if index >= n {
+		return nil, fmt.Errorf("element %d not in an MMR of %d elements", index, n)
+	}
+	ms := mountains(n)
+	mt := ms[mountainOf(ms, index)]
+	return &Proof{
+		Index:    index,
+		Size:     n,
+		Siblings: m.siblings(index, 0, mt.height),
+		Peaks:    m.peaks(n),
+	}, nil
+}
+
+// check checks that the proof has the shape given by its index and size,
+// and returns the position of the mountain of the element.
+func (p *Proof) check() (int, error) {
+	if p.Index >= p.Size {
+		return 0, fmt.Errorf("element %d not in an MMR of %d elements", p.Index, p.Size)
+	}
+	ms := mountains(p.Size)
+	k := mountainOf(ms, p.Index)
+	if len(p.Peaks) != len(ms) || len(p.Siblings) != int(ms[k].height) {
+		return 0, fmt.Errorf("invalid proof length")
+	}
+	if !inField(p.Siblings...) || !inField(p.Peaks...) {
+		return 0, fmt.Errorf("proof elements must be inside the finite field")
+	}
+	return k, nil
+}
+
+// Verify verifies the inclusion proof of the element against the root.
+func Verify(root *big.Int, p *Proof, element *big.Int) bool {
+	k, err := p.check()
+	if err != nil || !inField(element) {
+		return false
+	}
+	h, err := hash(element)
+	if err != nil {
+		return false
+	}
+	if h, err = climb(h, p.Index, p.Siblings); err != nil || h.Cmp(p.Peaks[k]) != 0 {
+		return false
+	}
+	r, err := bag(p.Size, p.Peaks)
+	return err == nil && r.Cmp(root) == 0
+}
+
+// ConsistencyProof proves that an MMR of NewSize elements is an extension
+// of the MMR of OldSize elements: it has the peaks of both, and the siblings
+// from each old peak up to the new peak of its mountain.
+type ConsistencyProof struct {
+	OldSize  uint64
+	NewSize  uint64
+	OldPeaks []*big.Int
+	NewPeaks []*big.Int
+	Paths    [][]*big.Int
+}
+
+// ProveConsistency returns the consistency proof between the MMR when it had
+// oldSize elements and the MMR now.
+func (m *MMR) ProveConsistency(oldSize uint64) (*ConsistencyProof, error) {
+	n := m.Size()
+	if oldSize > n {
+		return nil, fmt.Errorf("size %d larger than the MMR", oldSize)
+	}
+	newMs := mountains(n)
+	oldMs := mountains(oldSize)
+	p := &ConsistencyProof{
+		OldSize:  oldSize,
+		NewSize:  n,
+		OldPeaks: m.peaks(oldSize),
+		NewPeaks: m.peaks(n),
+		Paths:    make([][]*big.Int, len(oldMs)),
+	}
+	for k, mt := range oldMs {
+		height := newMs[mountainOf(newMs, mt.offset)].height
+		p.Paths[k] = m.siblings(mt.offset>>mt.height, mt.height, height)
+	}
+	return p, nil
+}
+
+// VerifyConsistency verifies the consistency proof between the old and the
+// new roots.
+func VerifyConsistency(oldRoot, newRoot *big.Int, p *ConsistencyProof) bool {
+	if p.OldSize > p.NewSize {
+		return false
+	}
+	newMs := mountains(p.NewSize)
+	oldMs := mountains(p.OldSize)
+	if len(p.OldPeaks) != len(oldMs) || len(p.NewPeaks) != len(newMs) ||
+		len(p.Paths) != len(oldMs) {
+		return false
+	}
+	if !inField(p.OldPeaks...) || !inField(p.NewPeaks...) {
+		return false
+	}
+	for k, mt := range oldMs {
+		l := mountainOf(newMs, mt.offset)
+		if len(p.Paths[k]) != int(newMs[l].height-mt.height) || !inField(p.Paths[k]...) {
+			return false
+		}
+		h, err := climb(p.OldPeaks[k], mt.offset>>mt.height, p.Paths[k])
+		if err != nil || h.Cmp(p.NewPeaks[l]) != 0 {
+			return false
+		}
+	}
+	r, err := bag(p.OldSize, p.OldPeaks)
+	if err != nil || r.Cmp(oldRoot) != 0 {
+		return false
+	}
+	r, err = bag(p.NewSize, p.NewPeaks)
+	return err == nil && r.Cmp(newRoot) == 0
+}
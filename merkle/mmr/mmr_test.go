@@ -0,0 +1,174 @@
+package mmr
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMMR(t *testing.T, n int) *MMR {
+	m := New()
+	for i := 0; i < n; i++ {
+		index, err := m.Append(big.NewInt(int64(i + 1)))
+		require.Nil(t, err)
+		assert.Equal(t, uint64(i), index)
+	}
+	return m
+}
+
+func TestRoot(t *testing.T) {
+	m := New()
+	root, err := m.Root()
+	require.Nil(t, err)
+	assert.Equal(t, "0", root.String())
+
+	m = newMMR(t, 3)
+	root, err = m.Root()
+	require.Nil(t, err)
+	assert.Equal(t,
+		"17818658353940250024761947525703901159188757987230393087696410700946719201646",
+		root.String())
+	root, err = m.RootAt(1)
+	require.Nil(t, err)
+	assert.Equal(t,
+		"460994364578180821311636537602727517048605927022615728646020367821512331978",
+		root.String())
+	_, err = m.RootAt(4)
+	assert.NotNil(t, err)
+
+	_, err = m.Append(constants.Q)
+	assert.NotNil(t, err)
+}
+
+func TestProve(t *testing.T) {
+	m := newMMR(t, 23)
+	root, err := m.Root()
+	require.Nil(t, err)
+	for i := uint64(0); i < 23; i++ {
+		element := big.NewInt(int64(i + 1))
+		p, err := m.Prove(i)
+		require.Nil(t, err)
+		assert.True(t, Verify(root, p, element))
+		assert.False(t, Verify(root, p, big.NewInt(100)))
+
+		b, err := json.Marshal(p)
+		require.Nil(t, err)
+		var p2 Proof
+		require.Nil(t, json.Unmarshal(b, &p2))
+		assert.Equal(t, p, &p2)
+		assert.True(t, Verify(root, &p2, element))
+
+		// the proof of a past element against a past root
+		old, err := m.RootAt(i + 1)
+		require.Nil(t, err)
+		p, err = m.ProveAt(i, i+1)
+		require.Nil(t, err)
+		assert.True(t, Verify(old, p, element))
+	}
+
+	p, err := m.Prove(5)
+	require.Nil(t, err)
+	p.Index = 4
+	assert.False(t, Verify(root, p, big.NewInt(6)))
+	p.Index = 23
+	assert.False(t, Verify(root, p, big.NewInt(6)))
+	_, err = m.Prove(23)
+	assert.NotNil(t, err)
+
+	// the element, siblings and peaks must be inside the field, or they
+	// would alias their reduction
+	p, err = m.Prove(5)
+	require.Nil(t, err)
+	assert.True(t, Verify(root, p, big.NewInt(6)))
+	assert.False(t, Verify(root, p, new(big.Int).Add(big.NewInt(6), constants.Q)))
+	assert.False(t, Verify(root, p, big.NewInt(-1)))
+	p.Siblings[0] = new(big.Int).Add(p.Siblings[0], constants.Q)
+	assert.False(t, Verify(root, p, big.NewInt(6)))
+	p.Siblings[0].Sub(p.Siblings[0], constants.Q)
+	p.Peaks[0] = new(big.Int).Add(p.Peaks[0], constants.Q)
+	assert.False(t, Verify(root, p, big.NewInt(6)))
+
+	// decimal field elements: [index, size, siblings..., peaks...]
+	m = newMMR(t, 3)
+	p, err = m.Prove(2)
+	require.Nil(t, err)
+	b, err := json.Marshal(p)
+	require.Nil(t, err)
+	var elems []string
+	require.Nil(t, json.Unmarshal(b, &elems))
+	assert.Len(t, elems, 4)
+	assert.Equal(t, []string{"2", "3"}, elems[:2])
+	var p2 Proof
+	assert.NotNil(t, json.Unmarshal([]byte(`["2","3","1"]`), &p2))
+	assert.NotNil(t, json.Unmarshal([]byte(`["3","3","1","1"]`), &p2))
+	assert.NotNil(t, json.Unmarshal([]byte(`["2","3","x","1"]`), &p2))
+	elems[2] = new(big.Int).Add(p.Peaks[0], constants.Q).String()
+	b, err = json.Marshal(elems)
+	require.Nil(t, err)
+	assert.NotNil(t, json.Unmarshal(b, &p2))
+}
+
+func TestProveConsistency(t *testing.T) {
+	m := newMMR(t, 37)
+	root, err := m.Root()
+	require.Nil(t, err)
+	for oldSize := uint64(0); oldSize <= 37; oldSize++ {
+		oldRoot, err := m.RootAt(oldSize)
+		require.Nil(t, err)
+		p, err := m.ProveConsistency(oldSize)
+		require.Nil(t, err)
+		assert.True(t, VerifyConsistency(oldRoot, root, p))
+
+		b, err := json.Marshal(p)
+		require.Nil(t, err)
+		var p2 ConsistencyProof
+		require.Nil(t, json.Unmarshal(b, &p2))
+		assert.True(t, VerifyConsistency(oldRoot, root, &p2))
+
+		if oldSize > 0 {
+			// not consistent with another old root
+			other, err := newMMR(t, 37).RootAt(oldSize - 1)
+			require.Nil(t, err)
+			assert.False(t, VerifyConsistency(other, root, p))
+		}
+	}
+
+	// a range that rewrote an element is not consistent
+	forked := newMMR(t, 10)
+	_, err = forked.Append(big.NewInt(100))
+	require.Nil(t, err)
+	for i := 12; i <= 37; i++ {
+		_, err = forked.Append(big.NewInt(int64(i)))
+		require.Nil(t, err)
+	}
+	forkedRoot, err := forked.Root()
+	require.Nil(t, err)
+	oldRoot, err := m.RootAt(16)
+	require.Nil(t, err)
+	p, err := forked.ProveConsistency(16)
+	require.Nil(t, err)
+	assert.False(t, VerifyConsistency(oldRoot, forkedRoot, p))
+
+	// the peaks and paths must be inside the field
+	p, err = m.ProveConsistency(16)
+	require.Nil(t, err)
+	assert.True(t, VerifyConsistency(oldRoot, root, p))
+	p.Paths[0][0] = new(big.Int).Add(p.Paths[0][0], constants.Q)
+	assert.False(t, VerifyConsistency(oldRoot, root, p))
+	p.Paths[0][0].Sub(p.Paths[0][0], constants.Q)
+	p.OldPeaks[0] = new(big.Int).Add(p.OldPeaks[0], constants.Q)
+	assert.False(t, VerifyConsistency(oldRoot, root, p))
+	p.OldPeaks[0].Sub(p.OldPeaks[0], constants.Q)
+	p.NewPeaks[0] = new(big.Int).Add(p.NewPeaks[0], constants.Q)
+	assert.False(t, VerifyConsistency(oldRoot, root, p))
+
+	_, err = m.ProveConsistency(38)
+	assert.NotNil(t, err)
+	var p2 ConsistencyProof
+	assert.NotNil(t, json.Unmarshal([]byte(`["3","2"]`), &p2))
+	assert.NotNil(t, json.Unmarshal([]byte(`["1","2","1"]`), &p2))
+}
@@ -0,0 +1,375 @@
+// Package quad implements the 4-ary Merkle trees of Loopring's account and
+// balance trees, whose nodes are the Poseidon hash of their four children,
+// poseidon.Hash with width t=5.
+//
+// The leaves are addressed by their index, whose base-4 digits from the
+// least significant one give the position of the node at each level from the
+// leaves up.  A tree starts with all its leaves set to a default leaf, so its
+// empty root is the default leaf hashed depth times with itself as the four
+// children, as Loopring's SparseMerkleTree computes it.
+package quad
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/merkle/storage"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// Arity is the number of children of a node.
+const Arity = 4
+
+// elemBytesLen is the length of the encoding of a field element in the
+// storage.
+const elemBytesLen = 32
+
+// MaxDepth is the maximum depth of a tree, so that the indexes of the leaves
+// fit in an uint64.
+const MaxDepth = 32
+
+// HashNode returns the hash of a node with the given children.
+func HashNode(children [Arity]*big.Int) (*big.Int, error) {
+	return poseidon.Hash(children[:])
+}
+
+// EmptyRoots returns the roots of the trees of depth 0 to depth whose leaves
+// are all emptyLeaf.
+func EmptyRoots(depth int, emptyLeaf *big.Int) ([]*big.Int, error) {
+	roots := make([]*big.Int, depth+1)
+	roots[0] = new(big.Int).Set(emptyLeaf)
+	for i := 1; i <= depth; i++ {
+		r := roots[i-1]
+		h, err := HashNode([Arity]*big.Int{r, r, r, r})
+		if err != nil {
+			return nil, err
+		}
+		roots[i] = h
+	}
+	return roots, nil
+}
+
+// ErrSnapshot is returned when modifying a snapshot.
+var ErrSnapshot = errors.New("snapshots are read-only")
+
+// rootKey is the key of the current root in the storage, which can't clash
+// with the 32 bytes keys of the nodes.
+var rootKey = []byte("root")
+
+// Tree is a 4-ary Poseidon Merkle tree.  Its nodes are kept in a
+// storage.Storage by hash, with their four children, and never removed, so a
+// tree can be persisted and reopened with a file storage, and any of its
+// previous roots can be opened as a snapshot.  The empty nodes are not
+// stored.
+type Tree struct {
+	depth int
+	// zeros are the empty nodes of each level, from the leaves up
+	zeros    []*big.Int
+	db       storage.Storage
+	root     *big.Int
+	snapshot bool
+}
+
+// New returns a tree in memory of the given depth with all the leaves set to
+// emptyLeaf.
+func New(depth int, emptyLeaf *big.Int) (*Tree, error) {
+	return NewWithStorage(storage.NewMemory(), depth, emptyLeaf)
+}
+
+// NewWithStorage returns the tree in the storage, which has all the leaves
+// set to emptyLeaf if the storage is new, with the given depth.
+func NewWithStorage(db storage.Storage, depth int, emptyLeaf *big.Int) (*Tree, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, fmt.Errorf("depth must be between 1 and %d", MaxDepth)
+	}
+	if !utils.CheckBigIntInField(emptyLeaf) {
+		return nil, fmt.Errorf("empty leaf must be inside the finite field")
+	}
+	zeros, err := EmptyRoots(depth, emptyLeaf)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{depth: depth, zeros: zeros, db: db, root: zeros[depth]}
+	b, err := db.Get(rootKey)
+	if err == nil {
+		t.root = decodeElem(b)
+	} else if err != storage.ErrNotFound {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NewFromLeaves returns a tree in memory of the given depth with the leaves
+// from index 0, and the rest of the leaves set to emptyLeaf.
+func NewFromLeaves(depth int, emptyLeaf *big.Int, leaves []*big.Int) (*Tree, error) {
+	t, err := New(depth, emptyLeaf)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaves) > 0 {
+		if err := t.checkIndex(uint64(len(leaves) - 1)); err != nil {
+			return nil, err
+		}
+	}
+	nodes := make(map[uint64]*big.Int)
+	for i, l := range leaves {
+		if !utils.CheckBigIntInField(l) {
+			return nil, fmt.Errorf("leaf %d must be inside the finite field", i)
+		}
+		if l.Cmp(t.zeros[0]) != 0 {
+			nodes[uint64(i)] = l
+		}
+	}
+	// hash each level once, instead of each path
+	err = t.write(func(b storage.Batch) (*big.Int, error) {
+		for lvl := 1; lvl <= depth; lvl++ {
+			parents := make(map[uint64]*big.Int)
+			for i := range nodes {
+				if _, ok := parents[i/Arity]; ok {
+					continue
+				}
+				var children [Arity]*big.Int
+				for j := range children {
+					c, ok := nodes[i/Arity*Arity+uint64(j)]
+					if !ok {
+						c = t.zeros[lvl-1]
+					}
+					children[j] = c
+				}
+				h, err := t.put(b, lvl, children)
+				if err != nil {
+					return nil, err
+				}
+				parents[i/Arity] = h
+			}
+			nodes = parents
+		}
+		if root, ok := nodes[0]; ok {
+			return root, nil
+		}
+		return t.zeros[depth], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int { return t.depth }
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *big.Int { return new(big.Int).Set(t.root) }
+
+// Snapshot returns a read-only view of the tree at a root it had before,
+// which is not affected by the later changes of the tree.
+func (t *Tree) Snapshot(root *big.Int) (*Tree, error) {
+	if _, err := t.children(t.depth, root); err != nil {
+		return nil, fmt.Errorf("unknown root %s", root)
+	}
+	return &Tree{
+		depth:    t.depth,
+		zeros:    t.zeros,
+		db:       t.db,
+		root:     new(big.Int).Set(root),
+		snapshot: true,
+	}, nil
+}
+
+// write runs the update f in a batch, which is committed with the new root
+// returned by f, or rolled back if f fails.
+func (t *Tree) write(f func(b storage.Batch) (*big.Int, error)) error {
+	if t.snapshot {
+		return ErrSnapshot
+	}
+	b := t.db.Batch()
+	root, err := f(b)
+	if err != nil {
+		b.Rollback()
+		return err
+	}
+	b.Put(rootKey, encodeElem(root))
+	if err := b.Commit(); err != nil {
+		return err
+	}
+	t.root = new(big.Int).Set(root)
+	return nil
+}
+
+func (t *Tree) checkIndex(index uint64) error {
+	if t.depth < MaxDepth && index>>(2*uint(t.depth)) != 0 {
+		return fmt.Errorf("index %d out of a tree of depth %d", index, t.depth)
+	}
+	return nil
+}
+
+func encodeElem(e *big.Int) []byte {
+	b := utils.BigIntLEBytes(e)
+	return b[:]
+}
+
+func decodeElem(b []byte) *big.Int {
+	return utils.SetBigIntFromLEBytes(new(big.Int), b)
+}
+
+// children returns the children of the node with hash h of the level.
+func (t *Tree) children(lvl int, h *big.Int) ([Arity]*big.Int, error) {
+	var children [Arity]*big.Int
+	if h.Cmp(t.zeros[lvl]) == 0 {
+		for j := range children {
+			children[j] = t.zeros[lvl-1]
+		}
+		return children, nil
+	}
+	b, err := t.db.Get(encodeElem(h))
+	if err == storage.ErrNotFound {
+		return children, fmt.Errorf("node %s not found", h)
+	} else if err != nil {
+		return children, err
+	}
+	if len(b) != Arity*elemBytesLen {
+		return children, fmt.Errorf("invalid node length %d", len(b))
+	}
+	for j := range children {
+		children[j] = decodeElem(b[j*elemBytesLen : (j+1)*elemBytesLen])
+	}
+	return children, nil
+}
+
+// put writes the node of the level with the children in the batch, unless
+// it is empty, and returns its hash.
+func (t *Tree) put(b storage.Batch, lvl int, children [Arity]*big.Int) (*big.Int, error) {
+	h, err := HashNode(children)
+	if err != nil {
+		return nil, err
+	}
+	if h.Cmp(t.zeros[lvl]) != 0 {
+		v := make([]byte, 0, Arity*elemBytesLen)
+		for _, c := range children {
+			v = append(v, encodeElem(c)...)
+		}
+		b.Put(encodeElem(h), v)
+	}
+	return h, nil
+}
+
+// walk returns the children of the nodes in the path of the leaf with the
+// index, from the leaves up: path[lvl] are the children of the node of the
+// level lvl+1.
+func (t *Tree) walk(index uint64) ([][Arity]*big.Int, error) {
+	if err := t.checkIndex(index); err != nil {
+		return nil, err
+	}
+	path := make([][Arity]*big.Int, t.depth)
+	h := t.root
+	for lvl := t.depth; lvl >= 1; lvl-- {
+		children, err := t.children(lvl, h)
+		if err != nil {
+			return nil, err
+		}
+		path[lvl-1] = children
+		h = children[index>>(2*uint(lvl-1))%Arity]
+	}
+	return path, nil
+}
+
+// Get returns the leaf with the index.
+func (t *Tree) Get(index uint64) (*big.Int, error) {
+	path, err := t.walk(index)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(path[0][index%Arity]), nil
+}
+
+// Update sets the leaf with the index and updates its path up to the root.
+func (t *Tree) Update(index uint64, leaf *big.Int) error {
+	if !utils.CheckBigIntInField(leaf) {
+		return fmt.Errorf("leaf must be inside the finite field")
+	}
+	path, err := t.walk(index)
+	if err != nil {
+		return err
+	}
+	return t.write(func(b storage.Batch) (*big.Int, error) {
+		h := new(big.Int).Set(leaf)
+		for lvl := 1; lvl <= t.depth; lvl++ {
+			children := path[lvl-1]
+			children[index%Arity] = h
+			var err error
+			if h, err = t.put(b, lvl, children); err != nil {
+				return nil, err
+			}
+			index /= Arity
+		}
+		return h, nil
+	})
+}
+
+// Proof is the Merkle proof of a leaf: the index of the leaf and, for each
+// level from the leaves up, the other three children of the node in the path
+// in their order.
+type Proof struct {
+	Index    uint64                `json:"index"`
+	Siblings [][Arity - 1]*big.Int `json:"siblings"`
+}
+
+// GenerateProof returns the Merkle proof of the leaf with the index.
+func (t *Tree) GenerateProof(index uint64) (*Proof, error) {
+	path, err := t.walk(index)
+	if err != nil {
+		return nil, err
+	}
+	p := &Proof{Index: index, Siblings: make([][Arity - 1]*big.Int, t.depth)}
+	for lvl, children := range path {
+		pos := int(index % Arity)
+		copy(p.Siblings[lvl][:pos], children[:pos])
+		copy(p.Siblings[lvl][pos:], children[pos+1:])
+		index /= Arity
+	}
+	return p, nil
+}
+
+// RootFromProof returns the root that the proof gives for the leaf.
+func RootFromProof(p *Proof, leaf *big.Int) (*big.Int, error) {
+	if len(p.Siblings) < 1 || len(p.Siblings) > MaxDepth {
+		return nil, fmt.Errorf("proof depth must be between 1 and %d", MaxDepth)
+	}
+	if len(p.Siblings) < MaxDepth && p.Index>>(2*uint(len(p.Siblings))) != 0 {
+		return nil, fmt.Errorf("index %d out of a tree of depth %d", p.Index, len(p.Siblings))
+	}
+	// a value outside the field would give the root of its reduction
+	if leaf.Sign() < 0 || !utils.CheckBigIntInField(leaf) {
+		return nil, fmt.Errorf("leaf must be inside the finite field")
+	}
+	for lvl, siblings := range p.Siblings {
+		for _, s := range siblings {
+			if s == nil || s.Sign() < 0 || !utils.CheckBigIntInField(s) {
+				return nil, fmt.Errorf("sibling of level %d must be inside the finite field", lvl)
+			}
+		}
+	}
+	h := leaf
+	index := p.Index
+	for _, siblings := range p.Siblings {
+		pos := int(index % Arity)
+		var children [Arity]*big.Int
+		copy(children[:pos], siblings[:pos])
+		children[pos] = h
+		copy(children[pos+1:], siblings[pos:])
+		var err error
+		if h, err = HashNode(children); err != nil {
+			return nil, err
+		}
+		index /= Arity
+	}
+	return h, nil
+}
+
+// VerifyProof verifies the proof of the leaf against the root.
+func VerifyProof(root *big.Int, p *Proof, leaf *big.Int) bool {
+	r, err := RootFromProof(p, leaf)
+	return err == nil && r.Cmp(root) == 0
+}
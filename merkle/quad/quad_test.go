@@ -0,0 +1,254 @@
+package quad
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/merkle/storage"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The empty roots are computed as Loopring's SparseMerkleTree.newTree does
+// in packages/loopring_v3/operator/sparse_merkle_tree.py, hashing the
+// default leaf depth times with Poseidon over the parameters of ethsnarks'
+// poseidon_params(SNARK_SCALAR_FIELD, 5, 6, 52, b'poseidon', 5), and checked
+// against an independent implementation of ethsnarks' poseidon.
+func TestEmptyRoots(t *testing.T) {
+	roots, err := EmptyRoots(24, big.NewInt(0))
+	require.Nil(t, err)
+	assert.Equal(t, "0", roots[0].String())
+	assert.Equal(t,
+		"18298609842015643040044099129089617646726077709878673957695062439183530196057",
+		roots[1].String())
+	assert.Equal(t,
+		"2371789476252246873145569183657984076150578936906379480269269056232125907764",
+		roots[2].String())
+	assert.Equal(t,
+		"6592749167578234498153410564243369229486412054742481069049239297514590357090",
+		roots[8].String())
+	assert.Equal(t,
+		"8589789729005292678407393934765522959861157726948742829224066115700840150996",
+		roots[16].String())
+	assert.Equal(t,
+		"4764496441220219690920625833384223473698041580301808170211800933119216186307",
+		roots[24].String())
+
+	tree, err := New(16, big.NewInt(0))
+	require.Nil(t, err)
+	assert.Equal(t, roots[16], tree.Root())
+}
+
+func TestEmptyRootsDefaultLeaf(t *testing.T) {
+	roots, err := EmptyRoots(8, big.NewInt(1))
+	require.Nil(t, err)
+	assert.Equal(t,
+		"7975728153526986453681054013246640837981661878633187329125187730450700725807",
+		roots[1].String())
+	assert.Equal(t,
+		"20023184934790047549769641801868556400086647745858764456883089596893357269969",
+		roots[8].String())
+
+	tree, err := New(8, big.NewInt(1))
+	require.Nil(t, err)
+	assert.Equal(t, roots[8], tree.Root())
+	got, err := tree.Get(12345)
+	require.Nil(t, err)
+	assert.Equal(t, "1", got.String())
+}
+
+// TestLoopringGenesisRoot builds the empty state of a Loopring 3.6 exchange
+// as its operator's state.py does: the default storage, balance and account
+// leaves, each a Poseidon of its fields padded to the width of its
+// parameters (t=5 for storage and balance leaves, t=7 for account leaves),
+// over trees of 7, 8 and 16 levels (14 bits storage IDs, 16 bits token IDs
+// and 32 bits account IDs).  The accounts root is the genesis Merkle root
+// the Loopring 3.6 exchanges are initialized with.
+func TestLoopringGenesisRoot(t *testing.T) {
+	zero := big.NewInt(0)
+
+	// StorageLeaf(data=0, storageID=0)
+	storageLeaf, err := poseidon.Hash([]*big.Int{zero, zero, zero, zero})
+	require.Nil(t, err)
+	assert.Equal(t,
+		"18298609842015643040044099129089617646726077709878673957695062439183530196057",
+		storageLeaf.String())
+	storageRoots, err := EmptyRoots(7, storageLeaf)
+	require.Nil(t, err)
+	assert.Equal(t,
+		"6592749167578234498153410564243369229486412054742481069049239297514590357090",
+		storageRoots[7].String())
+
+	// BalanceLeaf(balance=0, weightAMM=0) with the empty storage tree
+	balanceLeaf, err := poseidon.Hash([]*big.Int{zero, zero, storageRoots[7], zero})
+	require.Nil(t, err)
+	assert.Equal(t,
+		"12197991638270691487744821166354688521061097726524551723597013006897868939455",
+		balanceLeaf.String())
+	balanceRoots, err := EmptyRoots(8, balanceLeaf)
+	require.Nil(t, err)
+	assert.Equal(t,
+		"7801237487181981149186143086321797866080303338833483837539290826558835955956",
+		balanceRoots[8].String())
+
+	// Account(owner=0, publicKey=(0, 0), nonce=0, feeBipsAMM=0) with the
+	// empty balances tree
+	accountLeaf, err := poseidon.Hash([]*big.Int{zero, zero, zero, zero, zero, balanceRoots[8]})
+	require.Nil(t, err)
+	assert.Equal(t,
+		"5418215761218159140333834208930113385110152943258857812431343823125268479924",
+		accountLeaf.String())
+	accounts, err := New(16, accountLeaf)
+	require.Nil(t, err)
+	assert.Equal(t,
+		"1efe4f31c90f89eb9b139426a95e5e87f6e0c9e8dab9ddf295e3f9d651f54698",
+		accounts.Root().Text(16))
+}
+
+func TestTreeUpdate(t *testing.T) {
+	tree, err := New(2, big.NewInt(0))
+	require.Nil(t, err)
+	// 5 = 0b0101 is the child 1 of the node 1
+	require.Nil(t, tree.Update(5, big.NewInt(7)))
+	assert.Equal(t,
+		"184334992012923663047189591314930348442054044444614659319858351962065508033",
+		tree.Root().String())
+	leaf, err := tree.Get(5)
+	require.Nil(t, err)
+	assert.Equal(t, "7", leaf.String())
+
+	// setting the leaf back to empty gives the empty root
+	require.Nil(t, tree.Update(5, big.NewInt(0)))
+	roots, err := EmptyRoots(2, big.NewInt(0))
+	require.Nil(t, err)
+	assert.Equal(t, roots[2], tree.Root())
+
+	assert.NotNil(t, tree.Update(16, big.NewInt(1)))
+	_, err = tree.Get(16)
+	assert.NotNil(t, err)
+	_, err = New(0, big.NewInt(0))
+	assert.NotNil(t, err)
+	_, err = New(MaxDepth+1, big.NewInt(0))
+	assert.NotNil(t, err)
+}
+
+func TestTreeProofs(t *testing.T) {
+	emptyLeaf := big.NewInt(3)
+	leaves := make([]*big.Int, 50)
+	for i := range leaves {
+		leaves[i] = big.NewInt(int64(i * i))
+	}
+	tree, err := NewFromLeaves(4, emptyLeaf, leaves)
+	require.Nil(t, err)
+
+	tree2, err := New(4, emptyLeaf)
+	require.Nil(t, err)
+	for i := len(leaves) - 1; i >= 0; i-- {
+		require.Nil(t, tree2.Update(uint64(i), leaves[i]))
+	}
+	assert.Equal(t, tree2.Root(), tree.Root())
+
+	root := tree.Root()
+	for _, i := range []uint64{0, 1, 13, 49, 50, 255} {
+		leaf, err := tree.Get(i)
+		require.Nil(t, err)
+		p, err := tree.GenerateProof(i)
+		require.Nil(t, err)
+		assert.Len(t, p.Siblings, 4)
+		assert.True(t, VerifyProof(root, p, leaf))
+		assert.False(t, VerifyProof(root, p, new(big.Int).Add(leaf, big.NewInt(1))))
+
+		b, err := json.Marshal(p)
+		require.Nil(t, err)
+		var p2 Proof
+		require.Nil(t, json.Unmarshal(b, &p2))
+		assert.True(t, VerifyProof(root, &p2, leaf))
+		if i < uint64(len(leaves)) {
+			// the proof doesn't hold for the neighbour index
+			p2.Index ^= 1
+			assert.False(t, VerifyProof(root, &p2, leaf))
+		}
+	}
+
+	_, err = tree.GenerateProof(256)
+	assert.NotNil(t, err)
+	p, err := tree.GenerateProof(1)
+	require.Nil(t, err)
+	p.Index = 256
+	assert.False(t, VerifyProof(root, p, leaves[1]))
+
+	// the leaf and the siblings must be inside the field, or they would
+	// alias their reduction
+	p.Index = 1
+	assert.True(t, VerifyProof(root, p, leaves[1]))
+	assert.False(t, VerifyProof(root, p, new(big.Int).Add(leaves[1], constants.Q)))
+	assert.False(t, VerifyProof(root, p, new(big.Int).Sub(leaves[1], constants.Q)))
+	p.Siblings[1][2] = new(big.Int).Add(p.Siblings[1][2], constants.Q)
+	assert.False(t, VerifyProof(root, p, leaves[1]))
+	_, err = NewFromLeaves(1, emptyLeaf, leaves)
+	assert.NotNil(t, err)
+}
+
+func TestTreeStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quad")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "tree")
+
+	emptyLeaf := big.NewInt(3)
+	db, err := storage.OpenFile(path)
+	require.Nil(t, err)
+	tree, err := NewWithStorage(db, 8, emptyLeaf)
+	require.Nil(t, err)
+	for i := uint64(0); i < 20; i++ {
+		require.Nil(t, tree.Update(i*97, new(big.Int).SetUint64(i+100)))
+	}
+	snapshot := tree.Root()
+	require.Nil(t, tree.Update(97, big.NewInt(1)))
+	root := tree.Root()
+	// a failed update leaves the tree as it was
+	assert.NotNil(t, tree.Update(1<<16, big.NewInt(1)))
+	require.Nil(t, db.Close())
+
+	db, err = storage.OpenFile(path)
+	require.Nil(t, err)
+	defer db.Close() //nolint:errcheck
+	tree, err = NewWithStorage(db, 8, emptyLeaf)
+	require.Nil(t, err)
+	assert.Equal(t, root, tree.Root())
+	leaf, err := tree.Get(97)
+	require.Nil(t, err)
+	assert.Equal(t, "1", leaf.String())
+	p, err := tree.GenerateProof(194)
+	require.Nil(t, err)
+	assert.True(t, VerifyProof(root, p, big.NewInt(102)))
+
+	// a snapshot of the tree before the last update
+	snap, err := tree.Snapshot(snapshot)
+	require.Nil(t, err)
+	leaf, err = snap.Get(97)
+	require.Nil(t, err)
+	assert.Equal(t, "101", leaf.String())
+	assert.Equal(t, ErrSnapshot, snap.Update(1, big.NewInt(1)))
+	require.Nil(t, tree.Update(1, big.NewInt(1)))
+	assert.Equal(t, snapshot, snap.Root())
+	_, err = tree.Snapshot(big.NewInt(1))
+	assert.NotNil(t, err)
+
+	// the leaves of a tree built at once are in the storage too
+	leaves := make([]*big.Int, 20)
+	for i := range leaves {
+		leaves[i] = big.NewInt(int64(i))
+	}
+	built, err := NewFromLeaves(8, emptyLeaf, leaves)
+	require.Nil(t, err)
+	leaf, err = built.Get(19)
+	require.Nil(t, err)
+	assert.Equal(t, "19", leaf.String())
+}
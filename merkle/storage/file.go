@@ -0,0 +1,485 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// recordHeaderLen is the length of the header of a record of the log: the
+// length of the payload and its CRC-32, as 4 bytes little-endian each.
+const recordHeaderLen = 8
+
+// ErrLocked is returned when opening a File storage that is open in another
+// process.
+var ErrLocked = errors.New("storage is locked by another process")
+
+// File is a Storage in an append-only log file, with an index on disk that
+// maps each key to the location of its last value in the log, so that the
+// memory used doesn't grow with the number of keys.
+//
+// A record is a header with the length and the CRC-32 of the payload, and
+// the payload with the uvarint length of the key, the key, the uvarint
+// length of the value and the value of each write.  When the file is
+// opened, a torn or corrupt record and everything after it are discarded,
+// so a batch interrupted by a crash is never partially applied.
+//
+// The index is an open addressing hash table in the file <path>.idx, which
+// is rebuilt from the log if the storage was not closed cleanly.  The
+// storage is locked with <path>.lock, so only one process can open it at a
+// time.  The log keeps every value written, and Compact rewrites it with
+// only the last value of each key.
+type File struct {
+	mu   sync.RWMutex
+	path string
+	lock *os.File
+	f    *os.File
+	size int64
+	// last is the offset of the last record of the log
+	last int64
+	idx  *index
+}
+
+// OpenFile opens the File storage at the path, which is created if it
+// doesn't exist.  Returns ErrLocked if it is open in another process.
+func OpenFile(path string) (*File, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600) //nolint:gomnd
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+	s := &File{path: path, lock: lock}
+	if err := s.open(); err != nil {
+		s.closeFiles() //nolint:errcheck,gosec
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the log and its index, replaying into the index the records
+// that are not in it.
+func (s *File) open() error {
+	var err error
+	if s.f, err = os.OpenFile(s.path, os.O_RDWR|os.O_CREATE, 0600); err != nil { //nolint:gomnd
+		return err
+	}
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	if s.idx, err = openIndex(s.path+".idx", 0); err != nil {
+		return err
+	}
+	h, err := s.idx.readHeader()
+	if err == nil && h.clean && h.logSize <= info.Size() && s.checkRecord(h.last, h.logSize) {
+		s.size, s.last = h.logSize, h.last
+	} else if err == nil || err == errCorrupt {
+		// the index is missing, stale or was not closed cleanly
+		if err := s.idx.reset(minIndexSlots); err != nil {
+			return err
+		}
+		s.size, s.last = 0, 0
+	} else {
+		return err
+	}
+	if err := s.replay(info.Size()); err != nil {
+		return err
+	}
+	// discard the tail after the last valid record
+	if err := s.f.Truncate(s.size); err != nil {
+		return err
+	}
+	// the index is marked as dirty until the storage is closed
+	if err := s.idx.writeHeader(false, s.size, s.last); err != nil {
+		return err
+	}
+	return s.idx.f.Sync()
+}
+
+// errCorrupt is returned when reading a corrupt record or index.
+var errCorrupt = errors.New("corrupt record")
+
+// checkRecord returns true if the record at off is valid and ends at end.
+func (s *File) checkRecord(off, end int64) bool {
+	if end == 0 {
+		return off == 0
+	}
+	r := io.NewSectionReader(s.f, off, end-off)
+	payload, err := readRecord(r, end-off)
+	return err == nil && off+recordHeaderLen+int64(len(payload)) == end
+}
+
+// readRecord reads a record of at most max bytes from r and returns its
+// payload.
+func readRecord(r io.Reader, max int64) ([]byte, error) {
+	var header [recordHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := int64(binary.LittleEndian.Uint32(header[:4]))
+	if recordHeaderLen+n > max {
+		return nil, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, errCorrupt
+	}
+	return payload, nil
+}
+
+// write is the location of a write of a record: the offset of its key from
+// the start of the payload and the length of its value.
+type write struct {
+	key    []byte
+	keyOff int
+	valLen int
+}
+
+// parseRecord returns the writes of the payload of a record.
+func parseRecord(payload []byte) ([]write, error) {
+	var ws []write
+	for pos := 0; pos < len(payload); {
+		key, next, err := readField(payload, pos)
+		if err != nil {
+			return nil, err
+		}
+		value, end, err := readField(payload, next)
+		if err != nil {
+			return nil, err
+		}
+		ws = append(ws, write{key: key, keyOff: next - len(key), valLen: len(value)})
+		pos = end
+	}
+	return ws, nil
+}
+
+// readField reads the field with its uvarint length at pos of the payload,
+// and returns it with the position after it.
+func readField(payload []byte, pos int) ([]byte, int, error) {
+	l, n := binary.Uvarint(payload[pos:])
+	if n <= 0 || l > uint64(len(payload)-pos-n) {
+		return nil, 0, errCorrupt
+	}
+	start := pos + n
+	end := start + int(l)
+	return payload[start:end], end, nil
+}
+
+// replay adds to the index the writes of the records of the log from the
+// end of the valid log up to end, stopping at the first torn or corrupt
+// record.
+func (s *File) replay(end int64) error {
+	r := bufio.NewReader(io.NewSectionReader(s.f, s.size, end-s.size))
+	for {
+		payload, err := readRecord(r, end-s.size)
+		if err == io.EOF || err == io.ErrUnexpectedEOF || err == errCorrupt {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		ws, err := parseRecord(payload)
+		if err == errCorrupt {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := s.index(s.size, ws); err != nil {
+			return err
+		}
+		s.last = s.size
+		s.size += recordHeaderLen + int64(len(payload))
+	}
+}
+
+// index adds to the index the writes of the record at off.
+func (s *File) index(off int64, ws []write) error {
+	for _, w := range ws {
+		sl := slot{
+			hash:   hashKey(w.key),
+			keyOff: off + recordHeaderLen + int64(w.keyOff),
+			keyLen: uint32(len(w.key)),
+			valLen: uint32(w.valLen),
+		}
+		if err := s.idx.insert(sl, s.matchKey(w.key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchKey returns the function that checks if a slot of the index is the
+// one of the key, reading the key from the log.
+func (s *File) matchKey(key []byte) func(slot) (bool, error) {
+	return func(sl slot) (bool, error) {
+		if int(sl.keyLen) != len(key) {
+			return false, nil
+		}
+		k := make([]byte, sl.keyLen)
+		if _, err := s.f.ReadAt(k, sl.keyOff); err != nil {
+			return false, err
+		}
+		return bytes.Equal(k, key), nil
+	}
+}
+
+// Get implements the Storage interface.
+func (s *File) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var value []byte
+	// read the key and the value at once, keeping the value if the key
+	// matches
+	_, found, err := s.idx.find(hashKey(key), func(sl slot) (bool, error) {
+		if int(sl.keyLen) != len(key) {
+			return false, nil
+		}
+		buf := make([]byte, int(sl.keyLen)+uvarintLen(uint64(sl.valLen))+int(sl.valLen))
+		if _, err := s.f.ReadAt(buf, sl.keyOff); err != nil {
+			return false, err
+		}
+		if !bytes.Equal(buf[:len(key)], key) {
+			return false, nil
+		}
+		value = buf[len(buf)-int(sl.valLen):]
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// Put implements the Storage interface.
+func (s *File) Put(key, value []byte) error {
+	b := s.Batch()
+	b.Put(key, value)
+	return b.Commit()
+}
+
+// Batch implements the Storage interface.
+func (s *File) Batch() Batch {
+	return newBatch(s, s.commit)
+}
+
+// encodeRecord returns the record of the writes of the keys, together with
+// the writes.
+func encodeRecord(keys []string, values map[string][]byte) ([]byte, []write, error) {
+	record := make([]byte, recordHeaderLen)
+	ws := make([]write, len(keys))
+	var buf [binary.MaxVarintLen64]byte
+	for i, k := range keys {
+		v := values[k]
+		record = append(record, buf[:binary.PutUvarint(buf[:], uint64(len(k)))]...)
+		ws[i] = write{key: []byte(k), keyOff: len(record) - recordHeaderLen, valLen: len(v)}
+		record = append(record, k...)
+		record = append(record, buf[:binary.PutUvarint(buf[:], uint64(len(v)))]...)
+		record = append(record, v...)
+	}
+	payload := record[recordHeaderLen:]
+	if uint64(len(payload)) > math.MaxUint32 {
+		return nil, nil, fmt.Errorf("batch too large")
+	}
+	binary.LittleEndian.PutUint32(record[:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:recordHeaderLen], crc32.ChecksumIEEE(payload))
+	return record, ws, nil
+}
+
+// commit appends the writes as a record, syncs the file and indexes them.
+func (s *File) commit(keys []string, values map[string][]byte) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	record, ws, err := encodeRecord(keys, values)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.WriteAt(record, s.size); err != nil {
+		s.f.Truncate(s.size) //nolint:errcheck,gosec
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	// the record is durable: if indexing it fails, the index is rebuilt
+	// when the storage is opened again
+	if err := s.index(s.size, ws); err != nil {
+		return err
+	}
+	s.last = s.size
+	s.size += int64(len(record))
+	return nil
+}
+
+// compactRecordLen is the length of the payload of the records written by
+// Compact, which groups many writes in each record.
+const compactRecordLen = 1 << 20
+
+// Compact rewrites the log with only the last value of each key, discarding
+// the values that have been overwritten, and rebuilds the index.  The new
+// log replaces the old one atomically, so a crash during Compact leaves the
+// storage as it was.
+func (s *File) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path+".compact", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600) //nolint:gomnd
+	if err != nil {
+		return err
+	}
+	idx, err := openIndex(s.path+".idx.compact", os.O_TRUNC)
+	if err != nil {
+		f.Close() //nolint:errcheck,gosec
+		return err
+	}
+	c := &File{path: s.path, f: f, idx: idx}
+	err = s.compactInto(c)
+	if err == nil {
+		// the new index is clean, while the current one is dirty, so it is
+		// rebuilt if a crash replaces the log but not the index
+		err = idx.writeHeader(true, c.size, c.last)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = idx.f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := idx.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	// the files are closed before replacing them, as open files can't be
+	// replaced on some platforms
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if err := s.idx.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path+".compact", s.path); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path+".idx.compact", s.path+".idx"); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// compactInto writes the last value of each key of s into the empty c.
+func (s *File) compactInto(c *File) error {
+	slots := uint64(minIndexSlots)
+	for slots < 2*s.idx.count {
+		slots *= 2
+	}
+	if err := c.idx.reset(slots); err != nil {
+		return err
+	}
+	var keys []string
+	values := make(map[string][]byte)
+	n := 0
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		record, ws, err := encodeRecord(keys, values)
+		if err != nil {
+			return err
+		}
+		if _, err := c.f.WriteAt(record, c.size); err != nil {
+			return err
+		}
+		if err := c.index(c.size, ws); err != nil {
+			return err
+		}
+		c.last = c.size
+		c.size += int64(len(record))
+		keys, values, n = nil, make(map[string][]byte), 0
+		return nil
+	}
+	err := s.idx.each(func(sl slot) error {
+		buf := make([]byte, int(sl.keyLen)+uvarintLen(uint64(sl.valLen))+int(sl.valLen))
+		if _, err := s.f.ReadAt(buf, sl.keyOff); err != nil {
+			return err
+		}
+		k := string(buf[:sl.keyLen])
+		keys = append(keys, k)
+		values[k] = buf[len(buf)-int(sl.valLen):]
+		n += len(buf)
+		if n >= compactRecordLen {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// closeFiles closes the files of the storage and releases its lock.
+func (s *File) closeFiles() error {
+	var err error
+	if s.idx != nil {
+		err = s.idx.f.Close()
+	}
+	if s.f != nil {
+		if cerr := s.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	unlockFile(s.lock) //nolint:errcheck,gosec
+	if cerr := s.lock.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Close implements the Storage interface, marking the index as clean so
+// that it is not rebuilt when the storage is opened again.
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.idx.writeHeader(true, s.size, s.last)
+	if err == nil {
+		err = s.idx.f.Sync()
+	}
+	if cerr := s.closeFiles(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func hashKey(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(key) //nolint:errcheck,gosec
+	return h.Sum64()
+}
+
+func uvarintLen(x uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], x)
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+const (
+	// minIndexSlots is the number of slots of a new index.
+	minIndexSlots = 1 << 10
+	// maxIndexSlots bounds the number of slots of an index read from disk.
+	maxIndexSlots = 1 << 40
+	// indexHeaderLen is the length of the header of the index: the magic,
+	// the flags, the number of slots and of keys, and the length and the
+	// offset of the last record of the indexed log, little-endian.
+	indexHeaderLen = 40
+	// slotLen is the length of a slot: the hash of the key, the offset of
+	// the key in the log, and the lengths of the key and the value.
+	slotLen = 24
+	// indexChunkSlots is the number of slots read at once when iterating
+	// over the index.
+	indexChunkSlots = 4096
+
+	flagClean = 1
+)
+
+var indexMagic = []byte("IDX1")
+
+// slot is a slot of the index, which is empty if keyOff is 0, as a key is
+// never at the start of the log.
+type slot struct {
+	hash   uint64
+	keyOff int64
+	keyLen uint32
+	valLen uint32
+}
+
+func (sl *slot) encode(b []byte) {
+	binary.LittleEndian.PutUint64(b[0:8], sl.hash)
+	binary.LittleEndian.PutUint64(b[8:16], uint64(sl.keyOff))
+	binary.LittleEndian.PutUint32(b[16:20], sl.keyLen)
+	binary.LittleEndian.PutUint32(b[20:24], sl.valLen)
+}
+
+func decodeSlot(b []byte) slot {
+	return slot{
+		hash:   binary.LittleEndian.Uint64(b[0:8]),
+		keyOff: int64(binary.LittleEndian.Uint64(b[8:16])),
+		keyLen: binary.LittleEndian.Uint32(b[16:20]),
+		valLen: binary.LittleEndian.Uint32(b[20:24]),
+	}
+}
+
+// index is an open addressing hash table with linear probing in a file,
+// which is kept at most half full.  The keys are not stored in the index,
+// they are compared by the callers reading them from the log.
+type index struct {
+	path  string
+	f     *os.File
+	slots uint64
+	count uint64
+}
+
+// openIndex opens the index file at the path, which is created if it
+// doesn't exist.
+func openIndex(path string, flag int) (*index, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|flag, 0600) //nolint:gomnd
+	if err != nil {
+		return nil, err
+	}
+	return &index{path: path, f: f}, nil
+}
+
+// indexHeader is the header of the index.
+type indexHeader struct {
+	clean   bool
+	logSize int64
+	last    int64
+}
+
+// readHeader reads the header of the index, returning errCorrupt if the
+// index is empty or invalid.
+func (x *index) readHeader() (*indexHeader, error) {
+	info, err := x.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var b [indexHeaderLen]byte
+	if _, err := x.f.ReadAt(b[:], 0); err == io.EOF {
+		return nil, errCorrupt
+	} else if err != nil {
+		return nil, err
+	}
+	slots := binary.LittleEndian.Uint64(b[8:16])
+	count := binary.LittleEndian.Uint64(b[16:24])
+	if !bytes.Equal(b[:4], indexMagic) || slots < minIndexSlots || slots > maxIndexSlots ||
+		slots&(slots-1) != 0 || 2*count > slots ||
+		info.Size() != int64(indexHeaderLen+slots*slotLen) {
+		return nil, errCorrupt
+	}
+	x.slots, x.count = slots, count
+	return &indexHeader{
+		clean:   binary.LittleEndian.Uint32(b[4:8])&flagClean != 0,
+		logSize: int64(binary.LittleEndian.Uint64(b[24:32])),
+		last:    int64(binary.LittleEndian.Uint64(b[32:40])),
+	}, nil
+}
+
+// writeHeader writes the header of the index of the log of the given size
+// whose last record is at last.
+func (x *index) writeHeader(clean bool, logSize, last int64) error {
+	var b [indexHeaderLen]byte
+	copy(b[:4], indexMagic)
+	if clean {
+		binary.LittleEndian.PutUint32(b[4:8], flagClean)
+	}
+	binary.LittleEndian.PutUint64(b[8:16], x.slots)
+	binary.LittleEndian.PutUint64(b[16:24], x.count)
+	binary.LittleEndian.PutUint64(b[24:32], uint64(logSize))
+	binary.LittleEndian.PutUint64(b[32:40], uint64(last))
+	_, err := x.f.WriteAt(b[:], 0)
+	return err
+}
+
+// reset empties the index, with the given number of slots.
+func (x *index) reset(slots uint64) error {
+	if err := x.f.Truncate(0); err != nil {
+		return err
+	}
+	if err := x.f.Truncate(int64(indexHeaderLen + slots*slotLen)); err != nil {
+		return err
+	}
+	x.slots, x.count = slots, 0
+	return nil
+}
+
+func (x *index) slot(i uint64) (slot, error) {
+	var b [slotLen]byte
+	if _, err := x.f.ReadAt(b[:], int64(indexHeaderLen+i*slotLen)); err != nil {
+		return slot{}, err
+	}
+	return decodeSlot(b[:]), nil
+}
+
+func (x *index) setSlot(i uint64, sl slot) error {
+	var b [slotLen]byte
+	sl.encode(b[:])
+	_, err := x.f.WriteAt(b[:], int64(indexHeaderLen+i*slotLen))
+	return err
+}
+
+// find returns the position of the slot with the hash for which match
+// returns true, or of the empty slot where it would be inserted.
+func (x *index) find(hash uint64, match func(slot) (bool, error)) (uint64, bool, error) {
+	mask := x.slots - 1
+	for i := hash & mask; ; i = (i + 1) & mask {
+		sl, err := x.slot(i)
+		if err != nil {
+			return 0, false, err
+		}
+		if sl.keyOff == 0 {
+			return i, false, nil
+		}
+		if sl.hash != hash {
+			continue
+		}
+		ok, err := match(sl)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			return i, true, nil
+		}
+	}
+}
+
+// insert sets the slot of the key for which match returns true, growing the
+// index if needed.
+func (x *index) insert(sl slot, match func(slot) (bool, error)) error {
+	if 2*(x.count+1) > x.slots {
+		if err := x.grow(); err != nil {
+			return err
+		}
+	}
+	i, found, err := x.find(sl.hash, match)
+	if err != nil {
+		return err
+	}
+	if err := x.setSlot(i, sl); err != nil {
+		return err
+	}
+	if !found {
+		x.count++
+	}
+	return nil
+}
+
+// each calls f with each non-empty slot of the index.
+func (x *index) each(f func(slot) error) error {
+	buf := make([]byte, indexChunkSlots*slotLen)
+	for i := uint64(0); i < x.slots; i += indexChunkSlots {
+		n := x.slots - i
+		if n > indexChunkSlots {
+			n = indexChunkSlots
+		}
+		b := buf[:n*slotLen]
+		if _, err := x.f.ReadAt(b, int64(indexHeaderLen+i*slotLen)); err != nil {
+			return err
+		}
+		for j := 0; j < len(b); j += slotLen {
+			if sl := decodeSlot(b[j : j+slotLen]); sl.keyOff != 0 {
+				if err := f(sl); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// grow doubles the number of slots of the index, rehashing it into a new
+// file that replaces the current one.
+func (x *index) grow() error {
+	n, err := openIndex(x.path+".tmp", os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	err = n.reset(2 * x.slots)
+	if err == nil {
+		// the keys are distinct, so they are inserted without comparing them
+		err = x.each(func(sl slot) error {
+			i, _, err := n.find(sl.hash, func(slot) (bool, error) { return false, nil })
+			if err != nil {
+				return err
+			}
+			n.count++
+			return n.setSlot(i, sl)
+		})
+	}
+	if err == nil {
+		err = n.writeHeader(false, 0, 0)
+	}
+	if err == nil {
+		err = os.Rename(n.path, x.path)
+	}
+	if err != nil {
+		n.f.Close() //nolint:errcheck,gosec
+		return err
+	}
+	x.f.Close() //nolint:errcheck,gosec
+	x.f, x.slots, x.count = n.f, n.slots, n.count
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package storage
+
+import (
+	"os"
+)
+
+// lockFile does nothing on the platforms without file locks, where the
+// storage must not be opened by several processes.
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
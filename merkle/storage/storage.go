@@ -0,0 +1,141 @@
+// Package storage defines the key-value storage of the nodes of the Merkle
+// trees, with atomic batches of writes, and implements it in memory and in an
+// append-only log file.
+package storage
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when the key is not in the storage.
+var ErrNotFound = errors.New("key not found")
+
+// ErrBatchDone is returned when committing a batch that has been committed
+// or rolled back.
+var ErrBatchDone = errors.New("batch already committed or rolled back")
+
+// Getter reads values by key.
+type Getter interface {
+	// Get returns the value of the key, or ErrNotFound.
+	Get(key []byte) ([]byte, error)
+}
+
+// Storage is a key-value storage.
+type Storage interface {
+	Getter
+	// Put writes the value of the key, as a batch of one write.
+	Put(key, value []byte) error
+	// Batch returns a new batch of writes on the storage.
+	Batch() Batch
+	// Close closes the storage.
+	Close() error
+}
+
+// Batch is a set of writes that are applied to the storage atomically on
+// Commit, or discarded on Rollback.  Get sees the writes of the batch.
+type Batch interface {
+	Getter
+	// Put writes the value of the key in the batch.  It is ignored once the
+	// batch is committed or rolled back.
+	Put(key, value []byte)
+	// Commit applies the writes of the batch to the storage.
+	Commit() error
+	// Rollback discards the writes of the batch.
+	Rollback()
+}
+
+// batch is the Batch of the storages, which keeps the writes in memory
+// until they are committed with the commit function of the storage.
+type batch struct {
+	parent Getter
+	commit func(keys []string, values map[string][]byte) error
+	keys   []string
+	values map[string][]byte
+	done   bool
+}
+
+func newBatch(parent Getter, commit func([]string, map[string][]byte) error) *batch {
+	return &batch{parent: parent, commit: commit, values: make(map[string][]byte)}
+}
+
+// Get implements the Batch interface.
+func (b *batch) Get(key []byte) ([]byte, error) {
+	if v, ok := b.values[string(key)]; ok {
+		return append([]byte{}, v...), nil
+	}
+	return b.parent.Get(key)
+}
+
+// Put implements the Batch interface.
+func (b *batch) Put(key, value []byte) {
+	if b.done {
+		return
+	}
+	k := string(key)
+	if _, ok := b.values[k]; !ok {
+		b.keys = append(b.keys, k)
+	}
+	b.values[k] = append([]byte{}, value...)
+}
+
+// Commit implements the Batch interface.
+func (b *batch) Commit() error {
+	if b.done {
+		return ErrBatchDone
+	}
+	b.done = true
+	err := b.commit(b.keys, b.values)
+	b.keys, b.values = nil, nil
+	return err
+}
+
+// Rollback implements the Batch interface.
+func (b *batch) Rollback() {
+	b.done = true
+	b.keys, b.values = nil, nil
+}
+
+// Memory is a Storage in memory.
+type Memory struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemory returns an empty Memory storage.
+func NewMemory() *Memory {
+	return &Memory{values: make(map[string][]byte)}
+}
+
+// Get implements the Storage interface.
+func (m *Memory) Get(key []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.values[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+// Put implements the Storage interface.
+func (m *Memory) Put(key, value []byte) error {
+	b := m.Batch()
+	b.Put(key, value)
+	return b.Commit()
+}
+
+// Batch implements the Storage interface.
+func (m *Memory) Batch() Batch {
+	return newBatch(m, func(keys []string, values map[string][]byte) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, k := range keys {
+			m.values[k] = values[k]
+		}
+		return nil
+	})
+}
+
+// Close implements the Storage interface.
+func (m *Memory) Close() error { return nil }
@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "storage")
+	require.Nil(t, err)
+	return dir
+}
+
+// testStorage runs the tests of the Storage interface on s.
+func testStorage(t *testing.T, s Storage) {
+	_, err := s.Get([]byte("a"))
+	assert.Equal(t, ErrNotFound, err)
+
+	require.Nil(t, s.Put([]byte("a"), []byte("1")))
+	v, err := s.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("1"), v)
+
+	// the writes of a batch are only visible to the batch until commit
+	b := s.Batch()
+	b.Put([]byte("a"), []byte("2"))
+	b.Put([]byte("b"), []byte("3"))
+	b.Put([]byte("empty"), []byte{})
+	v, err = b.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("2"), v)
+	v, err = s.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("1"), v)
+	_, err = s.Get([]byte("b"))
+	assert.Equal(t, ErrNotFound, err)
+
+	require.Nil(t, b.Commit())
+	assert.Equal(t, ErrBatchDone, b.Commit())
+	// a write after the commit is ignored
+	b.Put([]byte("late"), []byte("0"))
+	assert.Equal(t, ErrBatchDone, b.Commit())
+	_, err = s.Get([]byte("late"))
+	assert.Equal(t, ErrNotFound, err)
+	v, err = s.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("2"), v)
+	v, err = s.Get([]byte("b"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("3"), v)
+	v, err = s.Get([]byte("empty"))
+	require.Nil(t, err)
+	assert.Empty(t, v)
+
+	// a rolled back batch is discarded
+	b = s.Batch()
+	b.Put([]byte("c"), []byte("4"))
+	b.Rollback()
+	b.Put([]byte("c"), []byte("4"))
+	assert.Equal(t, ErrBatchDone, b.Commit())
+	_, err = s.Get([]byte("c"))
+	assert.Equal(t, ErrNotFound, err)
+
+	// the values are copied
+	value := []byte("5")
+	require.Nil(t, s.Put([]byte("d"), value))
+	value[0] = '6'
+	v, err = s.Get([]byte("d"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("5"), v)
+}
+
+func TestMemory(t *testing.T) {
+	testStorage(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "log")
+
+	s, err := OpenFile(path)
+	require.Nil(t, err)
+	testStorage(t, s)
+	require.Nil(t, s.Close())
+
+	// reopen
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	v, err := s.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("2"), v)
+	v, err = s.Get([]byte("d"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("5"), v)
+	require.Nil(t, s.Close())
+}
+
+func TestFileTornRecord(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "log")
+
+	s, err := OpenFile(path)
+	require.Nil(t, err)
+	require.Nil(t, s.Put([]byte("a"), []byte("1")))
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	size := info.Size()
+	b := s.Batch()
+	b.Put([]byte("b"), []byte("2"))
+	b.Put([]byte("c"), []byte("3"))
+	require.Nil(t, b.Commit())
+	require.Nil(t, s.Close())
+
+	// a batch interrupted while writing is discarded as a whole
+	require.Nil(t, os.Truncate(path, size+10))
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	v, err := s.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("1"), v)
+	_, err = s.Get([]byte("b"))
+	assert.Equal(t, ErrNotFound, err)
+	_, err = s.Get([]byte("c"))
+	assert.Equal(t, ErrNotFound, err)
+
+	// and the log continues after the last valid record
+	require.Nil(t, s.Put([]byte("b"), []byte("4")))
+	require.Nil(t, s.Close())
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	v, err = s.Get([]byte("b"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("4"), v)
+
+	// a corrupt record is discarded
+	require.Nil(t, s.Put([]byte("e"), []byte("5")))
+	require.Nil(t, s.Close())
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	data[len(data)-1] ^= 0xff
+	require.Nil(t, ioutil.WriteFile(path, data, 0600))
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	_, err = s.Get([]byte("e"))
+	assert.Equal(t, ErrNotFound, err)
+	v, err = s.Get([]byte("b"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("4"), v)
+	require.Nil(t, s.Close())
+}
+
+func TestFileIndex(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "log")
+
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key%d", i)) }
+	s, err := OpenFile(path)
+	require.Nil(t, err)
+	// enough keys to grow the index a few times
+	n := 5200
+	for i := 0; i < n; i += 100 {
+		b := s.Batch()
+		for j := i; j < i+100; j++ {
+			b.Put(key(j), []byte{byte(j)})
+		}
+		require.Nil(t, b.Commit())
+	}
+	assert.Equal(t, uint64(n), s.idx.count)
+	assert.True(t, s.idx.slots >= uint64(2*n))
+	check := func(s *File) {
+		for i := 0; i < n; i += 7 {
+			v, err := s.Get(key(i))
+			require.Nil(t, err)
+			assert.Equal(t, []byte{byte(i)}, v)
+		}
+		_, err := s.Get(key(n))
+		assert.Equal(t, ErrNotFound, err)
+	}
+	check(s)
+
+	// a copy of the storage while it is open has a dirty index, which is
+	// rebuilt from the log
+	crashed := filepath.Join(dir, "crashed")
+	for _, ext := range []string{"", ".idx"} {
+		data, err := ioutil.ReadFile(path + ext)
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(crashed+ext, data, 0600))
+	}
+	c, err := OpenFile(crashed)
+	require.Nil(t, err)
+	check(c)
+	require.Nil(t, c.Close())
+
+	// the clean index is used as is when reopening
+	require.Nil(t, s.Close())
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	check(s)
+	require.Nil(t, s.Close())
+}
+
+func TestFileCompact(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "log")
+
+	s, err := OpenFile(path)
+	require.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		require.Nil(t, s.Put([]byte("root"), []byte(fmt.Sprintf("root%d", i))))
+		require.Nil(t, s.Put([]byte(fmt.Sprintf("node%d", i)), []byte("node")))
+	}
+	before, err := os.Stat(path)
+	require.Nil(t, err)
+	require.Nil(t, s.Compact())
+	after, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.True(t, after.Size() < before.Size())
+
+	check := func(s *File) {
+		v, err := s.Get([]byte("root"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("root99"), v)
+		for i := 0; i < 100; i++ {
+			v, err := s.Get([]byte(fmt.Sprintf("node%d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, []byte("node"), v)
+		}
+	}
+	check(s)
+	// the storage continues after the compaction
+	require.Nil(t, s.Put([]byte("new"), []byte("1")))
+	require.Nil(t, s.Close())
+
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	check(s)
+	v, err := s.Get([]byte("new"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("1"), v)
+	require.Nil(t, s.Close())
+}
+
+func TestFileLock(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "log")
+
+	s, err := OpenFile(path)
+	require.Nil(t, err)
+	_, err = OpenFile(path)
+	assert.Equal(t, ErrLocked, err)
+	require.Nil(t, s.Close())
+	s, err = OpenFile(path)
+	require.Nil(t, err)
+	require.Nil(t, s.Close())
+}
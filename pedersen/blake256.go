@@ -0,0 +1,115 @@
+package pedersen
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// blake256 implements the original BLAKE-256 hash from the SHA-3
+// competition (not BLAKE2s), which is the hash used by circomlib to derive
+// the Pedersen generators.
+
+var blake256IV = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A,
+	0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+var blake256C = [16]uint32{
+	0x243F6A88, 0x85A308D3, 0x13198A2E, 0x03707344,
+	0xA4093822, 0x299F31D0, 0x082EFA98, 0xEC4E6C89,
+	0x452821E6, 0x38D01377, 0xBE5466CF, 0x34E90C6C,
+	0xC0AC29B7, 0xC97C50DD, 0x3F84D5B5, 0xB5470917,
+}
+
+var blake256Sigma = [10][16]uint8{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+}
+
+const blake256Rounds = 14
+
+func blake256G(v *[16]uint32, m *[16]uint32, r, i, a, b, c, d int) {
+	s := &blake256Sigma[r%10]
+	x, y := s[2*i], s[2*i+1]
+	v[a] += v[b] + (m[x] ^ blake256C[y])
+	v[d] = bits.RotateLeft32(v[d]^v[a], -16)
+	v[c] += v[d]
+	v[b] = bits.RotateLeft32(v[b]^v[c], -12)
+	v[a] += v[b] + (m[y] ^ blake256C[x])
+	v[d] = bits.RotateLeft32(v[d]^v[a], -8)
+	v[c] += v[d]
+	v[b] = bits.RotateLeft32(v[b]^v[c], -7)
+}
+
+func blake256Compress(h *[8]uint32, block []byte, t uint64) {
+	var m [16]uint32
+	for i := range m {
+		m[i] = binary.BigEndian.Uint32(block[4*i:])
+	}
+	var v [16]uint32
+	copy(v[:8], h[:])
+	copy(v[8:], blake256C[:8])
+	v[12] ^= uint32(t)
+	v[13] ^= uint32(t)
+	v[14] ^= uint32(t >> 32) //nolint:gomnd
+	v[15] ^= uint32(t >> 32) //nolint:gomnd
+	for r := 0; r < blake256Rounds; r++ {
+		blake256G(&v, &m, r, 0, 0, 4, 8, 12)
+		blake256G(&v, &m, r, 1, 1, 5, 9, 13)
+		blake256G(&v, &m, r, 2, 2, 6, 10, 14)
+		blake256G(&v, &m, r, 3, 3, 7, 11, 15)
+		blake256G(&v, &m, r, 4, 0, 5, 10, 15)
+		blake256G(&v, &m, r, 5, 1, 6, 11, 12)
+		blake256G(&v, &m, r, 6, 2, 7, 8, 13)
+		blake256G(&v, &m, r, 7, 3, 4, 9, 14)
+	}
+	for i := range h {
+		h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+// blake256 returns the BLAKE-256 hash of msg, with a zero salt.
+func blake256(msg []byte) [32]byte {
+	const blockSize = 64
+	bitLen := uint64(len(msg)) * 8 //nolint:gomnd
+
+	padded := make([]byte, len(msg), len(msg)+2*blockSize)
+	copy(padded, msg)
+	padded = append(padded, 0x80)
+	for len(padded)%blockSize != blockSize-8 {
+		padded = append(padded, 0x00)
+	}
+	padded[len(padded)-1] |= 0x01
+	var lenBuf [8]byte
+	binary.BigEndian.PutUint64(lenBuf[:], bitLen)
+	padded = append(padded, lenBuf[:]...)
+
+	h := blake256IV
+	for i := 0; i < len(padded); i += blockSize {
+		// the counter is the number of message bits up to the end of the
+		// block, or 0 if the block only contains padding
+		var t uint64
+		if i < len(msg) {
+			end := i + blockSize
+			if end > len(msg) {
+				end = len(msg)
+			}
+			t = uint64(end) * 8 //nolint:gomnd
+		}
+		blake256Compress(&h, padded[i:i+blockSize], t)
+	}
+
+	var out [32]byte
+	for i := range h {
+		binary.BigEndian.PutUint32(out[4*i:], h[i])
+	}
+	return out
+}
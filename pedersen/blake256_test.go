@@ -0,0 +1,28 @@
+package pedersen
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlake256(t *testing.T) {
+	h := blake256([]byte{})
+	assert.Equal(t,
+		"716f6e863f744b9ac22c97ec7b76ea5f5908bc5b2f67c61510bfc4751384ea7a",
+		hex.EncodeToString(h[:]))
+	h = blake256([]byte{0})
+	assert.Equal(t,
+		"0ce8d4ef4dd7cd8d62dfded9d4edb0a774ae6a41929a74da23109e8f11139c87",
+		hex.EncodeToString(h[:]))
+	h = blake256([]byte("The quick brown fox jumps over the lazy dog"))
+	assert.Equal(t,
+		"7576698ee9cad30173080678e5965916adbb11cb5245d386bf1ffda1cb26c9d7",
+		hex.EncodeToString(h[:]))
+	// 72 zero bytes, the second block only has message bits in part
+	h = blake256(make([]byte, 72))
+	assert.Equal(t,
+		"d419bad32d504fb7d44d460c42c5593fe544fa4c135dec31e21bd9abdcc22d41",
+		hex.EncodeToString(h[:]))
+}
@@ -0,0 +1,286 @@
+package pedersen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+const (
+	// DefaultDomain is the domain string of the generators used by Commit.
+	DefaultDomain = "iden3_pedersen_commitment"
+	// DST is the domain separation tag of the hash to curve of the
+	// generators.
+	DST = "IDEN3_PEDERSEN_XMD:SHA-256_ELL2_RO_"
+)
+
+// hashToPoint deterministically maps the label to a point of the subgroup
+// with unknown discrete logarithm, with the hash to curve of babyjub.
+func hashToPoint(label string) *babyjub.Point {
+	return babyjub.HashToCurve([]byte(label), []byte(DST))
+}
+
+// Generators are the generators of the Pedersen commitments of a domain: one
+// generator G_i for each committed value, derived from "<domain>_G_<i>", and
+// the blinding generator H, derived from "<domain>_H".
+type Generators struct {
+	domain string
+	h      *babyjub.Point
+	mu     sync.Mutex
+	g      []*babyjub.Point
+}
+
+// NewGenerators returns the generators of the domain.  The generators of the
+// values are derived lazily as they are needed.
+func NewGenerators(domain string) *Generators {
+	return &Generators{
+		domain: domain,
+		h:      hashToPoint(domain + "_H"),
+	}
+}
+
+var defaultGenerators = NewGenerators(DefaultDomain)
+
+// Domain returns the domain string of the generators.
+func (gs *Generators) Domain() string {
+	return gs.domain
+}
+
+// H returns the blinding generator.
+func (gs *Generators) H() *babyjub.Point {
+	return babyjub.NewPoint().Set(gs.h)
+}
+
+// G returns the generator of the value i.
+func (gs *Generators) G(i int) *babyjub.Point {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	for len(gs.g) <= i {
+		gs.g = append(gs.g, hashToPoint(fmt.Sprintf("%s_G_%d", gs.domain, len(gs.g))))
+	}
+	return babyjub.NewPoint().Set(gs.g[i])
+}
+
+// Commitment is a Pedersen commitment to a vector of values: the point
+// sum(values[i] * G_i) + blinding * H.  It is a babyjub.Point with its own
+// serialization, see Point for the *babyjub.Point.
+type Commitment babyjub.Point
+
+// Point returns the Point corresponding to a Commitment.
+func (c *Commitment) Point() *babyjub.Point {
+	return (*babyjub.Point)(c)
+}
+
+// Opening holds the values and the blinding factor that open a Commitment.
+type Opening struct {
+	Values   []*big.Int
+	Blinding *big.Int
+}
+
+func checkScalar(v *big.Int) bool {
+	return v.Sign() >= 0 && v.Cmp(babyjub.SubOrder) < 0
+}
+
+// Commit commits to the values with the blinding factor, returning the
+// commitment as a *Commitment instead of a *babyjub.Point so that it has its
+// own serialization.  The values and the blinding factor must be in [0,
+// SubOrder): a field element v >= SubOrder would have the same commitment as
+// v - SubOrder, so it is rejected to keep the commitment binding.
+func (gs *Generators) Commit(values []*big.Int, blinding *big.Int) (*Commitment, error) {
+	if !checkScalar(blinding) {
+		return nil, fmt.Errorf("blinding not in [0, SubOrder)")
+	}
+	acc := babyjub.NewPoint().Mul(blinding, gs.h).Projective()
+	for i, v := range values {
+		if !checkScalar(v) {
+			return nil, fmt.Errorf("value %d not in [0, SubOrder)", i)
+		}
+		acc = babyjub.NewPointProjective().Add(acc, babyjub.NewPoint().Mul(v, gs.G(i)).Projective())
+	}
+	c := Commitment(*acc.Affine())
+	return &c, nil
+}
+
+// CommitRandom commits to the values with a random blinding factor, and
+// returns the commitment together with its opening.
+func (gs *Generators) CommitRandom(values []*big.Int) (*Commitment, *Opening, error) {
+	blinding, err := babyjub.NewRandScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := gs.Commit(values, blinding)
+	if err != nil {
+		return nil, nil, err
+	}
+	vs := make([]*big.Int, len(values))
+	for i := range values {
+		vs[i] = new(big.Int).Set(values[i])
+	}
+	return c, &Opening{Values: vs, Blinding: blinding}, nil
+}
+
+// Open checks that the opening opens the commitment, returning error if it
+// doesn't.
+func (gs *Generators) Open(c *Commitment, o *Opening) error {
+	c2, err := gs.Commit(o.Values, o.Blinding)
+	if err != nil {
+		return err
+	}
+	if !c.Point().Equal(c2.Point()) {
+		return fmt.Errorf("opening does not match the commitment")
+	}
+	return nil
+}
+
+// Verify returns true if the opening opens the commitment.
+func (gs *Generators) Verify(c *Commitment, o *Opening) bool {
+	return gs.Open(c, o) == nil
+}
+
+// Commit commits to the values with the blinding factor using the generators
+// of DefaultDomain.
+func Commit(values []*big.Int, blinding *big.Int) (*Commitment, error) {
+	return defaultGenerators.Commit(values, blinding)
+}
+
+// CommitRandom commits to the values with a random blinding factor using the
+// generators of DefaultDomain.
+func CommitRandom(values []*big.Int) (*Commitment, *Opening, error) {
+	return defaultGenerators.CommitRandom(values)
+}
+
+// Open checks that the opening opens the commitment using the generators of
+// DefaultDomain.
+func Open(c *Commitment, o *Opening) error {
+	return defaultGenerators.Open(c, o)
+}
+
+// Verify returns true if the opening opens the commitment using the
+// generators of DefaultDomain.
+func Verify(c *Commitment, o *Opening) bool {
+	return defaultGenerators.Verify(c, o)
+}
+
+// Add returns the commitment to the sum of the openings of a and b.
+func Add(a, b *Commitment) *Commitment {
+	c := Commitment(*babyjub.NewPoint().Add(a.Point(), b.Point()))
+	return &c
+}
+
+// AddOpenings returns the opening of the sum of the commitments of a and b.
+// The values and the blinding factors are added modulo SubOrder, and the
+// shorter vector of values is padded with zeros.
+func AddOpenings(a, b *Opening) *Opening {
+	n := len(a.Values)
+	if len(b.Values) > n {
+		n = len(b.Values)
+	}
+	values := make([]*big.Int, n)
+	for i := range values {
+		values[i] = big.NewInt(0)
+		if i < len(a.Values) {
+			values[i].Add(values[i], a.Values[i])
+		}
+		if i < len(b.Values) {
+			values[i].Add(values[i], b.Values[i])
+		}
+		values[i].Mod(values[i], babyjub.SubOrder)
+	}
+	blinding := new(big.Int).Add(a.Blinding, b.Blinding)
+	blinding.Mod(blinding, babyjub.SubOrder)
+	return &Opening{Values: values, Blinding: blinding}
+}
+
+// CommitmentComp represents a compressed Pedersen commitment.
+type CommitmentComp [32]byte
+
+// MarshalText implements the marshaler for the CommitmentComp
+func (cComp CommitmentComp) MarshalText() ([]byte, error) {
+	return utils.Hex(cComp[:]).MarshalText()
+}
+
+// String returns the string representation of the CommitmentComp
+func (cComp CommitmentComp) String() string { return utils.Hex(cComp[:]).String() }
+
+// UnmarshalText implements the unmarshaler for the CommitmentComp
+func (cComp *CommitmentComp) UnmarshalText(h []byte) error {
+	return utils.HexDecodeInto(cComp[:], h)
+}
+
+// Compress returns the CommitmentComp for the given Commitment
+func (c *Commitment) Compress() CommitmentComp {
+	return CommitmentComp(c.Point().Compress())
+}
+
+// Decompress returns the Commitment for the given CommitmentComp.  Returns
+// error if the point decompression fails or if the point is not in the
+// subgroup.
+func (cComp *CommitmentComp) Decompress() (*Commitment, error) {
+	p, err := babyjub.NewPoint().Decompress(*cComp)
+	if err != nil {
+		return nil, err
+	}
+	if !p.InSubGroup() {
+		return nil, fmt.Errorf("commitment not in the subgroup")
+	}
+	c := Commitment(*p)
+	return &c, nil
+}
+
+// MarshalText implements the marshaler for the Commitment
+func (c Commitment) MarshalText() ([]byte, error) {
+	return c.Compress().MarshalText()
+}
+
+// UnmarshalText implements the unmarshaler for the Commitment
+func (c *Commitment) UnmarshalText(h []byte) error {
+	var cComp CommitmentComp
+	if err := cComp.UnmarshalText(h); err != nil {
+		return err
+	}
+	c2, err := cComp.Decompress()
+	if err != nil {
+		return err
+	}
+	*c = *c2
+	return nil
+}
+
+type openingJSON struct {
+	Values   []string `json:"values"`
+	Blinding string   `json:"blinding"`
+}
+
+// MarshalJSON implements the json marshaler for the Opening, encoding the
+// values and the blinding factor as decimal strings.
+func (o Opening) MarshalJSON() ([]byte, error) {
+	oj := openingJSON{Values: make([]string, len(o.Values)), Blinding: o.Blinding.String()}
+	for i, v := range o.Values {
+		oj.Values[i] = v.String()
+	}
+	return json.Marshal(oj)
+}
+
+// UnmarshalJSON implements the json unmarshaler for the Opening
+func (o *Opening) UnmarshalJSON(b []byte) error {
+	var oj openingJSON
+	if err := json.Unmarshal(b, &oj); err != nil {
+		return err
+	}
+	blinding, ok := new(big.Int).SetString(oj.Blinding, 10) //nolint:gomnd
+	if !ok {
+		return fmt.Errorf("invalid blinding %q", oj.Blinding)
+	}
+	values := make([]*big.Int, len(oj.Values))
+	for i, s := range oj.Values {
+		if values[i], ok = new(big.Int).SetString(s, 10); !ok { //nolint:gomnd
+			return fmt.Errorf("invalid value %q", s)
+		}
+	}
+	o.Values, o.Blinding = values, blinding
+	return nil
+}
@@ -0,0 +1,119 @@
+package pedersen
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerators(t *testing.T) {
+	gs := NewGenerators(DefaultDomain)
+	assert.Equal(t, DefaultDomain, gs.Domain())
+	assert.Equal(t,
+		"18692082688417558392181713929858259285274961304790242804402854013680817536107",
+		gs.H().X.String())
+	assert.Equal(t,
+		"7096920323423566767739879219711152874313620591751564782347243098632133906186",
+		gs.H().Y.String())
+	assert.Equal(t,
+		"8149431077799027828642912520930420097599868292875168451100612181206705677796",
+		gs.G(0).X.String())
+	assert.Equal(t,
+		"10334740110673734571101199106518746447287874808543561064191260723811285195700",
+		gs.G(0).Y.String())
+
+	// the generators are the hash to curve of their labels
+	assert.Equal(t, babyjub.HashToCurve([]byte(DefaultDomain+"_G_1"), []byte(DST)), gs.G(1))
+	for i := 0; i < 4; i++ {
+		assert.True(t, gs.G(i).InSubGroup())
+		assert.False(t, gs.G(i).Equal(gs.H()))
+	}
+	assert.False(t, gs.G(0).Equal(NewGenerators("other").G(0)))
+}
+
+func TestCommitOpen(t *testing.T) {
+	values := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	c, err := Commit(values, big.NewInt(12345))
+	require.Nil(t, err)
+	assert.Equal(t,
+		"8a3e7fd577dc15d257a5b0cdf681d98ee0d10c80602fa17084a3d1b70be7c10f",
+		c.Compress().String())
+
+	o := &Opening{Values: values, Blinding: big.NewInt(12345)}
+	assert.Nil(t, Open(c, o))
+	assert.True(t, Verify(c, o))
+
+	// wrong value or blinding
+	assert.False(t, Verify(c, &Opening{Values: []*big.Int{big.NewInt(1), big.NewInt(2),
+		big.NewInt(4)}, Blinding: big.NewInt(12345)}))
+	assert.Equal(t, "opening does not match the commitment",
+		Open(c, &Opening{Values: values, Blinding: big.NewInt(12346)}).Error())
+
+	// the values are bound exactly: a field element above SubOrder, which
+	// would open the commitment of its reduction, is rejected
+	v := new(big.Int).Sub(constants.Q, big.NewInt(1))
+	_, err = Commit([]*big.Int{v}, big.NewInt(1))
+	assert.Equal(t, "value 0 not in [0, SubOrder)", err.Error())
+	_, err = Commit([]*big.Int{babyjub.SubOrder}, big.NewInt(1))
+	assert.Equal(t, "value 0 not in [0, SubOrder)", err.Error())
+	cmax, err := Commit([]*big.Int{new(big.Int).Sub(babyjub.SubOrder, big.NewInt(1))}, big.NewInt(1))
+	require.Nil(t, err)
+	alias := new(big.Int).Sub(new(big.Int).Lsh(babyjub.SubOrder, 1), big.NewInt(1))
+	assert.False(t, Verify(cmax, &Opening{Values: []*big.Int{alias}, Blinding: big.NewInt(1)}))
+
+	// values out of range
+	_, err = Commit([]*big.Int{big.NewInt(-1)}, big.NewInt(1))
+	assert.Equal(t, "value 0 not in [0, SubOrder)", err.Error())
+	_, err = Commit([]*big.Int{big.NewInt(1)}, big.NewInt(-1))
+	assert.Equal(t, "blinding not in [0, SubOrder)", err.Error())
+
+	// random blinding hides the values
+	c1, o1, err := CommitRandom(values)
+	require.Nil(t, err)
+	c2, o2, err := CommitRandom(values)
+	require.Nil(t, err)
+	assert.False(t, c1.Point().Equal(c2.Point()))
+	assert.True(t, Verify(c1, o1))
+	assert.True(t, Verify(c2, o2))
+
+	// a commitment of another domain doesn't open
+	gs := NewGenerators("other")
+	assert.False(t, gs.Verify(c1, o1))
+}
+
+func TestCommitAdd(t *testing.T) {
+	c1, o1, err := CommitRandom([]*big.Int{big.NewInt(10), big.NewInt(20)})
+	require.Nil(t, err)
+	c2, o2, err := CommitRandom([]*big.Int{big.NewInt(5)})
+	require.Nil(t, err)
+
+	c := Add(c1, c2)
+	o := AddOpenings(o1, o2)
+	assert.Equal(t, []*big.Int{big.NewInt(15), big.NewInt(20)}, o.Values)
+	assert.True(t, Verify(c, o))
+}
+
+func TestCommitmentSerialization(t *testing.T) {
+	c, o, err := CommitRandom([]*big.Int{big.NewInt(7), big.NewInt(8)})
+	require.Nil(t, err)
+
+	b, err := json.Marshal(c)
+	require.Nil(t, err)
+	var c2 Commitment
+	require.Nil(t, json.Unmarshal(b, &c2))
+	assert.True(t, c.Point().Equal(c2.Point()))
+
+	b, err = json.Marshal(o)
+	require.Nil(t, err)
+	var o2 Opening
+	require.Nil(t, json.Unmarshal(b, &o2))
+	assert.Equal(t, o, &o2)
+	assert.True(t, Verify(&c2, &o2))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"values":["x"],"blinding":"1"}`), &o2))
+}
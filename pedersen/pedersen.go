@@ -0,0 +1,121 @@
+// Package pedersen implements the Pedersen hash over the BabyJubJub curve
+// compatible with circomlib (pedersen.circom and circomlibjs pedersenHash).
+//
+// The message bits are split in segments of 200 bits, each segment is split
+// in windows of 4 bits, and each window encodes a signed value in [-8, 8]
+// \ {0}: the first 3 bits give the magnitude minus one and the fourth bit is
+// the sign.  The windows of a segment are combined into a scalar, in base 32,
+// that multiplies the generator of the segment, and the hash is the sum of
+// all the segment points.
+//
+// The package also implements Pedersen vector commitments over the BabyJubJub
+// subgroup, see Generators.
+package pedersen
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+)
+
+const (
+	// GenPointPrefix is the prefix of the strings hashed to derive the
+	// generators.
+	GenPointPrefix = "PedersenGenerator"
+	// WindowSize is the number of bits of each window.
+	WindowSize = 4
+	// NWindowsPerSegment is the number of windows of each segment.
+	NWindowsPerSegment = 50
+	// BitsPerSegment is the number of bits of each segment.
+	BitsPerSegment = WindowSize * NWindowsPerSegment
+)
+
+var (
+	basesMu sync.Mutex
+	bases   []*babyjub.Point
+)
+
+// BasePoint returns the generator of the segment i.  It is derived by hashing
+// "PedersenGenerator_<i>_<try>" (with both numbers padded with zeros to 32
+// digits) with Blake-256 until the hash, with the bit 254 cleared, is a valid
+// compressed point, and multiplying it by 8 to bring it into the subgroup.
+func BasePoint(i int) *babyjub.Point {
+	basesMu.Lock()
+	defer basesMu.Unlock()
+	for len(bases) <= i {
+		bases = append(bases, generateBasePoint(len(bases)))
+	}
+	return babyjub.NewPoint().Set(bases[i])
+}
+
+func generateBasePoint(pointIdx int) *babyjub.Point {
+	for tryIdx := 0; ; tryIdx++ {
+		s := fmt.Sprintf("%s_%032d_%032d", GenPointPrefix, pointIdx, tryIdx)
+		h := blake256([]byte(s))
+		h[31] = h[31] & 0xBF //nolint:gomnd
+		p, err := babyjub.NewPoint().Decompress(h)
+		if err != nil {
+			continue
+		}
+		p8 := babyjub.NewPoint().Mul(big.NewInt(8), p) //nolint:gomnd
+		if !p8.InSubGroup() {
+			panic(fmt.Errorf("pedersen generator %d not in the subgroup", pointIdx))
+		}
+		return p8
+	}
+}
+
+// bit returns the bit i of msg, taking the bits of each byte from the least
+// significant to the most significant.
+func bit(msg []byte, i int) bool {
+	return msg[i/8]&(1<<(uint(i)%8)) != 0 //nolint:gomnd
+}
+
+// Hash returns the Pedersen hash of msg as a point of the curve.
+func Hash(msg []byte) *babyjub.Point {
+	nBits := len(msg) * 8 //nolint:gomnd
+	nSegments := (nBits-1)/BitsPerSegment + 1
+	if nBits == 0 {
+		nSegments = 0
+	}
+	acc := babyjub.NewPointProjective()
+	for s := 0; s < nSegments; s++ {
+		nWindows := NWindowsPerSegment
+		if s == nSegments-1 {
+			nWindows = ((nBits-(nSegments-1)*BitsPerSegment)-1)/WindowSize + 1
+		}
+		escalar := big.NewInt(0)
+		exp := big.NewInt(1)
+		for w := 0; w < nWindows; w++ {
+			o := s*BitsPerSegment + w*WindowSize
+			win := big.NewInt(1)
+			for b := 0; b < WindowSize-1 && o < nBits; b++ {
+				if bit(msg, o) {
+					win.Add(win, new(big.Int).Lsh(big.NewInt(1), uint(b)))
+				}
+				o++
+			}
+			if o < nBits {
+				if bit(msg, o) {
+					win.Neg(win)
+				}
+			}
+			escalar.Add(escalar, win.Mul(win, exp))
+			exp.Lsh(exp, WindowSize+1)
+		}
+		if escalar.Sign() < 0 {
+			escalar.Add(babyjub.SubOrder, escalar)
+		}
+		p := babyjub.NewPoint().Mul(escalar, BasePoint(s))
+		acc = babyjub.NewPointProjective().Add(acc, p.Projective())
+	}
+	return acc.Affine()
+}
+
+// HashCompressed returns the Pedersen hash of msg as a compressed point, which
+// is the output of circomlibjs pedersenHash.hash.
+func HashCompressed(msg []byte) [32]byte {
+	return Hash(msg).Compress()
+}
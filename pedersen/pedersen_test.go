@@ -0,0 +1,88 @@
+package pedersen
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasePoints(t *testing.T) {
+	// BASE constants of circomlib pedersen.circom
+	expected := [][2]string{
+		{"10457101036533406547632367118273992217979173478358440826365724437999023779287",
+			"19824078218392094440610104313265183977899662750282163392862422243483260492317"},
+		{"2671756056509184035029146175565761955751135805354291559563293617232983272177",
+			"2663205510731142763556352975002641716101654201788071096152948830924149045094"},
+		{"5802099305472655231388284418920769829666717045250560929368476121199858275951",
+			"5980429700218124965372158798884772646841287887664001482443826541541529227896"},
+		{"7107336197374528537877327281242680114152313102022415488494307685842428166594",
+			"2857869773864086953506483169737724679646433914307247183624878062391496185654"},
+		{"20265828622013100949498132415626198973119240347465898028410217039057588424236",
+			"1160461593266035632937973507065134938065359936056410650153315956301179689506"},
+	}
+	for i, e := range expected {
+		p := BasePoint(i)
+		assert.Equal(t, e[0], p.X.String())
+		assert.Equal(t, e[1], p.Y.String())
+		assert.True(t, p.InSubGroup())
+	}
+}
+
+func TestHash(t *testing.T) {
+	// empty message hashes to the identity
+	assert.True(t, Hash([]byte{}).Equal(babyjub.NewPoint()))
+
+	h := Hash([]byte{1})
+	assert.Equal(t,
+		"518233436145504081055674691695570228329258577939788873963177054466170113805",
+		h.X.String())
+	assert.Equal(t,
+		"13429057467232557459741298054852631073843465104032416371777143105189743215221",
+		h.Y.String())
+
+	hc := HashCompressed(make([]byte, 32))
+	assert.Equal(t,
+		"37cfc3c92b8721bd82a7aa437c97cb4d7ef88399d666f72cae0d73558f867a2d",
+		hex.EncodeToString(hc[:]))
+
+	hc = HashCompressed([]byte("Hello"))
+	assert.Equal(t,
+		"0e90d7d613ab8b5ea7f4f8bc537db6bb0fa2e5e97bbac1c1f609ef9e6a35fd8b",
+		hex.EncodeToString(hc[:]))
+
+	// more than one segment
+	hc = HashCompressed(
+		[]byte("The quick brown fox jumps over the lazy dog, thirty six bytes more"))
+	assert.Equal(t,
+		"ba05f48630cc5047691fb3768c18db0aa481223dcf2ec05443f9a5cdaf67ce80",
+		hex.EncodeToString(hc[:]))
+	p, err := babyjub.NewPoint().Decompress(hc)
+	require.Nil(t, err)
+	assert.True(t, p.InSubGroup())
+}
+
+func TestHashWindows(t *testing.T) {
+	// a single window with bits b0 b1 b2 and sign b3 maps to
+	// (1 + b0 + 2*b1 + 4*b2) * (-1)^b3 times the first generator
+	for v := 0; v < 16; v++ {
+		m := int64(1 + v&7)
+		if v&8 != 0 {
+			m = -m
+		}
+		s := new(big.Int).Mod(big.NewInt(m), babyjub.SubOrder)
+		expected := babyjub.NewPoint().Mul(s, BasePoint(0))
+		assert.True(t, Hash([]byte{byte(v)}).Equal(
+			babyjub.NewPoint().Add(expected, babyjub.NewPoint().Mul(big.NewInt(32), BasePoint(0)))))
+	}
+}
+
+func BenchmarkHash(b *testing.B) {
+	msg := make([]byte, 32)
+	for i := 0; i < b.N; i++ {
+		Hash(msg)
+	}
+}
@@ -149,6 +149,69 @@ var jsonRaw []byte = []byte(`{
 					"13596762909635538739079656925495736900379091964739248298531655823337482778123"
 				]
 			],
+			"4": [
+				[
+					"11739432287187184656569880828944421268616385874806221589758215824904320817117",
+					"4977258759536702998522229302103997878600602264560359702680165243908162277980",
+					"19167410339349846567561662441069598364702008768579734801591448511131028229281",
+					"14183033936038168803360723133013092560869148726790180682363054735190196956789"
+				],
+				[
+					"16872301185549870956030057498946148102848662396374401407323436343924021192350",
+					"107933704346764130067829474107909495889716688591997879426350582457782826785",
+					"17034139127218860091985397764514160131253018178110701196935786874261236172431",
+					"2799255644797227968811798608332314218966179365168250111693473252876996230317"
+				],
+				[
+					"18618317300596756144100783409915332163189452886691331959651778092154775572832",
+					"13596762909635538739079656925495736900379091964739248298531655823337482778123",
+					"18985203040268814769637347880759846911264240088034262814847924884273017355969",
+					"8652975463545710606098548415650457376967119951977109072274595329619335974180"
+				],
+				[
+					"11128168843135959720130031095451763561052380159981718940182755860433840154182",
+					"2953507793609469112222895633455544691298656192015062835263784675891831794974",
+					"19025623051770008118343718096455821045904242602531062247152770448380880817517",
+					"9077319817220936628089890431129759976815127354480867310384708941479362824016"
+				]
+			],
+			"5": [
+				[
+					"4977258759536702998522229302103997878600602264560359702680165243908162277980",
+					"19167410339349846567561662441069598364702008768579734801591448511131028229281",
+					"14183033936038168803360723133013092560869148726790180682363054735190196956789",
+					"9067734253445064890734144122526450279189023719890032859456830213166173619761",
+					"16378664841697311562845443097199265623838619398287411428110917414833007677155"
+				],
+				[
+					"107933704346764130067829474107909495889716688591997879426350582457782826785",
+					"17034139127218860091985397764514160131253018178110701196935786874261236172431",
+					"2799255644797227968811798608332314218966179365168250111693473252876996230317",
+					"2482058150180648511543788012634934806465808146786082148795902594096349483974",
+					"16563522740626180338295201738437974404892092704059676533096069531044355099628"
+				],
+				[
+					"13596762909635538739079656925495736900379091964739248298531655823337482778123",
+					"18985203040268814769637347880759846911264240088034262814847924884273017355969",
+					"8652975463545710606098548415650457376967119951977109072274595329619335974180",
+					"970943815872417895015626519859542525373809485973005165410533315057253476903",
+					"19406667490568134101658669326517700199745817783746545889094238643063688871948"
+				],
+				[
+					"2953507793609469112222895633455544691298656192015062835263784675891831794974",
+					"19025623051770008118343718096455821045904242602531062247152770448380880817517",
+					"9077319817220936628089890431129759976815127354480867310384708941479362824016",
+					"4770370314098695913091200576539533727214143013236894216582648993741910829490",
+					"4298564056297802123194408918029088169104276109138370115401819933600955259473"
+				],
+				[
+					"8336710468787894148066071988103915091676109272951895469087957569358494947747",
+					"16205238342129310687768799056463408647672389183328001070715567975181364448609",
+					"8303849270045876854140023508764676765932043944545416856530551331270859502246",
+					"20218246699596954048529384569730026273241102596326201163062133863539137060414",
+					"1712845821388089905746651754894206522004527237615042226559791118162382909269"
+				]
+			],
 			"6": [
 				[
 						"19167410339349846567561662441069598364702008768579734801591448511131028229281",
@@ -264,6 +327,88 @@ var jsonRaw []byte = []byte(`{
 						"6200020095464686209289974437830528853749866001482481427982839122465470640886"
 				]
 			],
+			"8": [
+				[
+					"9067734253445064890734144122526450279189023719890032859456830213166173619761",
+					"16378664841697311562845443097199265623838619398287411428110917414833007677155",
+					"12968540216479938138647596899147650021419273189336843725176422194136033835172",
+					"3636162562566338420490575570584278737093584021456168183289112789616069756675",
+					"8949952361235797771659501126471156178804092479420606597426318793013844305422",
+					"13586657904816433080148729258697725609063090799921401830545410130405357110367",
+					"9234644095326950665182299534206533404013403644192586933457524891645396292987",
+					"21716239453658409906539773463855601090749352024072354407676420846971925763352"
+				],
+				[
+					"2482058150180648511543788012634934806465808146786082148795902594096349483974",
+					"16563522740626180338295201738437974404892092704059676533096069531044355099628",
+					"10468644849657689537028565510142839489302836569811003546969773105463051947124",
+					"3328913364598498171733622353010907641674136720305714432354138807013088636408",
+					"8642889650254799419576843603477253661899356105675006557919250564400804756641",
+					"14300697791556510113764686242794463641010174685800128469053974698256194076125",
+					"5585884681068831368957819127799934550116264845072199016558603424861777753252",
+					"3478164595623309231528081170973492360030471123077314602599603198947503453402"
+				],
+				[
+					"970943815872417895015626519859542525373809485973005165410533315057253476903",
+					"19406667490568134101658669326517700199745817783746545889094238643063688871948",
+					"17049854690034965250221386317058877242629221002521630573756355118745574274967",
+					"4964394613021008685803675656098849539153699842663541444414978877928878266244",
+					"15474947305445649466370538888925567099067120578851553103424183520405650587995",
+					"1016119095639665978105768933448186152078842964810837543326777554729232767846",
+					"1094643194372100629123149177218988304969310518086967353237224710253647912217",
+					"19683112286289404632257045032408336402139497606956310649520051095163041093043"
+				],
+				[
+					"4770370314098695913091200576539533727214143013236894216582648993741910829490",
+					"4298564056297802123194408918029088169104276109138370115401819933600955259473",
+					"6905514380186323693285869145872115273350947784558995755916362330070690839131",
+					"4783343257810358393326889022942241108539824540285247795235499223017138301952",
+					"1420772902128122367335354247676760257656541121773854204774788519230732373317",
+					"14172871439045259377975734198064051992755748777535789572469924335100006948373",
+					"701171404446517799603547590964435136387194297039347722754381539762095803416",
+					"9803177017074123807147870516958969882415683917143053879462479514476003798692"
+				],
+				[
+					"20218246699596954048529384569730026273241102596326201163062133863539137060414",
+					"1712845821388089905746651754894206522004527237615042226559791118162382909269",
+					"13001155522144542028910638547179410124467185319212645031214919884423841839406",
+					"16037892369576300958623292723740289861626299352695838577330319504984091062115",
+					"19189494548480259335554606182055502469831573298885662881571444557262020106898",
+					"19032687447778391106390582750185144485341165205399984747451318330476859342654",
+					"12323575831655155253804858088151729263068755350164008078826612164541519408135",
+					"13364062595561633544353642535185185386831615196434150714209983763273382358030"
+				],
+				[
+					"9416416589114508529880440146952102328470363729880726115521103179442988482948",
+					"8035240799672199706102747147502951589635001418759394863664434079699838251138",
+					"21642389080762222565487157652540372010968704000567605990102641816691459811717",
+					"20261355950827657195644012399234591122288573679402601053407151083849785332516",
+					"14514189384576734449268559374569145463190040567900950075547616936149781403109",
+					"19038036134886073991945204537416211699632292792787812530208911676638479944765",
+					"686782683208273499702675091923241011258708712578932107294509288335035332309",
+					"19460483659494742538635058842962321840203437040436001627070209503346220914620"
+				],
+				[
+					"5655785191024506056588710805596292231240948371113351452712848652644610823632",
+					"8265264721707292643644260517162050867559314081394556886644673791575065394002",
+					"17151144681903609082202835646026478898625761142991787335302962548605510241586",
+					"18731644709777529787185361516475509623264209648904603914668024590231177708831",
+					"20697789991623248954020701081488146717484139720322034504511115160686216223641",
+					"6200020095464686209289974437830528853749866001482481427982839122465470640886",
+					"15003643064481014784403977252896879471469342634022488726217418739723899468318",
+					"12647612870405528475535038805212974373775200663189541014169237057917818933775"
+				],
+				[
+					"9322038271681112575390909338686173013663899980505474147882630774122936723770",
+					"19539311024738522891356101949155059335275979719197714163855792975479791830596",
+					"6659076024959487416731638372513310695435146898436979094444558067856073756736",
+					"18638411010780926799370141496147754702830985736366292681854483730874058975603",
+					"13991166219115538669786979327638629497368851557264728153209258584901344742173",
+					"10708464376044593093210642907737038037693199311429347815304064429229497756513",
+					"6487544089495620557439978277791925879985014522759859697996101158123830288903",
+					"2913307273815072522855995578822138088278918070152314785631428419886490815017"
+				]
+			],
 			"9": [
 				[
 				"16378664841697311562845443097199265623838619398287411428110917414833007677155",
@@ -487,6 +632,151 @@ var jsonRaw []byte = []byte(`{
 				"6097212856823059610806594870167172449630826014160149958358449182659226854683"
 				]
 			],
+			"11": [
+				[
+					"3636162562566338420490575570584278737093584021456168183289112789616069756675",
+					"8949952361235797771659501126471156178804092479420606597426318793013844305422",
+					"13586657904816433080148729258697725609063090799921401830545410130405357110367",
+					"9234644095326950665182299534206533404013403644192586933457524891645396292987",
+					"21716239453658409906539773463855601090749352024072354407676420846971925763352",
+					"4217850196621719492070441371114581340961962601115446718610695075341064782843",
+					"14091314373946770079087815723744110798105826012788667211882320191290756637054",
+					"1836151420849876287257251498242729836824311746824392923242828663131666580828",
+					"6835788420848335173243495671810987843103243246225527562249885075005140362623",
+					"1980065348636533793938224420722478561090879463814453742159951744016644584724",
+					"15713403866108081374909941292970437652990369272785906691248434303775169969543"
+				],
+				[
+					"3328913364598498171733622353010907641674136720305714432354138807013088636408",
+					"8642889650254799419576843603477253661899356105675006557919250564400804756641",
+					"14300697791556510113764686242794463641010174685800128469053974698256194076125",
+					"5585884681068831368957819127799934550116264845072199016558603424861777753252",
+					"3478164595623309231528081170973492360030471123077314602599603198947503453402",
+					"7199924820941799838017782197767573398800843214159492464366697425752021794207",
+					"1154943146689858448412819104632261733949486281652220573359100064807135186211",
+					"2014307020840030171599981482679561995491247943968618411317941095661736771072",
+					"3570239959937051869699597646596776576100477379279696986371716806585418148999",
+					"6444436284087049954311426326470253493866988349227325357692234777181945302138",
+					"4084181522452846717426267118401257065171304838427595913046458801675418547946"
+				],
+				[
+					"4964394613021008685803675656098849539153699842663541444414978877928878266244",
+					"15474947305445649466370538888925567099067120578851553103424183520405650587995",
+					"1016119095639665978105768933448186152078842964810837543326777554729232767846",
+					"1094643194372100629123149177218988304969310518086967353237224710253647912217",
+					"19683112286289404632257045032408336402139497606956310649520051095163041093043",
+					"15078463390585580460701632423762128113753337560111211686732764674670454433375",
+					"1801502535278136950711454362447206750370371584220726360504187134310004854946",
+					"8621790518781836243437824920511539973165116610755426647487428365048155246615",
+					"12942512140539042341676889757219435053433203623152024989066217867030059451362",
+					"20440428457116895728643297727585714505972968360880851439871840763004876047842",
+					"501563077712479629714853317258969081525260934521262312772885253926721582762"
+				],
+				[
+					"4783343257810358393326889022942241108539824540285247795235499223017138301952",
+					"1420772902128122367335354247676760257656541121773854204774788519230732373317",
+					"14172871439045259377975734198064051992755748777535789572469924335100006948373",
+					"701171404446517799603547590964435136387194297039347722754381539762095803416",
+					"9803177017074123807147870516958969882415683917143053879462479514476003798692",
+					"10882504770686057070666901469152010168883484553788707224519875015429176286468",
+					"16116223334794707241932949543829423645032061244136000915663484670112660674335",
+					"1050574705143710849158660280515783436048703475592206287328683483437386496342",
+					"3193766187369768098819373128520920194995009659905354541728591134729338630146",
+					"5442308134552484158006176363860250847803335445074346356521667371941439320753",
+					"11191223378227773223878730222746316529427664100909744690819477729631161942363"
+				],
+				[
+					"16037892369576300958623292723740289861626299352695838577330319504984091062115",
+					"19189494548480259335554606182055502469831573298885662881571444557262020106898",
+					"19032687447778391106390582750185144485341165205399984747451318330476859342654",
+					"12323575831655155253804858088151729263068755350164008078826612164541519408135",
+					"13364062595561633544353642535185185386831615196434150714209983763273382358030",
+					"9274699025052388999143876606332457090594226076618918017186910082987520766620",
+					"21666017696927606866900803180167159825580415253627077790152277311026667245774",
+					"19428861633624669932732235043823844526934683635426508396069006262580783292098",
+					"349092129900167299852086304168956780686281800973047308710033408193673557137",
+					"9891605682735775701372497220084495703059875491976279694547892411730413275106",
+					"9572033480346284888842892814742041052680607489312867118792896402407551781000"
+				],
+				[
+					"20261355950827657195644012399234591122288573679402601053407151083849785332516",
+					"14514189384576734449268559374569145463190040567900950075547616936149781403109",
+					"19038036134886073991945204537416211699632292792787812530208911676638479944765",
+					"686782683208273499702675091923241011258708712578932107294509288335035332309",
+					"19460483659494742538635058842962321840203437040436001627070209503346220914620",
+					"2717363541929281429425363151363573812700929689092742076307025102432122849113",
+					"592856464239577084277812148344474334229160123467784799518401396518621499307",
+					"19605232505714887411140533539156676078023064817112556974818688529720554103027",
+					"17281837796802566248553868696561647661575293017929540397986609448489469656408",
+					"5179101331705486631216523439406626504427859861086380006880198353271403634284",
+					"21494373900212553862269598166466369411014604306433901007975656990265100086773"
+				],
+				[
+					"18731644709777529787185361516475509623264209648904603914668024590231177708831",
+					"20697789991623248954020701081488146717484139720322034504511115160686216223641",
+					"6200020095464686209289974437830528853749866001482481427982839122465470640886",
+					"15003643064481014784403977252896879471469342634022488726217418739723899468318",
+					"12647612870405528475535038805212974373775200663189541014169237057917818933775",
+					"4495592920221816845183500919899258791575337853955422662005352518056111526526",
+					"17016589944599426232470877677784553412045023522564439128359954090088542925927",
+					"7427163762123037666168877552179990976257321514817431071386277873217974716214",
+					"6954714365751319627532260089365398510597789423888128188300193742623440858991",
+					"20741354368082668749519294669330263800034959643576788680679721465758396340655",
+					"8690392737093296508596420240113481624211167630103821946341025063336615861750"
+				],
+				[
+					"18638411010780926799370141496147754702830985736366292681854483730874058975603",
+					"13991166219115538669786979327638629497368851557264728153209258584901344742173",
+					"10708464376044593093210642907737038037693199311429347815304064429229497756513",
+					"6487544089495620557439978277791925879985014522759859697996101158123830288903",
+					"2913307273815072522855995578822138088278918070152314785631428419886490815017",
+					"14285037819197997716648396320167175389429136413217640368836681114764148118185",
+					"3333794576395592518925638954760517866119123399586466538142440796174614359894",
+					"3845776014605207395343871969433626371796105685015639546701817312715048078273",
+					"9263887251631740182673249586528554091206122902651331921320275863021853816005",
+					"14516758205336303628030971413987867488663371869161580208931064798637853946497",
+					"15357311091575984266241753426851379448502423574157741469776854720990327180318"
+				],
+				[
+					"18410998549604629667288121409987879184434965955790116388599455426890628819225",
+					"21124868883310895249704508909746850568648918888363322451096318445789496113765",
+					"3729190893061666874168977650023409592233396533084615654829157344975623505524",
+					"10648014247890130944143499130156012552468488977293052246766339673997837070992",
+					"9568023470137472494284353113734549348684200769702589788112091125656147667116",
+					"3536927330244885178374691992819629480677300039684470529320471913652629606838",
+					"11398590172899810645820530606484864595574598270604175688862890426075002823331",
+					"13867154112143118575451613166385157242483631693788556304796979714601785936825",
+					"15587970419040244702732665316366587844861765432258110249032770438196575054494",
+					"1760728291310754125712539670786268511398339681699173022567759629151538940271",
+					"10983112402223375904816148852700278466880680448249842946746264282026432805356"
+				],
+				[
+					"15065949325738665978704380810964125491600291026570726386996048869310110891732",
+					"15565208172936548126814901061215221899993229204075026231475743356766667186275",
+					"14273341415389175002732931022197371408910107356935395501556639778517250463216",
+					"5902907000654141499635699176721929039612928718177921573754626287345808685856",
+					"203666313136774023325316072654501128468371734762708925063627487502421439094",
+					"9450682786498823752891980662275296997029499323840362934742782429277244701264",
+					"5845919573782750816116964311797087944211904659910565804455233427824994872877",
+					"2620768939180089200997122995060391898943652408298747707765440591474859977536",
+					"6097212856823059610806594870167172449630826014160149958358449182659226854683",
+					"21035421258088434187064210132510536027666832877207574626482275926069854695763",
+					"13051875927006043184532456259732675608112064860752811133476026158472953275617"
+				],
+				[
+					"472457522600255640988723379286302816100381459868930319714328105195226075021",
+					"7324527588398529369832404971572555764840381229220960043028736393060929031165",
+					"16180780920520829787590397461786386727911681900904540409095357904584006980425",
+					"11923552431018057977761262750896735278348525198229774660021082286179569562761",
+					"16103000669516568314626924758129945191156182821310825343179071305812017533451",
+					"6703384075365774580846496809352382349237535411108756946275887008878257647636",
+					"8320969168029750438001524325485404349446296049650925704636382736628876200413",
+					"7711442374668991871896332031308406364421360151841877944011644435604249756323",
+					"4858024683814982254309886653697281467687205888514801303577930237690860071670",
+					"21452852726519764756830618853858002743210980897675031341247154775030934599037",
+					"13833240608470544540225953613275063421934297305979294165453842748748438594425"
+				]
+			],
 			"12": [
 				[
 				"8949952361235797771659501126471156178804092479420606597426318793013844305422",
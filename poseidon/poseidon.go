@@ -2,7 +2,7 @@ package poseidon
 
 import (
 	// "errors"
-	// "fmt"
+	"fmt"
 	"math/big"
 
 	"github.com/iden3/go-iden3-crypto/ff"
@@ -14,7 +14,7 @@ const NROUNDSF = 6 //nolint:golint
 // For loopring only.
 var NROUNDSP = 53
 // if input is in this map, use corresponding value as NROUNDSP
-var NROUNDSPMAP = map[int]int{6: 52, 7:52}
+var NROUNDSPMAP = map[int]int{5: 52, 6: 52, 7:52}
 
 func zero() *ff.Element {
 	return ff.NewElement()
@@ -56,20 +56,11 @@ func mix(state []*ff.Element, newState []*ff.Element, m [][]*ff.Element) {
 	}
 }
 
-// Hash computes the Poseidon hash for the given inputs
-func Hash(inpBI []*big.Int) (*big.Int, error) {
-	t := len(inpBI) + 1
-	// if len(inpBI) == 0 || len(inpBI) >= len(NROUNDSP)-1 {
-	// 	return nil, fmt.Errorf("invalid inputs length %d, max %d", len(inpBI), len(NROUNDSP)-1)
-	// }
-	// if !utils.CheckBigIntArrayInField(inpBI[:]) {
-	// 	return nil, errors.New("inputs values not inside Finite Field")
-	// }
-	inp := utils.BigIntArrayToElementArray(inpBI[:])
-	state := make([]*ff.Element, t)
-	state[t-1] = zero()
-	copy(state[:t], inp[:])
-
+// permute applies the Poseidon permutation to the state, whose length is the
+// width t, and returns the permuted state.  The given state slice is used as
+// scratch space.
+func permute(state []*ff.Element) []*ff.Element {
+	t := len(state)
 	nRoundsF := NROUNDSF
 	var nRoundsP = NROUNDSP
 	nSp, ok := NROUNDSPMAP[t]
@@ -89,6 +80,27 @@ func Hash(inpBI []*big.Int) (*big.Int, error) {
 		mix(state, newState, c.m[t])
 		state, newState = newState, state
 	}
+	return state
+}
+
+// Hash computes the Poseidon hash for the given inputs
+func Hash(inpBI []*big.Int) (*big.Int, error) {
+	t := len(inpBI) + 1
+	// if len(inpBI) == 0 || len(inpBI) >= len(NROUNDSP)-1 {
+	// 	return nil, fmt.Errorf("invalid inputs length %d, max %d", len(inpBI), len(NROUNDSP)-1)
+	// }
+	// if !utils.CheckBigIntArrayInField(inpBI[:]) {
+	// 	return nil, errors.New("inputs values not inside Finite Field")
+	// }
+	if _, ok := c.m[t]; !ok {
+		return nil, fmt.Errorf("invalid inputs length %d, max %d", len(inpBI), len(c.m))
+	}
+	inp := utils.BigIntArrayToElementArray(inpBI[:])
+	state := make([]*ff.Element, t)
+	state[t-1] = zero()
+	copy(state[:t], inp[:])
+
+	state = permute(state)
 	rE := state[0]
 	r := big.NewInt(0)
 	rE.ToBigIntRegular(r)
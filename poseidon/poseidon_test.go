@@ -47,6 +47,31 @@ func TestPoseidonHash(t *testing.T) {
 		h.String())
 }
 
+func TestPoseidonHashWidths(t *testing.T) {
+	inputs := func(n int) []*big.Int {
+		inp := make([]*big.Int, n)
+		for i := range inp {
+			inp[i] = big.NewInt(int64(i + 1))
+		}
+		return inp
+	}
+	for n, expected := range map[int]string{
+		3:  "19801411534570191360806271694822043743785399107804606088620884793905779339737",
+		4:  "8944410529251910607972990650111588127512667948963861847670132342989949661539",
+		7:  "15263416922092390037374216785412251361791064323653253134600726157998896829522",
+		10: "5217080618200396640243389053165791253737170670685373530987815110536983904485",
+	} {
+		h, err := Hash(inputs(n))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, h.String())
+	}
+
+	for n := 1; n <= 12; n++ {
+		_, err := Hash(inputs(n))
+		assert.Nil(t, err)
+	}
+}
+
 func TestBigIntPoseidonHash(t *testing.T) {
 	b0, ok := big.NewInt(0).SetString("69588426711107115100232500042334179657931174539151555867956034570704220523596", 10)
 	assert.True(t, ok)
@@ -63,16 +88,16 @@ func TestErrorInputs(t *testing.T) {
 	b1 := big.NewInt(1)
 	b2 := big.NewInt(2)
 
-	_, err := Hash([]*big.Int{b1, b2, b0, b0, b0, b0})
+	_, err := Hash([]*big.Int{b1, b2, b0, b0, b0, b0, b0, b0, b0, b0, b0, b0})
 	assert.Nil(t, err)
 
-	_, err = Hash([]*big.Int{b1, b2, b0, b0, b0, b0, b0})
+	_, err = Hash([]*big.Int{b1, b2, b0, b0, b0, b0, b0, b0, b0, b0, b0, b0, b0})
 	assert.NotNil(t, err)
-	assert.Equal(t, "invalid inputs length 7, max 7", err.Error())
+	assert.Equal(t, "invalid inputs length 13, max 12", err.Error())
 
-	_, err = Hash([]*big.Int{b1, b2, b0, b0, b0, b0, b0, b0})
+	_, err = Hash([]*big.Int{})
 	assert.NotNil(t, err)
-	assert.Equal(t, "invalid inputs length 8, max 7", err.Error())
+	assert.Equal(t, "invalid inputs length 0, max 12", err.Error())
 }
 
 func BenchmarkPoseidonHash(b *testing.B) {
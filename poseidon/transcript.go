@@ -0,0 +1,153 @@
+package poseidon
+
+import (
+	"math/big"
+
+	crypto "github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/ff"
+)
+
+// Transcript is a Fiat-Shamir transcript built on a duplex sponge over the
+// Poseidon permutation of width 6: the first 5 elements of the state are the
+// rate, where the inputs are added, and the last one is the capacity.  The
+// state is permuted when the rate is full and before squeezing each
+// challenge, so a circuit can recompute the challenges with one permutation
+// per 5 absorbed elements.
+//
+// Every operation absorbs an operation tag, its label and its data, so
+// transcripts with different sequences of operations or labels diverge.
+// Labels and byte strings are absorbed as their length followed by chunks of
+// 31 bytes read as big-endian integers.
+type Transcript struct {
+	state []*ff.Element
+	pos   int
+}
+
+const (
+	transcriptWidth = 6
+	transcriptRate  = transcriptWidth - 1
+	// bytesPerElement is the number of bytes packed in each field element.
+	bytesPerElement = 31
+)
+
+// Operation tags absorbed before the label of each operation.
+const (
+	opInit = iota + 1
+	opScalar
+	opPoint
+	opBytes
+	opChallenge
+	opFork
+)
+
+// Point is a curve point with affine coordinates.  It is implemented by
+// *babyjub.Point, which can't be referenced here as babyjub imports poseidon.
+type Point interface {
+	Coordinates() (x, y *big.Int)
+}
+
+// NewTranscript returns a new transcript initialized with the protocol label.
+func NewTranscript(label string) *Transcript {
+	t := &Transcript{state: make([]*ff.Element, transcriptWidth)}
+	for i := range t.state {
+		t.state[i] = zero()
+	}
+	t.absorbOp(opInit, label)
+	return t
+}
+
+// absorb adds the element to the next position of the rate, permuting the
+// state first if the rate is full.
+func (t *Transcript) absorb(e *ff.Element) {
+	if t.pos == transcriptRate {
+		t.state = permute(t.state)
+		t.pos = 0
+	}
+	t.state[t.pos].Add(t.state[t.pos], e)
+	t.pos++
+}
+
+func (t *Transcript) absorbUint64(v uint64) {
+	t.absorb(ff.NewElement().SetUint64(v))
+}
+
+func (t *Transcript) absorbBigInt(v *big.Int) {
+	t.absorb(ff.NewElement().SetBigInt(v))
+}
+
+func (t *Transcript) absorbBytes(b []byte) {
+	t.absorbUint64(uint64(len(b)))
+	for i := 0; i < len(b); i += bytesPerElement {
+		end := i + bytesPerElement
+		if end > len(b) {
+			end = len(b)
+		}
+		t.absorbBigInt(new(big.Int).SetBytes(b[i:end]))
+	}
+}
+
+func (t *Transcript) absorbOp(op uint64, label string) {
+	t.absorbUint64(op)
+	t.absorbBytes([]byte(label))
+}
+
+// AppendScalar appends the field element s with the label.  s is reduced
+// modulo Q, so it must be canonical for the encoding to be unambiguous.
+func (t *Transcript) AppendScalar(label string, s *big.Int) {
+	t.absorbOp(opScalar, label)
+	t.absorbBigInt(s)
+}
+
+// AppendPoint appends the coordinates of the point p with the label.
+func (t *Transcript) AppendPoint(label string, p Point) {
+	x, y := p.Coordinates()
+	t.absorbOp(opPoint, label)
+	t.absorbBigInt(x)
+	t.absorbBigInt(y)
+}
+
+// AppendBytes appends the byte string b with the label.
+func (t *Transcript) AppendBytes(label string, b []byte) {
+	t.absorbOp(opBytes, label)
+	t.absorbBytes(b)
+}
+
+// Challenge squeezes a challenge, a field element in [0, Q), with the label.
+func (t *Transcript) Challenge(label string) *big.Int {
+	t.absorbOp(opChallenge, label)
+	t.state = permute(t.state)
+	t.pos = 0
+	r := big.NewInt(0)
+	t.state[0].ToBigIntRegular(r)
+	return r
+}
+
+// ChallengeScalar squeezes a challenge with the label and reduces it modulo
+// order, which is usually babyjub.SubOrder for challenges used as scalars of
+// the curve, or Q.  A nil order means Q.
+func (t *Transcript) ChallengeScalar(label string, order *big.Int) *big.Int {
+	if order == nil {
+		order = crypto.Q
+	}
+	c := t.Challenge(label)
+	return c.Mod(c, order)
+}
+
+// Clone returns an independent copy of the transcript.
+func (t *Transcript) Clone() *Transcript {
+	t2 := &Transcript{state: make([]*ff.Element, transcriptWidth), pos: t.pos}
+	for i := range t.state {
+		t2.state[i] = ff.NewElement()
+		*t2.state[i] = *t.state[i]
+	}
+	return t2
+}
+
+// Fork returns a copy of the transcript with the label appended, so that the
+// fork diverges from the transcript and from forks with other labels.  The
+// transcript itself is not modified.
+func (t *Transcript) Fork(label string) *Transcript {
+	t2 := t.Clone()
+	t2.absorbOp(opFork, label)
+	return t2
+}
@@ -0,0 +1,99 @@
+package poseidon
+
+import (
+	"math/big"
+	"testing"
+
+	crypto "github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPoint struct {
+	x, y *big.Int
+}
+
+func (p testPoint) Coordinates() (*big.Int, *big.Int) {
+	return p.x, p.y
+}
+
+func TestTranscriptPermutation(t *testing.T) {
+	// with the empty labels, the init and challenge operations absorb
+	// [opInit, 0, opChallenge, 0] in a single permutation
+	c := NewTranscript("").Challenge("")
+	h, err := Hash([]*big.Int{big.NewInt(opInit), big.NewInt(0),
+		big.NewInt(opChallenge), big.NewInt(0), big.NewInt(0)})
+	assert.Nil(t, err)
+	assert.Equal(t, h, c)
+}
+
+func TestTranscriptDeterministic(t *testing.T) {
+	build := func() *Transcript {
+		tr := NewTranscript("test")
+		tr.AppendScalar("s", big.NewInt(42))
+		tr.AppendPoint("p", testPoint{big.NewInt(1), big.NewInt(2)})
+		tr.AppendBytes("b", []byte("a message longer than thirty one bytes"))
+		return tr
+	}
+	t1, t2 := build(), build()
+	c1 := t1.Challenge("c")
+	assert.Equal(t, c1, t2.Challenge("c"))
+	assert.True(t, c1.Cmp(crypto.Q) < 0)
+
+	// successive challenges differ
+	c2 := t1.Challenge("c")
+	assert.NotEqual(t, c1, c2)
+	assert.Equal(t, c2, t2.Challenge("c"))
+}
+
+func TestTranscriptDomainSeparation(t *testing.T) {
+	challenge := func(f func(tr *Transcript)) *big.Int {
+		tr := NewTranscript("test")
+		f(tr)
+		return tr.Challenge("c")
+	}
+	cs := []*big.Int{
+		challenge(func(tr *Transcript) {}),
+		challenge(func(tr *Transcript) { tr.AppendScalar("s", big.NewInt(1)) }),
+		challenge(func(tr *Transcript) { tr.AppendScalar("t", big.NewInt(1)) }),
+		challenge(func(tr *Transcript) { tr.AppendScalar("s", big.NewInt(2)) }),
+		challenge(func(tr *Transcript) { tr.AppendBytes("s", []byte{1}) }),
+		challenge(func(tr *Transcript) { tr.AppendBytes("s", []byte{0, 1}) }),
+		challenge(func(tr *Transcript) { tr.AppendPoint("s", testPoint{big.NewInt(1), big.NewInt(0)}) }),
+		challenge(func(tr *Transcript) {
+			tr.AppendScalar("s", big.NewInt(1))
+			tr.AppendScalar("s", big.NewInt(0))
+		}),
+	}
+	for i := range cs {
+		for j := i + 1; j < len(cs); j++ {
+			assert.NotEqual(t, cs[i], cs[j], "%d %d", i, j)
+		}
+	}
+	assert.NotEqual(t, NewTranscript("a").Challenge("c"), NewTranscript("b").Challenge("c"))
+	assert.NotEqual(t, NewTranscript("a").Challenge("c"), NewTranscript("a").Challenge("d"))
+}
+
+func TestTranscriptFork(t *testing.T) {
+	tr := NewTranscript("test")
+	tr.AppendScalar("s", big.NewInt(7))
+	f1 := tr.Fork("1")
+	f2 := tr.Fork("2")
+	f1b := tr.Fork("1")
+
+	c := tr.Clone().Challenge("c")
+	c1 := f1.Challenge("c")
+	assert.NotEqual(t, c, c1)
+	assert.NotEqual(t, c1, f2.Challenge("c"))
+	assert.Equal(t, c1, f1b.Challenge("c"))
+
+	// the forks don't modify the transcript
+	assert.Equal(t, c, tr.Challenge("c"))
+}
+
+func TestTranscriptChallengeScalar(t *testing.T) {
+	order := big.NewInt(1000)
+	tr := NewTranscript("test")
+	c := tr.Clone().Challenge("c")
+	assert.Equal(t, new(big.Int).Mod(c, order), tr.Clone().ChallengeScalar("c", order))
+	assert.Equal(t, c, tr.ChallengeScalar("c", nil))
+}
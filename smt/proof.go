@@ -0,0 +1,232 @@
+package smt
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+const (
+	// elemBytesLen is the length of an encoded field element.
+	elemBytesLen = 32
+	// proofFlagsLen is the length of the flags and depth of an encoded
+	// proof, before the bitmap of the non-empty siblings.
+	proofFlagsLen = 2
+)
+
+// NodeAux is the leaf found at the path of the key of a non-membership
+// proof, when the path doesn't end in an empty node.
+type NodeAux struct {
+	Key   *big.Int
+	Value *big.Int
+}
+
+// Proof is a membership or non-membership proof of a key.  Only the
+// non-empty siblings are kept, with a bitmap of the levels that have them.
+type Proof struct {
+	// Existence is true for a membership proof.
+	Existence bool
+	// Siblings are the non-empty siblings from the root down.
+	Siblings []*big.Int
+	// NodeAux is the leaf found instead of the key in a non-membership
+	// proof, if any.
+	NodeAux    *NodeAux
+	depth      int
+	notEmpties [elemBytesLen - proofFlagsLen]byte
+}
+
+// testBit returns whether the bit n of the big-endian bitmap is set.
+func testBit(bitmap []byte, n int) bool {
+	return bitmap[len(bitmap)-n/8-1]&(1<<uint(n%8)) != 0
+}
+
+// setBit sets the bit n of the big-endian bitmap.
+func setBit(bitmap []byte, n int) {
+	bitmap[len(bitmap)-n/8-1] |= 1 << uint(n%8)
+}
+
+// GenerateProof returns the membership proof of the key k if it is in the
+// tree, or its non-membership proof otherwise.
+func (t *Tree) GenerateProof(k *big.Int) (*Proof, error) {
+	siblings, n, err := t.walk(k)
+	if err != nil {
+		return nil, err
+	}
+	p := &Proof{depth: len(siblings)}
+	for lvl, s := range siblings {
+		if s.Sign() != 0 {
+			setBit(p.notEmpties[:], lvl)
+			p.Siblings = append(p.Siblings, new(big.Int).Set(s))
+		}
+	}
+	if n != nil {
+		if n.key.Cmp(k) == 0 {
+			p.Existence = true
+		} else {
+			p.NodeAux = &NodeAux{Key: new(big.Int).Set(n.key), Value: new(big.Int).Set(n.value)}
+		}
+	}
+	return p, nil
+}
+
+// Depth returns the depth of the node at the end of the proof path.
+func (p *Proof) Depth() int { return p.depth }
+
+// countNotEmpties returns the number of non-empty siblings in the bitmap.
+func (p *Proof) countNotEmpties() int {
+	n := 0
+	for lvl := 0; lvl < p.depth; lvl++ {
+		if testBit(p.notEmpties[:], lvl) {
+			n++
+		}
+	}
+	return n
+}
+
+// AllSiblings returns the siblings from the root down, including the empty
+// ones.
+func (p *Proof) AllSiblings() []*big.Int {
+	siblings := make([]*big.Int, p.depth)
+	i := 0
+	for lvl := range siblings {
+		if testBit(p.notEmpties[:], lvl) {
+			siblings[lvl] = p.Siblings[i]
+			i++
+		} else {
+			siblings[lvl] = big.NewInt(0)
+		}
+	}
+	return siblings
+}
+
+// RootFromProof returns the root that the proof gives for the key k, with
+// value v for a membership proof.  A nil hasher defaults to PoseidonHasher.
+func RootFromProof(hasher Hasher, p *Proof, k, v *big.Int) (*big.Int, error) {
+	if hasher == nil {
+		hasher = PoseidonHasher
+	}
+	if p.depth > MaxLevels-1 {
+		return nil, fmt.Errorf("proof depth %d larger than %d", p.depth, MaxLevels-1)
+	}
+	if n := p.countNotEmpties(); n != len(p.Siblings) {
+		return nil, fmt.Errorf("proof with %d siblings, bitmap has %d", len(p.Siblings), n)
+	}
+	var h *big.Int
+	var err error
+	switch {
+	case p.Existence:
+		h, err = hasher.HashLeaf(k, v)
+	case p.NodeAux != nil:
+		if p.NodeAux.Key.Cmp(k) == 0 {
+			return nil, fmt.Errorf("non-membership proof with a leaf of the same key")
+		}
+		h, err = hasher.HashLeaf(p.NodeAux.Key, p.NodeAux.Value)
+	default:
+		h = big.NewInt(0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pth := path(p.depth, k)
+	siblings := p.AllSiblings()
+	for lvl := p.depth - 1; lvl >= 0; lvl-- {
+		if pth[lvl] {
+			h, err = hasher.HashNode(siblings[lvl], h)
+		} else {
+			h, err = hasher.HashNode(h, siblings[lvl])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// VerifyProof verifies the proof of the key k, with value v for a
+// membership proof, against the root.  A nil hasher defaults to
+// PoseidonHasher.
+func VerifyProof(hasher Hasher, root *big.Int, p *Proof, k, v *big.Int) bool {
+	r, err := RootFromProof(hasher, p, k, v)
+	return err == nil && r.Cmp(root) == 0
+}
+
+// Bytes returns the encoding of the proof of go-merkletree-sql: a byte of
+// flags (0x01 for non-membership, 0x02 with NodeAux), a byte with the depth,
+// the bitmap of the non-empty siblings, the non-empty siblings and the key
+// and value of NodeAux, with the field elements in 32 bytes little-endian.
+func (p *Proof) Bytes() []byte {
+	bs := make([]byte, proofFlagsLen, elemBytesLen*(1+len(p.Siblings)+2)) //nolint:gomnd
+	if !p.Existence {
+		bs[0] |= 0x01
+	}
+	bs[1] = byte(p.depth)
+	bs = append(bs, p.notEmpties[:]...)
+	for _, s := range p.Siblings {
+		e := utils.BigIntLEBytes(s)
+		bs = append(bs, e[:]...)
+	}
+	if p.NodeAux != nil {
+		bs[0] |= 0x02
+		k, v := utils.BigIntLEBytes(p.NodeAux.Key), utils.BigIntLEBytes(p.NodeAux.Value)
+		bs = append(bs, k[:]...)
+		bs = append(bs, v[:]...)
+	}
+	return bs
+}
+
+// NewProofFromBytes decodes a proof encoded with Bytes.
+func NewProofFromBytes(bs []byte) (*Proof, error) {
+	if len(bs) < elemBytesLen || len(bs)%elemBytesLen != 0 {
+		return nil, fmt.Errorf("invalid proof length %d", len(bs))
+	}
+	p := &Proof{Existence: bs[0]&0x01 == 0, depth: int(bs[1])}
+	copy(p.notEmpties[:], bs[proofFlagsLen:elemBytesLen])
+	elems := make([]*big.Int, 0, len(bs)/elemBytesLen-1)
+	for i := elemBytesLen; i < len(bs); i += elemBytesLen {
+		e := utils.SetBigIntFromLEBytes(new(big.Int), bs[i:i+elemBytesLen])
+		if !utils.CheckBigIntInField(e) {
+			return nil, fmt.Errorf("proof element not inside the finite field")
+		}
+		elems = append(elems, e)
+	}
+	if p.depth > MaxLevels-1 {
+		return nil, fmt.Errorf("proof depth %d larger than %d", p.depth, MaxLevels-1)
+	}
+	n := p.countNotEmpties()
+	aux := bs[0]&0x02 != 0
+	if p.Existence && aux {
+		return nil, fmt.Errorf("membership proof with NodeAux")
+	}
+	want := n
+	if aux {
+		want += 2
+	}
+	if len(elems) != want {
+		return nil, fmt.Errorf("proof with %d elements, want %d", len(elems), want)
+	}
+	p.Siblings = elems[:n]
+	if aux {
+		p.NodeAux = &NodeAux{Key: elems[n], Value: elems[n+1]}
+	}
+	return p, nil
+}
+
+// MarshalText implements the marshaler for the Proof, as the hex of Bytes.
+func (p Proof) MarshalText() ([]byte, error) {
+	return utils.Hex(p.Bytes()).MarshalText()
+}
+
+// UnmarshalText implements the unmarshaler for the Proof
+func (p *Proof) UnmarshalText(h []byte) error {
+	bs, err := utils.HexDecode(string(h))
+	if err != nil {
+		return err
+	}
+	p2, err := NewProofFromBytes(bs)
+	if err != nil {
+		return err
+	}
+	*p = *p2
+	return nil
+}
@@ -0,0 +1,460 @@
+// Package smt implements a sparse Merkle tree of key-value pairs of field
+// elements, with the layout of iden3's go-merkletree-sql: each leaf sits at
+// the shallowest level where its path, the bits of the key from the least
+// significant one (0 for left and 1 for right), is not shared with any other
+// leaf, middle nodes hash as H(left, right), and empty nodes are 0.
+//
+// The node hasher is pluggable.  HashFunc(h) hashes exactly as
+// go-merkletree-sql does with the hash function h, the leaves as
+// h(key, value, 1), so the roots are the same given the same hash function.
+// Note that the Poseidon of this module uses different parameters than the
+// circomlib Poseidon of go-merkletree-sql.
+//
+// The default PoseidonHasher doesn't use the H(key, value, 1) leaf layout of
+// go-merkletree-sql: it hashes the leaves as Poseidon(key, value, 1, 0, 0),
+// which the trees built with the first versions of this package have in
+// their roots.  Use HashFunc(poseidon.Hash) for the go-merkletree-sql layout.
+//
+// The nodes are kept in a storage.Storage by hash, and never removed, so a
+// tree can be persisted and reopened with a file storage, and any of its
+// previous roots can be restored or opened as a snapshot.
+package smt
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/merkle/storage"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// MaxLevels is the maximum number of levels of a tree, limited by the
+// bitmap of the non-empty siblings of the proofs.
+const MaxLevels = 8 * (elemBytesLen - proofFlagsLen)
+
+var (
+	// ErrKeyNotFound is returned when the key is not in the tree.
+	ErrKeyNotFound = errors.New("key not found in the tree")
+	// ErrKeyAlreadyExists is returned when adding a key that is already in
+	// the tree.
+	ErrKeyAlreadyExists = errors.New("key already exists in the tree")
+	// ErrReachedMaxLevel is returned when a new leaf would be deeper than
+	// the levels of the tree, because its path is shared with another leaf.
+	ErrReachedMaxLevel = errors.New("reached the maximum level of the tree")
+	// ErrSnapshot is returned when modifying a snapshot.
+	ErrSnapshot = errors.New("snapshots are read-only")
+)
+
+// Hasher computes the hashes of the nodes of the tree.
+type Hasher interface {
+	// HashLeaf returns the hash of the leaf with key k and value v.
+	HashLeaf(k, v *big.Int) (*big.Int, error)
+	// HashNode returns the hash of the middle node with children l and r.
+	HashNode(l, r *big.Int) (*big.Int, error)
+}
+
+// HashFunc is a Hasher that hashes the leaves as h(k, v, 1) and the middle
+// nodes as h(l, r), as go-merkletree-sql does.
+type HashFunc func([]*big.Int) (*big.Int, error)
+
+// HashLeaf implements the Hasher interface.
+func (h HashFunc) HashLeaf(k, v *big.Int) (*big.Int, error) {
+	return h([]*big.Int{k, v, big.NewInt(1)})
+}
+
+// HashNode implements the Hasher interface.
+func (h HashFunc) HashNode(l, r *big.Int) (*big.Int, error) {
+	return h([]*big.Int{l, r})
+}
+
+type poseidonHasher struct{}
+
+// HashLeaf hashes the leaf as Poseidon(k, v, 1, 0, 0), the leaf hash of the
+// first versions of this package, kept so that the roots of their trees
+// don't change.  HashFunc(poseidon.Hash) hashes it as Poseidon(k, v, 1).
+func (poseidonHasher) HashLeaf(k, v *big.Int) (*big.Int, error) {
+	return poseidon.Hash([]*big.Int{k, v, big.NewInt(1), big.NewInt(0), big.NewInt(0)})
+}
+
+func (poseidonHasher) HashNode(l, r *big.Int) (*big.Int, error) {
+	return poseidon.Hash([]*big.Int{l, r})
+}
+
+// PoseidonHasher is the default Hasher, with poseidon.Hash and the 5 inputs
+// leaf hash.
+var PoseidonHasher Hasher = poseidonHasher{}
+
+type nodeType byte
+
+const (
+	nodeTypeMiddle nodeType = iota
+	nodeTypeLeaf
+)
+
+// node is a non-empty node of the tree: a middle node with its children, or
+// a leaf with its key and value.
+type node struct {
+	typ         nodeType
+	left, right *big.Int
+	key, value  *big.Int
+}
+
+// rootKey is the key of the current root in the storage, which can't clash
+// with the 32 bytes keys of the nodes.
+var rootKey = []byte("root")
+
+// Tree is a sparse Merkle tree.
+type Tree struct {
+	levels   int
+	hasher   Hasher
+	db       storage.Storage
+	root     *big.Int
+	snapshot bool
+}
+
+// New returns an empty tree in memory with the given number of levels, at
+// most MaxLevels, and hasher.  A nil hasher defaults to PoseidonHasher.
+func New(levels int, hasher Hasher) (*Tree, error) {
+	return NewWithStorage(storage.NewMemory(), levels, hasher)
+}
+
+// NewWithStorage returns the tree in the storage, which is empty if the
+// storage is new, with the given number of levels, at most MaxLevels, and
+// hasher.  A nil hasher defaults to PoseidonHasher.
+func NewWithStorage(db storage.Storage, levels int, hasher Hasher) (*Tree, error) {
+	if levels < 1 || levels > MaxLevels {
+		return nil, fmt.Errorf("levels must be between 1 and %d", MaxLevels)
+	}
+	if hasher == nil {
+		hasher = PoseidonHasher
+	}
+	t := &Tree{levels: levels, hasher: hasher, db: db, root: big.NewInt(0)}
+	b, err := db.Get(rootKey)
+	if err == nil {
+		t.root = decodeElem(b)
+	} else if err != storage.ErrNotFound {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Levels returns the number of levels of the tree.
+func (t *Tree) Levels() int { return t.levels }
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *big.Int { return new(big.Int).Set(t.root) }
+
+// checkRoot checks that the root is empty or a node in the storage.
+func (t *Tree) checkRoot(root *big.Int) error {
+	if _, err := t.get(root); err != nil {
+		return fmt.Errorf("unknown root %s", root)
+	}
+	return nil
+}
+
+// SetRoot moves the tree to a root it had before.
+func (t *Tree) SetRoot(root *big.Int) error {
+	if err := t.checkRoot(root); err != nil {
+		return err
+	}
+	return t.write(func(storage.Batch) (*big.Int, error) {
+		return root, nil
+	})
+}
+
+// Snapshot returns a read-only view of the tree at a root it had before,
+// which is not affected by the later changes of the tree.
+func (t *Tree) Snapshot(root *big.Int) (*Tree, error) {
+	if err := t.checkRoot(root); err != nil {
+		return nil, err
+	}
+	return &Tree{
+		levels:   t.levels,
+		hasher:   t.hasher,
+		db:       t.db,
+		root:     new(big.Int).Set(root),
+		snapshot: true,
+	}, nil
+}
+
+// write runs the update f in a batch, which is committed with the new root
+// returned by f, or rolled back if f fails.
+func (t *Tree) write(f func(b storage.Batch) (*big.Int, error)) error {
+	if t.snapshot {
+		return ErrSnapshot
+	}
+	b := t.db.Batch()
+	root, err := f(b)
+	if err != nil {
+		b.Rollback()
+		return err
+	}
+	b.Put(rootKey, encodeElem(root))
+	if err := b.Commit(); err != nil {
+		return err
+	}
+	t.root = new(big.Int).Set(root)
+	return nil
+}
+
+// path returns the path of the key k in a tree of the given levels: the
+// bits of k from the least significant one.
+func path(levels int, k *big.Int) []bool {
+	p := make([]bool, levels)
+	for i := range p {
+		p[i] = k.Bit(i) == 1
+	}
+	return p
+}
+
+func checkEntry(k, v *big.Int) error {
+	if !utils.CheckBigIntInField(k) || !utils.CheckBigIntInField(v) {
+		return fmt.Errorf("key and value must be inside the finite field")
+	}
+	return nil
+}
+
+func encodeElem(e *big.Int) []byte {
+	b := utils.BigIntLEBytes(e)
+	return b[:]
+}
+
+func decodeElem(b []byte) *big.Int {
+	return utils.SetBigIntFromLEBytes(new(big.Int), b)
+}
+
+// encode returns the encoding of the node in the storage: its type and its
+// children, or its key and value, in 32 bytes little-endian.
+func (n *node) encode() []byte {
+	b := []byte{byte(n.typ)}
+	if n.typ == nodeTypeLeaf {
+		return append(append(b, encodeElem(n.key)...), encodeElem(n.value)...)
+	}
+	return append(append(b, encodeElem(n.left)...), encodeElem(n.right)...)
+}
+
+func decodeNode(b []byte) (*node, error) {
+	if len(b) != 1+2*elemBytesLen {
+		return nil, fmt.Errorf("invalid node length %d", len(b))
+	}
+	a, c := decodeElem(b[1:1+elemBytesLen]), decodeElem(b[1+elemBytesLen:])
+	switch nodeType(b[0]) {
+	case nodeTypeLeaf:
+		return &node{typ: nodeTypeLeaf, key: a, value: c}, nil
+	case nodeTypeMiddle:
+		return &node{typ: nodeTypeMiddle, left: a, right: c}, nil
+	}
+	return nil, fmt.Errorf("invalid node type %d", b[0])
+}
+
+// get returns the node with hash h, or nil if it is empty.
+func (t *Tree) get(h *big.Int) (*node, error) {
+	if h.Sign() == 0 {
+		return nil, nil
+	}
+	b, err := t.db.Get(encodeElem(h))
+	if err == storage.ErrNotFound {
+		return nil, fmt.Errorf("node %s not found", h)
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeNode(b)
+}
+
+// hash returns the hash of the node.
+func (t *Tree) hash(n *node) (*big.Int, error) {
+	if n.typ == nodeTypeLeaf {
+		return t.hasher.HashLeaf(n.key, n.value)
+	}
+	return t.hasher.HashNode(n.left, n.right)
+}
+
+// put writes the node in the batch and returns its hash.
+func (t *Tree) put(b storage.Batch, n *node) (*big.Int, error) {
+	h, err := t.hash(n)
+	if err != nil {
+		return nil, err
+	}
+	b.Put(encodeElem(h), n.encode())
+	return h, nil
+}
+
+func newLeaf(k, v *big.Int) *node {
+	return &node{typ: nodeTypeLeaf, key: new(big.Int).Set(k), value: new(big.Int).Set(v)}
+}
+
+// middle returns the middle node with the child at the side given by right
+// and its sibling.
+func middle(child, sibling *big.Int, right bool) *node {
+	if right {
+		return &node{typ: nodeTypeMiddle, left: sibling, right: child}
+	}
+	return &node{typ: nodeTypeMiddle, left: child, right: sibling}
+}
+
+// walk returns the siblings from the root down to the node found at the path
+// of k, which is nil if it is empty, or a leaf.
+func (t *Tree) walk(k *big.Int) ([]*big.Int, *node, error) {
+	p := path(t.levels, k)
+	var siblings []*big.Int
+	next := t.root
+	for lvl := 0; lvl < t.levels; lvl++ {
+		n, err := t.get(next)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n == nil || n.typ == nodeTypeLeaf {
+			return siblings, n, nil
+		}
+		if p[lvl] {
+			next, siblings = n.right, append(siblings, n.left)
+		} else {
+			next, siblings = n.left, append(siblings, n.right)
+		}
+	}
+	return nil, nil, ErrReachedMaxLevel
+}
+
+// upload hashes the node h at the end of the path of k up to the root with
+// the siblings, and returns the new root.
+func (t *Tree) upload(b storage.Batch, k, h *big.Int, siblings []*big.Int) (*big.Int, error) {
+	p := path(t.levels, k)
+	for lvl := len(siblings) - 1; lvl >= 0; lvl-- {
+		var err error
+		if h, err = t.put(b, middle(h, siblings[lvl], p[lvl])); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Get returns the value of the key k.
+func (t *Tree) Get(k *big.Int) (*big.Int, error) {
+	_, n, err := t.walk(k)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.key.Cmp(k) != 0 {
+		return nil, ErrKeyNotFound
+	}
+	return new(big.Int).Set(n.value), nil
+}
+
+// Add adds the key k with value v to the tree.
+func (t *Tree) Add(k, v *big.Int) error {
+	if err := checkEntry(k, v); err != nil {
+		return err
+	}
+	return t.write(func(b storage.Batch) (*big.Int, error) {
+		return t.add(b, k, v)
+	})
+}
+
+func (t *Tree) add(b storage.Batch, k, v *big.Int) (*big.Int, error) {
+	siblings, n, err := t.walk(k)
+	if err != nil {
+		return nil, err
+	}
+	if n != nil && n.key.Cmp(k) == 0 {
+		return nil, ErrKeyAlreadyExists
+	}
+	h, err := t.put(b, newLeaf(k, v))
+	if err != nil {
+		return nil, err
+	}
+	if n != nil {
+		// push both leaves down until their paths diverge
+		old, err := t.hash(n)
+		if err != nil {
+			return nil, err
+		}
+		pNew, pOld := path(t.levels, k), path(t.levels, n.key)
+		lvl := len(siblings)
+		for {
+			if lvl > t.levels-2 {
+				return nil, ErrReachedMaxLevel
+			}
+			if pNew[lvl] != pOld[lvl] {
+				break
+			}
+			lvl++
+		}
+		if h, err = t.put(b, middle(h, old, pNew[lvl])); err != nil {
+			return nil, err
+		}
+		for lvl--; lvl >= len(siblings); lvl-- {
+			if h, err = t.put(b, middle(h, big.NewInt(0), pNew[lvl])); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t.upload(b, k, h, siblings)
+}
+
+// Update sets the value of the key k to v.
+func (t *Tree) Update(k, v *big.Int) error {
+	if err := checkEntry(k, v); err != nil {
+		return err
+	}
+	return t.write(func(b storage.Batch) (*big.Int, error) {
+		return t.update(b, k, v)
+	})
+}
+
+func (t *Tree) update(b storage.Batch, k, v *big.Int) (*big.Int, error) {
+	siblings, n, err := t.walk(k)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.key.Cmp(k) != 0 {
+		return nil, ErrKeyNotFound
+	}
+	h, err := t.put(b, newLeaf(k, v))
+	if err != nil {
+		return nil, err
+	}
+	return t.upload(b, k, h, siblings)
+}
+
+// Delete removes the key k from the tree.  The tree is left as if the key
+// had never been added: a leaf left without sibling moves up.
+func (t *Tree) Delete(k *big.Int) error {
+	return t.write(func(b storage.Batch) (*big.Int, error) {
+		return t.delete(b, k)
+	})
+}
+
+func (t *Tree) delete(b storage.Batch, k *big.Int) (*big.Int, error) {
+	siblings, n, err := t.walk(k)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.key.Cmp(k) != 0 {
+		return nil, ErrKeyNotFound
+	}
+	h := big.NewInt(0)
+	lvl := len(siblings) - 1
+	// the nearest non-empty sibling moves up while it is a leaf without
+	// sibling
+	for ; lvl >= 0; lvl-- {
+		if siblings[lvl].Sign() == 0 {
+			continue
+		}
+		s, err := t.get(siblings[lvl])
+		if err != nil {
+			return nil, err
+		}
+		if s.typ != nodeTypeLeaf {
+			break
+		}
+		h = siblings[lvl]
+		lvl--
+		for lvl >= 0 && siblings[lvl].Sign() == 0 {
+			lvl--
+		}
+		break
+	}
+	return t.upload(b, k, h, siblings[:lvl+1])
+}
@@ -0,0 +1,230 @@
+package smt
+
+import (
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/merkle/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func leaf(t *testing.T, k, v int64) *big.Int {
+	h, err := PoseidonHasher.HashLeaf(big.NewInt(k), big.NewInt(v))
+	require.Nil(t, err)
+	return h
+}
+
+func mid(t *testing.T, l, r *big.Int) *big.Int {
+	h, err := PoseidonHasher.HashNode(l, r)
+	require.Nil(t, err)
+	return h
+}
+
+func TestTreeLayout(t *testing.T) {
+	tree, err := New(10, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "0", tree.Root().String())
+
+	require.Nil(t, tree.Add(big.NewInt(1), big.NewInt(11)))
+	assert.Equal(t, leaf(t, 1, 11), tree.Root())
+	// pinned roots of PoseidonHasher, Poseidon(k, v, 1, 0, 0) for leaves
+	assert.Equal(t,
+		"20473278327130415373758645412120188683358680753783303008118260615975572257355",
+		tree.Root().String())
+
+	// 2 = 0b10 goes left of 1 = 0b01 at the first level
+	require.Nil(t, tree.Add(big.NewInt(2), big.NewInt(22)))
+	assert.Equal(t, mid(t, leaf(t, 2, 22), leaf(t, 1, 11)), tree.Root())
+
+	// 5 = 0b101 shares the first two levels with 1 = 0b001
+	require.Nil(t, tree.Add(big.NewInt(5), big.NewInt(55)))
+	zero := big.NewInt(0)
+	n := mid(t, leaf(t, 2, 22), mid(t, mid(t, leaf(t, 1, 11), leaf(t, 5, 55)), zero))
+	assert.Equal(t, n, tree.Root())
+	assert.Equal(t,
+		"4879404304431220593967988042647566837027216045834416688647189079657485289929",
+		tree.Root().String())
+
+	require.Nil(t, tree.Update(big.NewInt(5), big.NewInt(56)))
+	assert.Equal(t, mid(t, leaf(t, 2, 22), mid(t, mid(t, leaf(t, 1, 11), leaf(t, 5, 56)), zero)),
+		tree.Root())
+
+	// deleting 1 moves 5 back up next to 2
+	require.Nil(t, tree.Delete(big.NewInt(1)))
+	assert.Equal(t, mid(t, leaf(t, 2, 22), leaf(t, 5, 56)), tree.Root())
+	require.Nil(t, tree.Delete(big.NewInt(2)))
+	assert.Equal(t, leaf(t, 5, 56), tree.Root())
+	require.Nil(t, tree.Delete(big.NewInt(5)))
+	assert.Equal(t, "0", tree.Root().String())
+}
+
+func TestTreeCanonical(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	keys := make([]*big.Int, 64)
+	for i := range keys {
+		keys[i] = big.NewInt(rnd.Int63())
+	}
+
+	tree, err := New(64, nil)
+	require.Nil(t, err)
+	for i, k := range keys {
+		require.Nil(t, tree.Add(k, big.NewInt(int64(i))))
+	}
+	for i := 0; i < len(keys); i += 2 {
+		require.Nil(t, tree.Delete(keys[i]))
+	}
+
+	// the same keys added in reverse order give the same root
+	tree2, err := New(64, nil)
+	require.Nil(t, err)
+	for i := len(keys) - 1; i > 0; i -= 2 {
+		require.Nil(t, tree2.Add(keys[i], big.NewInt(int64(i))))
+	}
+	assert.Equal(t, tree2.Root(), tree.Root())
+
+	for i, k := range keys {
+		v, err := tree.Get(k)
+		if i%2 == 0 {
+			assert.Equal(t, ErrKeyNotFound, err)
+		} else {
+			require.Nil(t, err)
+			assert.Equal(t, int64(i), v.Int64())
+		}
+	}
+}
+
+func TestTreeProofs(t *testing.T) {
+	tree, err := New(40, nil)
+	require.Nil(t, err)
+	for k := int64(0); k < 32; k += 3 {
+		require.Nil(t, tree.Add(big.NewInt(k), big.NewInt(k*k)))
+	}
+	root := tree.Root()
+
+	for k := int64(0); k < 48; k++ {
+		p, err := tree.GenerateProof(big.NewInt(k))
+		require.Nil(t, err)
+		member := k < 32 && k%3 == 0
+		assert.Equal(t, member, p.Existence)
+		assert.True(t, VerifyProof(nil, root, p, big.NewInt(k), big.NewInt(k*k)))
+		if member {
+			assert.False(t, VerifyProof(nil, root, p, big.NewInt(k), big.NewInt(k*k+1)))
+		}
+
+		var p2 Proof
+		text, err := p.MarshalText()
+		require.Nil(t, err)
+		require.Nil(t, p2.UnmarshalText(text))
+		assert.Equal(t, p.Bytes(), p2.Bytes())
+		assert.Equal(t, p.AllSiblings(), p2.AllSiblings())
+		assert.True(t, VerifyProof(nil, root, &p2, big.NewInt(k), big.NewInt(k*k)))
+	}
+
+	// a non-membership proof with NodeAux: 1 ends in the leaf of 9
+	p, err := tree.GenerateProof(big.NewInt(1))
+	require.Nil(t, err)
+	require.NotNil(t, p.NodeAux)
+	assert.Equal(t, "9", p.NodeAux.Key.String())
+	assert.Equal(t, byte(0x03), p.Bytes()[0])
+	// it doesn't prove the non-membership of 9
+	assert.False(t, VerifyProof(nil, root, p, big.NewInt(9), big.NewInt(0)))
+	// a membership proof can't be a non-membership proof of another key
+	p, err = tree.GenerateProof(big.NewInt(9))
+	require.Nil(t, err)
+	p.Existence = false
+	assert.False(t, VerifyProof(nil, root, p, big.NewInt(9), big.NewInt(81)))
+}
+
+func TestTreeErrors(t *testing.T) {
+	_, err := New(0, nil)
+	assert.NotNil(t, err)
+	_, err = New(MaxLevels+1, nil)
+	assert.NotNil(t, err)
+
+	tree, err := New(3, nil)
+	require.Nil(t, err)
+	require.Nil(t, tree.Add(big.NewInt(1), big.NewInt(1)))
+	assert.Equal(t, ErrKeyAlreadyExists, tree.Add(big.NewInt(1), big.NewInt(2)))
+	assert.Equal(t, ErrKeyNotFound, tree.Update(big.NewInt(2), big.NewInt(2)))
+	assert.Equal(t, ErrKeyNotFound, tree.Delete(big.NewInt(2)))
+	// 9 = 0b1001 shares the path of 1 in 3 levels
+	assert.Equal(t, ErrReachedMaxLevel, tree.Add(big.NewInt(9), big.NewInt(1)))
+	assert.NotNil(t, tree.Add(constants.Q, big.NewInt(1)))
+	assert.NotNil(t, tree.Add(big.NewInt(2), constants.Q))
+
+	_, err = NewProofFromBytes(make([]byte, 31))
+	assert.NotNil(t, err)
+	bs := make([]byte, 32)
+	bs[31] = 1 // a non-empty sibling at level 0 of a proof of depth 0
+	_, err = NewProofFromBytes(append(bs, make([]byte, 32)...))
+	assert.NotNil(t, err)
+	bs[1] = 250
+	_, err = NewProofFromBytes(bs)
+	assert.NotNil(t, err)
+}
+
+func TestTreeSetRoot(t *testing.T) {
+	tree, err := New(10, nil)
+	require.Nil(t, err)
+	require.Nil(t, tree.Add(big.NewInt(1), big.NewInt(1)))
+	snapshot := tree.Root()
+	require.Nil(t, tree.Add(big.NewInt(2), big.NewInt(2)))
+
+	require.Nil(t, tree.SetRoot(snapshot))
+	_, err = tree.Get(big.NewInt(2))
+	assert.Equal(t, ErrKeyNotFound, err)
+	assert.NotNil(t, tree.SetRoot(big.NewInt(12345)))
+}
+
+func TestTreeStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smt")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	path := filepath.Join(dir, "tree")
+
+	db, err := storage.OpenFile(path)
+	require.Nil(t, err)
+	tree, err := NewWithStorage(db, 32, nil)
+	require.Nil(t, err)
+	for k := int64(0); k < 10; k++ {
+		require.Nil(t, tree.Add(big.NewInt(k), big.NewInt(k+100)))
+	}
+	snapshot := tree.Root()
+	require.Nil(t, tree.Delete(big.NewInt(3)))
+	root := tree.Root()
+	// a failed update leaves the tree as it was
+	assert.Equal(t, ErrKeyAlreadyExists, tree.Add(big.NewInt(4), big.NewInt(1)))
+	require.Nil(t, db.Close())
+
+	db, err = storage.OpenFile(path)
+	require.Nil(t, err)
+	defer db.Close() //nolint:errcheck
+	tree, err = NewWithStorage(db, 32, nil)
+	require.Nil(t, err)
+	assert.Equal(t, root, tree.Root())
+	v, err := tree.Get(big.NewInt(7))
+	require.Nil(t, err)
+	assert.Equal(t, "107", v.String())
+	p, err := tree.GenerateProof(big.NewInt(3))
+	require.Nil(t, err)
+	assert.False(t, p.Existence)
+	assert.True(t, VerifyProof(nil, root, p, big.NewInt(3), big.NewInt(0)))
+
+	// a snapshot of the tree before the deletion
+	snap, err := tree.Snapshot(snapshot)
+	require.Nil(t, err)
+	v, err = snap.Get(big.NewInt(3))
+	require.Nil(t, err)
+	assert.Equal(t, "103", v.String())
+	assert.Equal(t, ErrSnapshot, snap.Add(big.NewInt(20), big.NewInt(1)))
+	require.Nil(t, tree.Add(big.NewInt(20), big.NewInt(1)))
+	assert.Equal(t, snapshot, snap.Root())
+	_, err = tree.Snapshot(big.NewInt(1))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,91 @@
+package sss
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/ff"
+)
+
+// evalElement evaluates the polynomial with the coefficients at x.
+func evalElement(coefs []*ff.Element, x int) *ff.Element {
+	xe := ff.NewElement().SetUint64(uint64(x))
+	r := ff.NewElement()
+	for i := len(coefs) - 1; i >= 0; i-- {
+		r.Mul(r, xe)
+		r.Add(r, coefs[i])
+	}
+	return r
+}
+
+// interpolateElement evaluates at x the polynomial of degree len(xs) - 1
+// that passes through the points (xs[i], ys[i]), with Lagrange
+// interpolation.  The xs must be distinct.
+func interpolateElement(xs []int, ys []*ff.Element, x int) *ff.Element {
+	xe := ff.NewElement().SetUint64(uint64(x))
+	r := ff.NewElement()
+	for i := range xs {
+		xi := ff.NewElement().SetUint64(uint64(xs[i]))
+		num := ff.NewElement().SetOne()
+		den := ff.NewElement().SetOne()
+		for j := range xs {
+			if j == i {
+				continue
+			}
+			xj := ff.NewElement().SetUint64(uint64(xs[j]))
+			num.Mul(num, ff.NewElement().Sub(xe, xj))
+			den.Mul(den, ff.NewElement().Sub(xi, xj))
+		}
+		num.Div(num, den)
+		r.Add(r, num.Mul(num, ys[i]))
+	}
+	return r
+}
+
+// SplitElement splits the secret, an element of the field Q, into n shares,
+// any threshold of which recover it.
+func SplitElement(secret *ff.Element, threshold, n int) ([]*Share, error) {
+	if err := checkSplit(threshold, n); err != nil {
+		return nil, err
+	}
+	coefs := []*ff.Element{ff.NewElement().Set(secret)}
+	for i := 1; i < threshold; i++ {
+		a, err := rand.Int(rand.Reader, constants.Q)
+		if err != nil {
+			return nil, err
+		}
+		coefs = append(coefs, ff.NewElement().SetBigInt(a))
+	}
+	shares := make([]*Share, n)
+	for i := range shares {
+		v := big.NewInt(0)
+		evalElement(coefs, i+1).ToBigIntRegular(v)
+		shares[i] = &Share{Field: FieldQ, Index: i + 1, Threshold: threshold, Value: v}
+	}
+	return shares, nil
+}
+
+// CombineElement recovers the secret from the shares of a SplitElement.  It
+// returns error if there are fewer shares than the threshold, if some shares
+// are duplicated or malformed, or if the shares beyond the threshold are
+// inconsistent with the others.
+func CombineElement(shares []*Share) (*ff.Element, error) {
+	if err := checkShares(shares, FieldQ); err != nil {
+		return nil, err
+	}
+	xs := make([]int, len(shares))
+	ys := make([]*ff.Element, len(shares))
+	for i, s := range shares {
+		xs[i] = s.Index
+		ys[i] = ff.NewElement().SetBigInt(s.Value)
+	}
+	t := shares[0].Threshold
+	for i := t; i < len(shares); i++ {
+		if !interpolateElement(xs[:t], ys[:t], xs[i]).Equal(ys[i]) {
+			return nil, fmt.Errorf("share %d inconsistent with the others", xs[i])
+		}
+	}
+	return interpolateElement(xs[:t], ys[:t], 0), nil
+}
@@ -0,0 +1,124 @@
+package sss
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/ff"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// halfLen is the number of bytes of each half of a private key seed.
+const halfLen = 16
+
+// PrivateKeyShare is a share of a babyjub.PrivateKey seed.  As the 32 byte
+// seed doesn't fit in a field element, each 16 byte half, read as a
+// little-endian integer, is shared over FieldQ with its own polynomial, and
+// the share holds the shares of both halves with the same index.
+//
+// The seed is shared as is, so the recovered private key signs exactly as the
+// original one.  To share only the scalar of the key, with verification
+// commitments, use SplitScalar with the scalar modulo SubOrder.
+type PrivateKeyShare [2]*Share
+
+// Index returns the index of the share.
+func (ks PrivateKeyShare) Index() int {
+	return ks[0].Index
+}
+
+// SplitPrivateKey splits the seed of the private key into n shares, any
+// threshold of which recover it.
+func SplitPrivateKey(sk *babyjub.PrivateKey, threshold, n int) ([]PrivateKeyShare, error) {
+	var halves [2][]*Share
+	for h := range halves {
+		v := utils.SetBigIntFromLEBytes(new(big.Int), sk[h*halfLen:(h+1)*halfLen])
+		var err error
+		if halves[h], err = SplitElement(ff.NewElement().SetBigInt(v), threshold, n); err != nil {
+			return nil, err
+		}
+	}
+	shares := make([]PrivateKeyShare, n)
+	for i := range shares {
+		shares[i] = PrivateKeyShare{halves[0][i], halves[1][i]}
+	}
+	return shares, nil
+}
+
+// CombinePrivateKey recovers the private key seed from the shares of a
+// SplitPrivateKey, with the same checks as CombineElement.
+func CombinePrivateKey(shares []PrivateKeyShare) (*babyjub.PrivateKey, error) {
+	var halves [2][]*Share
+	for _, ks := range shares {
+		if ks[0] == nil || ks[1] == nil || ks[0].Index != ks[1].Index {
+			return nil, fmt.Errorf("malformed private key share")
+		}
+		halves[0] = append(halves[0], ks[0])
+		halves[1] = append(halves[1], ks[1])
+	}
+	var sk babyjub.PrivateKey
+	maxHalf := new(big.Int).Lsh(big.NewInt(1), 8*halfLen) //nolint:gomnd
+	for h := range halves {
+		e, err := CombineElement(halves[h])
+		if err != nil {
+			return nil, err
+		}
+		v := big.NewInt(0)
+		e.ToBigIntRegular(v)
+		if v.Cmp(maxHalf) >= 0 {
+			return nil, fmt.Errorf("recovered seed half out of range, inconsistent shares")
+		}
+		b := utils.BigIntLEBytes(v)
+		copy(sk[h*halfLen:(h+1)*halfLen], b[:halfLen])
+	}
+	return &sk, nil
+}
+
+// Bytes returns the encoding of the share: the encodings of the shares of
+// the two halves.
+func (ks PrivateKeyShare) Bytes() []byte {
+	return append(ks[0].Bytes(), ks[1].Bytes()...)
+}
+
+// ParsePrivateKeyShare decodes a share encoded with PrivateKeyShare.Bytes.
+func ParsePrivateKeyShare(b []byte) (PrivateKeyShare, error) {
+	var ks PrivateKeyShare
+	if len(b) != 2*ShareLen { //nolint:gomnd
+		return ks, fmt.Errorf("invalid private key share length %d, want %d", len(b), 2*ShareLen)
+	}
+	for h := range ks {
+		var err error
+		if ks[h], err = ParseShare(b[h*ShareLen : (h+1)*ShareLen]); err != nil {
+			return ks, err
+		}
+		if ks[h].Field != FieldQ {
+			return ks, fmt.Errorf("private key share not of FieldQ")
+		}
+	}
+	if ks[0].Index != ks[1].Index || ks[0].Threshold != ks[1].Threshold {
+		return ks, fmt.Errorf("inconsistent private key share halves")
+	}
+	return ks, nil
+}
+
+// MarshalText implements the marshaler for the PrivateKeyShare
+func (ks PrivateKeyShare) MarshalText() ([]byte, error) {
+	return utils.Hex(ks.Bytes()).MarshalText()
+}
+
+// String returns the string representation of the PrivateKeyShare
+func (ks PrivateKeyShare) String() string { return utils.Hex(ks.Bytes()).String() }
+
+// UnmarshalText implements the unmarshaler for the PrivateKeyShare
+func (ks *PrivateKeyShare) UnmarshalText(h []byte) error {
+	b := make([]byte, 2*ShareLen) //nolint:gomnd
+	if err := utils.HexDecodeInto(b, h); err != nil {
+		return err
+	}
+	ks2, err := ParsePrivateKeyShare(b)
+	if err != nil {
+		return err
+	}
+	*ks = ks2
+	return nil
+}
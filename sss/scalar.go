@@ -0,0 +1,99 @@
+package sss
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+)
+
+// Commitments are the Feldman verification commitments of a sharing over
+// FieldSubOrder: the points a_k * B8 of the coefficients a_k of the
+// polynomial.  The first commitment is the public key of the secret.
+type Commitments []*babyjub.Point
+
+// PublicKey returns the public key secret * B8 of the shared secret.
+func (cs Commitments) PublicKey() *babyjub.PublicKey {
+	pk := babyjub.PublicKey(*babyjub.NewPoint().Set(cs[0]))
+	return &pk
+}
+
+// Verify checks that the share is consistent with the commitments:
+// Value * B8 == sum(C_k * Index^k).
+func (cs Commitments) Verify(s *Share) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	if s.Field != FieldSubOrder {
+		return fmt.Errorf("share of field %d, want %d", s.Field, FieldSubOrder)
+	}
+	if s.Threshold != len(cs) {
+		return fmt.Errorf("share threshold %d, %d commitments", s.Threshold, len(cs))
+	}
+	x := big.NewInt(int64(s.Index))
+	xk := big.NewInt(1)
+	acc := babyjub.NewPointProjective()
+	for _, c := range cs {
+		acc = babyjub.NewPointProjective().Add(acc, babyjub.NewPoint().Mul(xk, c).Projective())
+		xk = new(big.Int).Mul(xk, x)
+		xk.Mod(xk, babyjub.SubOrder)
+	}
+	if !babyjub.NewPoint().Mul(s.Value, babyjub.B8).Equal(acc.Affine()) {
+		return fmt.Errorf("share %d inconsistent with the commitments", s.Index)
+	}
+	return nil
+}
+
+// SplitScalar splits the secret, a scalar in [0, SubOrder), into n shares,
+// any threshold of which recover it, and returns the shares together with
+// their Feldman verification commitments.
+func SplitScalar(secret *big.Int, threshold, n int) ([]*Share, Commitments, error) {
+	if err := checkSplit(threshold, n); err != nil {
+		return nil, nil, err
+	}
+	if secret.Sign() < 0 || secret.Cmp(babyjub.SubOrder) >= 0 {
+		return nil, nil, fmt.Errorf("secret not in [0, SubOrder)")
+	}
+	coefs := []*big.Int{new(big.Int).Set(secret)}
+	for i := 1; i < threshold; i++ {
+		a, err := rand.Int(rand.Reader, babyjub.SubOrder)
+		if err != nil {
+			return nil, nil, err
+		}
+		coefs = append(coefs, a)
+	}
+	cs := make(Commitments, threshold)
+	for i, a := range coefs {
+		cs[i] = babyjub.NewPoint().Mul(a, babyjub.B8)
+	}
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{Field: FieldSubOrder, Index: i + 1, Threshold: threshold,
+			Value: babyjub.EvalPolynomial(coefs, i+1)}
+	}
+	return shares, cs, nil
+}
+
+// CombineScalar recovers the secret from the shares of a SplitScalar.  It
+// returns error if there are fewer shares than the threshold, if some shares
+// are duplicated or malformed, or if the shares beyond the threshold are
+// inconsistent with the others.  Use Commitments.Verify to identify invalid
+// shares.
+func CombineScalar(shares []*Share) (*big.Int, error) {
+	if err := checkShares(shares, FieldSubOrder); err != nil {
+		return nil, err
+	}
+	xs := make([]int, len(shares))
+	ys := make([]*big.Int, len(shares))
+	for i, s := range shares {
+		xs[i], ys[i] = s.Index, s.Value
+	}
+	t := shares[0].Threshold
+	for i := t; i < len(shares); i++ {
+		if babyjub.Interpolate(xs[:t], ys[:t], xs[i]).Cmp(ys[i]) != 0 {
+			return nil, fmt.Errorf("share %d inconsistent with the others", xs[i])
+		}
+	}
+	return babyjub.Interpolate(xs[:t], ys[:t], 0), nil
+}
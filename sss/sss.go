@@ -0,0 +1,167 @@
+// Package sss implements Shamir secret sharing over the SNARK field Q, with
+// ff.Element arithmetic, and over the order of the BabyJubJub subgroup, with
+// Feldman verification commitments using babyjub.B8.
+//
+// The shares of a secret are the evaluations at the indexes 1 to n of a
+// random polynomial of degree threshold - 1 whose constant term is the
+// secret, so any threshold shares recover the secret with Lagrange
+// interpolation while fewer shares reveal nothing about it.
+package sss
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/utils"
+)
+
+// Field identifies the field of a sharing.
+type Field uint8
+
+const (
+	// FieldQ is the SNARK scalar field Q.
+	FieldQ Field = iota
+	// FieldSubOrder is the field of the order of the BabyJubJub subgroup.
+	FieldSubOrder
+)
+
+// Modulus returns the modulus of the field.
+func (f Field) Modulus() *big.Int {
+	switch f {
+	case FieldQ:
+		return constants.Q
+	case FieldSubOrder:
+		return babyjub.SubOrder
+	}
+	return nil
+}
+
+// MaxShares is the maximum number of shares, and of the threshold, as both
+// are encoded in a byte.
+const MaxShares = 255
+
+// ShareLen is the length of an encoded share: the field, the index and the
+// threshold as bytes, the value as 32 bytes little-endian, and the checksum.
+const ShareLen = 3 + 32 + checksumLen
+
+const checksumLen = 4
+
+// Share is a share of a secret.
+type Share struct {
+	Field     Field
+	Index     int
+	Threshold int
+	Value     *big.Int
+}
+
+// check returns error if the share is not well formed.
+func (s *Share) check() error {
+	mod := s.Field.Modulus()
+	if mod == nil {
+		return fmt.Errorf("unknown field %d", s.Field)
+	}
+	if s.Index < 1 || s.Index > MaxShares {
+		return fmt.Errorf("invalid share index %d", s.Index)
+	}
+	if s.Threshold < 1 || s.Threshold > MaxShares {
+		return fmt.Errorf("invalid threshold %d", s.Threshold)
+	}
+	if s.Value == nil || s.Value.Sign() < 0 || s.Value.Cmp(mod) >= 0 {
+		return fmt.Errorf("share value not in the field")
+	}
+	return nil
+}
+
+// Bytes returns the encoding of the share: the field, the index, the
+// threshold, the value as 32 bytes little-endian, and the first 4 bytes of
+// the SHA-256 of all the previous bytes as checksum.
+func (s *Share) Bytes() []byte {
+	b := make([]byte, 0, ShareLen)
+	b = append(b, byte(s.Field), byte(s.Index), byte(s.Threshold))
+	v := utils.BigIntLEBytes(s.Value)
+	b = append(b, v[:]...)
+	h := sha256.Sum256(b)
+	return append(b, h[:checksumLen]...)
+}
+
+// ParseShare decodes a share encoded with Bytes, checking its checksum.
+func ParseShare(b []byte) (*Share, error) {
+	if len(b) != ShareLen {
+		return nil, fmt.Errorf("invalid share length %d, want %d", len(b), ShareLen)
+	}
+	h := sha256.Sum256(b[:ShareLen-checksumLen])
+	if !bytes.Equal(h[:checksumLen], b[ShareLen-checksumLen:]) {
+		return nil, fmt.Errorf("invalid share checksum")
+	}
+	s := &Share{
+		Field:     Field(b[0]),
+		Index:     int(b[1]),
+		Threshold: int(b[2]),
+		Value:     utils.SetBigIntFromLEBytes(new(big.Int), b[3:35]),
+	}
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MarshalText implements the marshaler for the Share
+func (s Share) MarshalText() ([]byte, error) {
+	return utils.Hex(s.Bytes()).MarshalText()
+}
+
+// String returns the string representation of the Share
+func (s Share) String() string { return utils.Hex(s.Bytes()).String() }
+
+// UnmarshalText implements the unmarshaler for the Share
+func (s *Share) UnmarshalText(h []byte) error {
+	b := make([]byte, ShareLen)
+	if err := utils.HexDecodeInto(b, h); err != nil {
+		return err
+	}
+	s2, err := ParseShare(b)
+	if err != nil {
+		return err
+	}
+	*s = *s2
+	return nil
+}
+
+func checkSplit(threshold, n int) error {
+	if threshold < 1 || threshold > n || n > MaxShares {
+		return fmt.Errorf("invalid threshold %d of %d shares", threshold, n)
+	}
+	return nil
+}
+
+// checkShares checks that the shares are well formed, of the field, with the
+// same threshold, with unique indexes, and at least threshold of them.
+func checkShares(shares []*Share, field Field) error {
+	if len(shares) == 0 {
+		return fmt.Errorf("no shares")
+	}
+	seen := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		if err := s.check(); err != nil {
+			return err
+		}
+		if s.Field != field {
+			return fmt.Errorf("share of field %d, want %d", s.Field, field)
+		}
+		if s.Threshold != shares[0].Threshold {
+			return fmt.Errorf("inconsistent thresholds %d and %d", s.Threshold, shares[0].Threshold)
+		}
+		if seen[s.Index] {
+			return fmt.Errorf("duplicated share index %d", s.Index)
+		}
+		seen[s.Index] = true
+	}
+	if len(shares) < shares[0].Threshold {
+		return fmt.Errorf("%d shares, threshold is %d", len(shares), shares[0].Threshold)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package sss
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/iden3/go-iden3-crypto/ff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombineElement(t *testing.T) {
+	secret := ff.NewElement().SetRandom()
+	shares, err := SplitElement(secret, 3, 5)
+	require.Nil(t, err)
+	require.Equal(t, 5, len(shares))
+
+	for _, subset := range [][]*Share{
+		shares[:3],
+		{shares[4], shares[0], shares[2]},
+		shares,
+	} {
+		s, err := CombineElement(subset)
+		require.Nil(t, err)
+		assert.True(t, s.Equal(secret))
+	}
+
+	// not enough shares
+	_, err = CombineElement(shares[:2])
+	assert.NotNil(t, err)
+	// duplicated share
+	_, err = CombineElement([]*Share{shares[0], shares[1], shares[1]})
+	assert.NotNil(t, err)
+	// share of another field
+	_, err = CombineScalar(shares[:3])
+	assert.NotNil(t, err)
+	// inconsistent share beyond the threshold
+	bad := *shares[4]
+	bad.Value = new(big.Int).Add(bad.Value, big.NewInt(1))
+	bad.Value.Mod(bad.Value, constants.Q)
+	_, err = CombineElement([]*Share{shares[0], shares[1], shares[2], &bad})
+	assert.NotNil(t, err)
+	// inconsistent threshold
+	bad = *shares[3]
+	bad.Threshold = 2
+	_, err = CombineElement([]*Share{shares[0], shares[1], shares[2], &bad})
+	assert.NotNil(t, err)
+
+	_, err = SplitElement(secret, 0, 5)
+	assert.NotNil(t, err)
+	_, err = SplitElement(secret, 6, 5)
+	assert.NotNil(t, err)
+	_, err = SplitElement(secret, 2, 256)
+	assert.NotNil(t, err)
+}
+
+func TestSplitCombineScalar(t *testing.T) {
+	sk := babyjub.NewRandPrivKey()
+	secret := new(big.Int).Mod(sk.Scalar().BigInt(), babyjub.SubOrder)
+	shares, cs, err := SplitScalar(secret, 2, 4)
+	require.Nil(t, err)
+	assert.True(t, cs.PublicKey().Point().Equal(sk.Public().Point()))
+
+	for _, s := range shares {
+		assert.Nil(t, cs.Verify(s))
+	}
+	s, err := CombineScalar([]*Share{shares[3], shares[1]})
+	require.Nil(t, err)
+	assert.Equal(t, secret, s)
+	s, err = CombineScalar(shares)
+	require.Nil(t, err)
+	assert.Equal(t, secret, s)
+
+	// a modified share fails the Feldman verification
+	bad := *shares[2]
+	bad.Value = new(big.Int).Add(bad.Value, big.NewInt(1))
+	assert.NotNil(t, cs.Verify(&bad))
+	_, err = CombineScalar([]*Share{shares[0], shares[1], &bad})
+	assert.NotNil(t, err)
+
+	_, _, err = SplitScalar(babyjub.SubOrder, 2, 4)
+	assert.NotNil(t, err)
+}
+
+func TestShareEncoding(t *testing.T) {
+	shares, _, err := SplitScalar(big.NewInt(12345), 2, 3)
+	require.Nil(t, err)
+	s := shares[1]
+	b := s.Bytes()
+	assert.Equal(t, ShareLen, len(b))
+	assert.Equal(t, []byte{byte(FieldSubOrder), 2, 2}, b[:3])
+
+	s2, err := ParseShare(b)
+	require.Nil(t, err)
+	assert.Equal(t, s, s2)
+
+	text, err := s.MarshalText()
+	require.Nil(t, err)
+	var s3 Share
+	require.Nil(t, s3.UnmarshalText(text))
+	assert.Equal(t, s, &s3)
+
+	// corrupted share
+	b[10] ^= 1
+	_, err = ParseShare(b)
+	assert.NotNil(t, err)
+	_, err = ParseShare(b[:ShareLen-1])
+	assert.NotNil(t, err)
+
+	// known encoding
+	s4 := &Share{Field: FieldQ, Index: 1, Threshold: 2, Value: big.NewInt(1)}
+	assert.Equal(t, "00010201000000000000000000000000000000000000000000000000000000000000002fa2f07a",
+		s4.String())
+}
+
+func TestSplitCombinePrivateKey(t *testing.T) {
+	var sk babyjub.PrivateKey
+	for i := range sk {
+		sk[i] = 0xff
+	}
+	for _, key := range []babyjub.PrivateKey{sk, babyjub.NewRandPrivKey()} {
+		shares, err := SplitPrivateKey(&key, 3, 5)
+		require.Nil(t, err)
+
+		recovered, err := CombinePrivateKey([]PrivateKeyShare{shares[1], shares[3], shares[4]})
+		require.Nil(t, err)
+		assert.Equal(t, key, *recovered)
+
+		text, err := shares[2].MarshalText()
+		require.Nil(t, err)
+		var ks PrivateKeyShare
+		require.Nil(t, ks.UnmarshalText(text))
+		assert.Equal(t, 3, ks.Index())
+		recovered, err = CombinePrivateKey([]PrivateKeyShare{shares[0], ks, shares[4]})
+		require.Nil(t, err)
+		assert.Equal(t, key, *recovered)
+
+		_, err = CombinePrivateKey(shares[:2])
+		assert.NotNil(t, err)
+		_, err = CombinePrivateKey([]PrivateKeyShare{{shares[0][0], shares[1][1]},
+			shares[2], shares[3]})
+		assert.NotNil(t, err)
+	}
+}